@@ -375,4 +375,44 @@ var contentType = map[string]string{
 	".ipa":     "application/vnd.iphone",
 	".apk":     "application/vnd.android.package-archive",
 	".xap":     "application/x-silverlight-app",
+	".zip":     "application/zip",
+	".gz":      "application/gzip",
+	".tgz":     "application/gzip",
+	".bz2":     "application/x-bzip2",
+	".xz":      "application/x-xz",
+	".zst":     "application/zstd",
+	".tar":     "application/x-tar",
+	".7z":      "application/x-7z-compressed",
+	".rar":     "application/vnd.rar",
+	".jar":     "application/java-archive",
+	".epub":    "application/epub+zip",
+	".odt":     "application/vnd.oasis.opendocument.text",
+	".ods":     "application/vnd.oasis.opendocument.spreadsheet",
+	".odp":     "application/vnd.oasis.opendocument.presentation",
+	".webp":    "image/webp",
+	".heic":    "image/heic",
+	".avif":    "image/avif",
+	".psd":     "image/vnd.adobe.photoshop",
+	".flac":    "audio/flac",
+	".ogg":     "audio/ogg",
+	".oga":     "audio/ogg",
+	".m4a":     "audio/mp4",
+	".aac":     "audio/aac",
+	".webm":    "video/webm",
+	".mkv":     "video/x-matroska",
+	".mov":     "video/quicktime",
+	".m4v":     "video/mp4",
+	".flv":     "video/x-flv",
+	".3gp":     "video/3gpp",
+	".json":    "application/json",
+	".csv":     "text/csv",
+	".md":      "text/markdown",
+	".yaml":    "text/yaml",
+	".yml":     "text/yaml",
+	".sh":      "text/x-shellscript",
+	".wasm":    "application/wasm",
+	".woff":    "font/woff",
+	".woff2":   "font/woff2",
+	".ttf":     "font/ttf",
+	".otf":     "font/otf",
 }
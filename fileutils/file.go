@@ -64,19 +64,11 @@ func CreateDir(dirPath string, perms ...os.FileMode) error {
 	return nil
 }
 
-//写入文本文件内容
+//写入文本文件内容，原子写入，中途失败时文件保持原内容
+//文件已存在时保留原权限，否则权限为DefaultFilePerm
 // @param force 文件夹不存在时自动创建
 func WriteFile(filePath string, body string, forces ...bool) error {
-	if len(forces) > 0 && forces[0] {
-		dir := strings.Replace(filePath, `\`, "/", -1)
-		index := strings.LastIndex(dir, "/")
-		dir = dir[:index]
-		err := CreateDir(dir)
-		if err != nil {
-			return err
-		}
-	}
-	return ioutil.WriteFile(filePath, []byte(body), 0777)
+	return WriteBytes(filePath, []byte(body), WriteOptions{CreateDir: len(forces) > 0 && forces[0]})
 }
 
 //读取文本文件内容
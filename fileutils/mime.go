@@ -0,0 +1,535 @@
+package fileutils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 识别文件类型时读取的文件头长度，OOXML等zip格式需要读取多个条目的文件头
+const SniffLen = 8192
+
+const (
+	mimeOctetStream = "application/octet-stream"
+	mimeZip         = "application/zip"
+	mimeOle         = "application/x-ole-storage" //doc、xls、ppt、msi等旧版Office复合文档
+	mimeJar         = "application/java-archive"
+	mimeText        = "text/plain"
+)
+
+//ContentTypeResult 综合文件内容与扩展名的识别结果
+type ContentTypeResult struct {
+	ContentType string //最终判定的类型：两者一致时取更具体的一个，不一致时以内容为准
+	ByContent   string //根据内容识别的类型，无法识别时为空
+	ByExt       string //根据扩展名得到的类型，未知扩展名时为空
+	Mismatch    bool   //内容与扩展名不一致，如把可执行文件或html改名为图片
+}
+
+// 文件头特征，offset为特征在文件中的位置
+type magic struct {
+	offset int
+	sig    string
+	mime   string
+}
+
+var magics = []magic{
+	// 图片
+	{0, "\xFF\xD8\xFF", "image/jpeg"},
+	{0, "\x89PNG\r\n\x1a\n", "image/png"},
+	{0, "GIF87a", "image/gif"},
+	{0, "GIF89a", "image/gif"},
+	{0, "BM", "image/bmp"},
+	{0, "II*\x00", "image/tiff"},
+	{0, "MM\x00*", "image/tiff"},
+	{0, "\x00\x00\x01\x00", "image/x-icon"},
+	{0, "8BPS", "image/vnd.adobe.photoshop"},
+	// 文档
+	{0, "%PDF-", "application/pdf"},
+	{0, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1", mimeOle},
+	{0, "{\\rtf", "application/rtf"},
+	// 压缩包
+	{0, "PK\x03\x04", mimeZip},
+	{0, "PK\x05\x06", mimeZip},
+	{0, "\x1F\x8B", "application/gzip"},
+	{0, "BZh", "application/x-bzip2"},
+	{0, "\xFD7zXZ\x00", "application/x-xz"},
+	{0, "7z\xBC\xAF\x27\x1C", "application/x-7z-compressed"},
+	{0, "Rar!\x1A\x07", "application/vnd.rar"},
+	{0, "\x28\xB5\x2F\xFD", "application/zstd"},
+	{257, "ustar", "application/x-tar"},
+	// 音频
+	{0, "ID3", "audio/mpeg"},
+	{0, "fLaC", "audio/flac"},
+	{0, "OggS", "audio/ogg"},
+	{0, "MThd", "audio/midi"},
+	{0, "#!AMR", "audio/amr"},
+	// 视频
+	{0, "FLV\x01", "video/x-flv"},
+	{0, "\x00\x00\x01\xBA", "video/mpeg"},
+	{0, "\x00\x00\x01\xB3", "video/mpeg"},
+	{0, "\x30\x26\xB2\x75\x8E\x66\xCF\x11", "video/x-ms-asf"},
+	// 可执行文件
+	{0, "MZ", "application/x-msdownload"},
+	{0, "\x7FELF", "application/x-executable"},
+	{0, "\xCF\xFA\xED\xFE", "application/x-mach-binary"},
+	{0, "\xFE\xED\xFA\xCF", "application/x-mach-binary"},
+	{0, "\xCA\xFE\xBA\xBE", "application/x-mach-binary"},
+	{0, "\x00asm", "application/wasm"},
+	{0, "#!", "text/x-shellscript"},
+}
+
+//DetectContentType 根据文件内容识别ContentType，data为文件开头部分，建议不少于SniffLen字节
+//支持常见的图片、PDF、Office/OOXML、压缩包、音视频及可执行文件，其余按文本或html识别，无法识别时返回application/octet-stream
+func DetectContentType(data []byte) string {
+	if typ := sniff(data); typ != "" {
+		return typ
+	}
+	return mimeOctetStream
+}
+
+//DetectFileContentType 根据文件内容识别ContentType，zip格式会读取目录以区分docx、xlsx、jar等
+func DetectFileContentType(filePath string) (string, error) {
+	typ, err := sniffFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	if typ == "" {
+		return mimeOctetStream, nil
+	}
+	return typ, nil
+}
+
+//DetectReaderContentType 读取r的开头部分识别ContentType，返回的reader包含完整的内容，用于上传等只能读取一次的数据流
+func DetectReaderContentType(r io.Reader) (string, io.Reader, error) {
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
+	return DetectContentType(head), io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+//CheckContentType 综合文件名的扩展名与文件内容判断ContentType，并检查两者是否一致
+//data为文件开头部分，建议不少于SniffLen字节
+func CheckContentType(fileName string, data []byte) ContentTypeResult {
+	return checkContentType(fileName, sniff(data))
+}
+
+//CheckFileContentType 综合扩展名与文件内容判断ContentType，并检查两者是否一致
+func CheckFileContentType(filePath string) (ContentTypeResult, error) {
+	typ, err := sniffFile(filePath)
+	if err != nil {
+		return ContentTypeResult{}, err
+	}
+	return checkContentType(filePath, typ), nil
+}
+
+//DetectFileType 根据文件内容与扩展名获取文件类型，取值同GetFileType
+func DetectFileType(filePath string) (string, error) {
+	res, err := CheckFileContentType(filePath)
+	if err != nil {
+		return "", err
+	}
+	typ := mimeBase(res.ContentType)
+	switch {
+	case strings.HasPrefix(typ, "image/") || typ == "application/x-bmp":
+		return "image", nil
+	case strings.HasPrefix(typ, "video/"):
+		return "video", nil
+	case strings.HasPrefix(typ, "audio/"):
+		return "audio", nil
+	case officeTypes[typ]:
+		return "text", nil
+	}
+	return "file", nil
+}
+
+func checkContentType(fileName string, byContent string) ContentTypeResult {
+	res := ContentTypeResult{ByContent: byContent}
+	if ext := strings.ToLower(filepath.Ext(fileName)); ext != "" {
+		res.ByExt = contentType[ext]
+	}
+	switch {
+	case res.ByExt == "" && byContent == "":
+		res.ContentType = mimeOctetStream
+	case res.ByExt == "":
+		res.ContentType = byContent
+	case byContent == "":
+		// 内容无法识别，但扩展名对应的格式有明确的文件头
+		res.Mismatch = sniffable(res.ByExt)
+		res.ContentType = res.ByExt
+		if res.Mismatch {
+			res.ContentType = mimeOctetStream
+		}
+	case compatibleMime(res.ByExt, byContent):
+		res.ContentType = byContent
+		if genericMime(byContent) {
+			res.ContentType = res.ByExt
+		}
+	default:
+		res.Mismatch = true
+		res.ContentType = byContent
+	}
+	return res
+}
+
+// 按文件头识别，无法识别时返回空字符串
+func sniff(data []byte) string {
+	if typ := sniffMagic(data); typ != "" {
+		if typ == mimeZip {
+			if sub := sniffZipHead(data); sub != "" {
+				return sub
+			}
+		}
+		return typ
+	}
+	if len(data) == 0 {
+		return ""
+	}
+	typ := http.DetectContentType(data)
+	if typ == mimeOctetStream {
+		return ""
+	}
+	if (strings.HasPrefix(typ, "text/xml") || strings.HasPrefix(typ, mimeText)) && isSvg(data) {
+		return "image/svg+xml"
+	}
+	return typ
+}
+
+func sniffMagic(data []byte) string {
+	if len(data) >= 12 {
+		switch string(data[4:8]) {
+		case "ftyp":
+			return ftypMime(string(data[8:12]))
+		}
+		if string(data[:4]) == "RIFF" {
+			switch string(data[8:12]) {
+			case "WEBP":
+				return "image/webp"
+			case "WAVE":
+				return "audio/wav"
+			case "AVI ":
+				return "video/x-msvideo"
+			}
+		}
+	}
+	if bytes.HasPrefix(data, []byte("\x1A\x45\xDF\xA3")) {
+		// EBML，文档类型为webm或matroska
+		head := data
+		if len(head) > 64 {
+			head = head[:64]
+		}
+		if bytes.Contains(head, []byte("webm")) {
+			return "video/webm"
+		}
+		return "video/x-matroska"
+	}
+	for _, m := range magics {
+		if len(data) >= m.offset+len(m.sig) && string(data[m.offset:m.offset+len(m.sig)]) == m.sig {
+			return m.mime
+		}
+	}
+	// 无ID3标签的mp3与ADTS格式的aac以帧同步字开头
+	if len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 {
+		if data[1]&0x06 == 0 {
+			return "audio/aac"
+		}
+		return "audio/mpeg"
+	}
+	return ""
+}
+
+// ISO基础媒体文件格式，按主品牌区分
+func ftypMime(brand string) string {
+	switch {
+	case brand == "qt  ":
+		return "video/quicktime"
+	case brand == "M4A " || brand == "M4B " || brand == "F4A ":
+		return "audio/mp4"
+	case strings.HasPrefix(brand, "3g"):
+		return "video/3gpp"
+	case brand == "avif" || brand == "avis":
+		return "image/avif"
+	case brand == "heic" || brand == "heix" || brand == "hevc" || brand == "hevx" || brand == "mif1" || brand == "msf1":
+		return "image/heic"
+	}
+	return "video/mp4"
+}
+
+// 按zip条目名称区分具体格式，返回空字符串表示普通zip
+func zipEntryMime(name string, content func() []byte) string {
+	switch {
+	case name == "mimetype":
+		// ODF与EPUB的第一个条目为未压缩的mimetype
+		if typ := strings.TrimSpace(string(content())); strings.HasPrefix(typ, "application/") {
+			return typ
+		}
+	case strings.HasPrefix(name, "word/"):
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case strings.HasPrefix(name, "xl/"):
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case strings.HasPrefix(name, "ppt/"):
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	case name == "AndroidManifest.xml":
+		return "application/vnd.android.package-archive"
+	case strings.HasPrefix(name, "Payload/") && strings.Contains(name, ".app/"):
+		return "application/vnd.iphone"
+	}
+	return ""
+}
+
+// 遍历data中的zip本地文件头，遇到使用数据描述符、无法确定长度的条目时停止
+func sniffZipHead(data []byte) string {
+	jar := false
+	for offset := 0; offset+30 <= len(data) && string(data[offset:offset+4]) == "PK\x03\x04"; {
+		flags := binary.LittleEndian.Uint16(data[offset+6:])
+		compressed := int(binary.LittleEndian.Uint32(data[offset+18:]))
+		nameLen := int(binary.LittleEndian.Uint16(data[offset+26:]))
+		extraLen := int(binary.LittleEndian.Uint16(data[offset+28:]))
+		start := offset + 30 + nameLen + extraLen
+		if start > len(data) {
+			break
+		}
+		name := string(data[offset+30 : offset+30+nameLen])
+		if typ := zipEntryMime(name, func() []byte {
+			if start+compressed > len(data) {
+				return nil
+			}
+			return data[start : start+compressed]
+		}); typ != "" {
+			return typ
+		}
+		if name == "META-INF/MANIFEST.MF" {
+			jar = true
+		}
+		if flags&0x08 != 0 {
+			break
+		}
+		offset = start + compressed
+	}
+	if jar {
+		return mimeJar
+	}
+	return ""
+}
+
+// 读取文件头识别，zip格式读取目录中的所有条目名称
+func sniffFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	typ := sniff(head[:n])
+	// 文件头中出现META-INF/MANIFEST.MF时识别为jar，但apk等格式的特征条目可能位于其后，同样需要读取目录
+	if typ != mimeZip && typ != mimeJar {
+		return typ, nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		// 文件头为zip但目录损坏，按文件头的识别结果返回
+		return typ, nil
+	}
+	jar := false
+	for _, file := range zr.File {
+		if sub := zipEntryMime(file.Name, func() []byte { return readZipEntry(file, 256) }); sub != "" {
+			return sub, nil
+		}
+		if file.Name == "META-INF/MANIFEST.MF" {
+			jar = true
+		}
+	}
+	if jar {
+		return mimeJar, nil
+	}
+	return typ, nil
+}
+
+func readZipEntry(file *zip.File, limit int64) []byte {
+	rc, err := file.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(io.LimitReader(rc, limit))
+	return data
+}
+
+// 文本以<开头且包含svg标签
+func isSvg(data []byte) bool {
+	head := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(head, []byte("<")) {
+		return false
+	}
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return bytes.Contains(bytes.ToLower(head), []byte("<svg"))
+}
+
+// 同一格式的不同写法
+var mimeAliases = map[string]string{
+	"image/jpg":                    "image/jpeg",
+	"image/pjpeg":                  "image/jpeg",
+	"image/x-png":                  "image/png",
+	"application/x-bmp":            "image/bmp",
+	"image/x-ms-bmp":               "image/bmp",
+	"image/vnd.microsoft.icon":     "image/x-icon",
+	"audio/mp3":                    "audio/mpeg",
+	"audio/x-mpeg":                 "audio/mpeg",
+	"audio/wav":                    "audio/wav",
+	"audio/x-wav":                  "audio/wav",
+	"audio/mid":                    "audio/midi",
+	"audio/x-flac":                 "audio/flac",
+	"audio/x-m4a":                  "audio/mp4",
+	"video/mpeg4":                  "video/mp4",
+	"video/avi":                    "video/x-msvideo",
+	"video/msvideo":                "video/x-msvideo",
+	"video/mpg":                    "video/mpeg",
+	"video/x-ms-wmv":               "video/x-ms-asf",
+	"audio/x-ms-wma":               "video/x-ms-asf",
+	"application/x-zip-compressed": mimeZip,
+	"application/x-gzip":           "application/gzip",
+	"application/x-rtf":            "application/rtf",
+	"application/x-rar-compressed": "application/vnd.rar",
+	"application/xml":              "text/xml",
+	"image/svg+xml":                "text/xml",
+	"application/x-javascript":     "text/javascript",
+	"application/javascript":       "text/javascript",
+}
+
+// ISO基础媒体文件格式，品牌识别不可靠，相互视为一致
+var mp4Family = map[string]bool{
+	"video/mp4": true, "audio/mp4": true, "video/quicktime": true, "video/3gpp": true,
+}
+
+// 旧版Office复合文档的扩展名类型
+var oleTypes = map[string]bool{
+	"application/msword": true, "application/x-xls": true, "application/vnd.ms-excel": true,
+	"application/x-ppt": true, "application/vnd.ms-powerpoint": true, "application/x-msi": true,
+	"application/vnd.visio": true, "application/x-vsd": true,
+}
+
+// 以zip为容器的格式
+var zipTypes = map[string]bool{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/vnd.oasis.opendocument.text":                                   true,
+	"application/vnd.oasis.opendocument.spreadsheet":                            true,
+	"application/vnd.oasis.opendocument.presentation":                           true,
+	"application/epub+zip":                    true,
+	"application/java-archive":                true,
+	"application/vnd.android.package-archive": true,
+	"application/vnd.iphone":                  true,
+	"application/x-silverlight-app":           true,
+	"application/vnd.symbian.install":         true,
+}
+
+var officeTypes = map[string]bool{
+	"application/msword": true, "application/x-xls": true, "application/vnd.ms-excel": true,
+	"application/x-ppt": true, "application/vnd.ms-powerpoint": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+}
+
+// 去掉参数
+func plainMime(typ string) string {
+	if i := strings.IndexByte(typ, ';'); i >= 0 {
+		typ = typ[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(typ))
+}
+
+// 去掉参数并统一别名
+func mimeBase(typ string) string {
+	typ = plainMime(typ)
+	if alias, ok := mimeAliases[typ]; ok {
+		return alias
+	}
+	return typ
+}
+
+// 扩展名类型与内容类型是否一致，内容类型可以是扩展名类型的容器格式
+func compatibleMime(byExt string, byContent string) bool {
+	ext, content := mimeBase(byExt), mimeBase(byContent)
+	switch {
+	case ext == content:
+		return true
+	case mp4Family[ext] && mp4Family[content]:
+		return true
+	case content == mimeZip:
+		return zipTypes[ext] || ext == mimeZip
+	case zipTypes[content]:
+		// docx等改为.zip扩展名
+		return ext == mimeZip
+	case content == mimeOle:
+		return oleTypes[ext]
+	case content == mimeText:
+		// 纯文本可以是任意文本格式，但不能冒充html等会被浏览器执行的类型
+		return isTextMime(ext) && ext != "text/html"
+	case content == "text/x-shellscript":
+		return ext == mimeText || ext == content
+	}
+	return false
+}
+
+// 识别出的是容器或纯文本等通用类型，扩展名可提供更具体的类型
+func genericMime(typ string) bool {
+	switch plainMime(typ) {
+	case mimeZip, mimeOle, mimeText, "text/xml":
+		return true
+	}
+	return false
+}
+
+func isTextMime(typ string) bool {
+	if strings.HasPrefix(typ, "text/") {
+		return true
+	}
+	switch typ {
+	case "application/json", "application/x-sh", "application/x-csh", "application/x-latex", "application/x-tex":
+		return true
+	}
+	return false
+}
+
+// 有明确文件头的格式，扩展名为这些格式而内容无法识别时视为不一致
+var sniffableTypes = func() map[string]bool {
+	types := map[string]bool{}
+	for _, m := range magics {
+		types[mimeBase(m.mime)] = true
+	}
+	for typ := range zipTypes {
+		types[typ] = true
+	}
+	for typ := range oleTypes {
+		types[typ] = true
+	}
+	for _, typ := range []string{"image/webp", "audio/wav", "video/x-msvideo", "video/webm", "video/x-matroska",
+		"video/mp4", "audio/mp4", "video/quicktime", "video/3gpp", "image/heic", "image/avif"} {
+		types[typ] = true
+	}
+	delete(types, "text/x-shellscript")
+	return types
+}()
+
+func sniffable(byExt string) bool {
+	return sniffableTypes[mimeBase(byExt)]
+}
@@ -0,0 +1,267 @@
+package fileutils
+
+import (
+	"archive/zip"
+	"bytes"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 在sig之后补齐到n字节
+func pad(sig string, n int) []byte {
+	data := make([]byte, n)
+	copy(data, sig)
+	return data
+}
+
+func tarHead() []byte {
+	data := make([]byte, 512)
+	copy(data, "file.txt")
+	copy(data[257:], "ustar\x0000")
+	return data
+}
+
+var sniffTests = []struct {
+	name string
+	data []byte
+	want string
+}{
+	{"jpeg", pad("\xFF\xD8\xFF\xE0\x00\x10JFIF", 64), "image/jpeg"},
+	{"png", pad("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", 64), "image/png"},
+	{"gif", pad("GIF89a", 64), "image/gif"},
+	{"bmp", pad("BM", 64), "image/bmp"},
+	{"tiff", pad("II*\x00", 64), "image/tiff"},
+	{"webp", pad("RIFF\x00\x00\x00\x00WEBPVP8 ", 64), "image/webp"},
+	{"heic", pad("\x00\x00\x00\x18ftypheic", 64), "image/heic"},
+	{"avif", pad("\x00\x00\x00\x1cftypavif", 64), "image/avif"},
+	{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml"},
+	{"pdf", pad("%PDF-1.7\n", 64), "application/pdf"},
+	{"ole", pad("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1", 64), mimeOle},
+	{"rtf", []byte(`{\rtf1\ansi hello}`), "application/rtf"},
+	{"gzip", pad("\x1F\x8B\x08", 64), "application/gzip"},
+	{"bzip2", pad("BZh91AY&SY", 64), "application/x-bzip2"},
+	{"xz", pad("\xFD7zXZ\x00", 64), "application/x-xz"},
+	{"7z", pad("7z\xBC\xAF\x27\x1C", 64), "application/x-7z-compressed"},
+	{"rar", pad("Rar!\x1A\x07\x01\x00", 64), "application/vnd.rar"},
+	{"zstd", pad("\x28\xB5\x2F\xFD", 64), "application/zstd"},
+	{"tar", tarHead(), "application/x-tar"},
+	{"mp3 id3", pad("ID3\x04\x00", 64), "audio/mpeg"},
+	{"mp3 frame", pad("\xFF\xFB\x90\x00", 64), "audio/mpeg"},
+	{"aac", pad("\xFF\xF1\x50\x80", 64), "audio/aac"},
+	{"flac", pad("fLaC", 64), "audio/flac"},
+	{"ogg", pad("OggS", 64), "audio/ogg"},
+	{"wav", pad("RIFF\x00\x00\x00\x00WAVEfmt ", 64), "audio/wav"},
+	{"m4a", pad("\x00\x00\x00\x20ftypM4A ", 64), "audio/mp4"},
+	{"mp4", pad("\x00\x00\x00\x20ftypisom", 64), "video/mp4"},
+	{"mov", pad("\x00\x00\x00\x14ftypqt  ", 64), "video/quicktime"},
+	{"3gp", pad("\x00\x00\x00\x14ftyp3gp5", 64), "video/3gpp"},
+	{"avi", pad("RIFF\x00\x00\x00\x00AVI LIST", 64), "video/x-msvideo"},
+	{"webm", pad("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm", 64), "video/webm"},
+	{"mkv", pad("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x88matroska", 64), "video/x-matroska"},
+	{"flv", pad("FLV\x01\x05", 64), "video/x-flv"},
+	{"mpeg", pad("\x00\x00\x01\xBA", 64), "video/mpeg"},
+	{"exe", pad("MZ\x90\x00", 64), "application/x-msdownload"},
+	{"elf", pad("\x7FELF\x02\x01\x01", 64), "application/x-executable"},
+	{"wasm", pad("\x00asm\x01\x00\x00\x00", 64), "application/wasm"},
+	{"shebang", []byte("#!/bin/sh\necho hi\n"), "text/x-shellscript"},
+	{"html", []byte("<!DOCTYPE html><html><body>hi</body></html>"), "text/html; charset=utf-8"},
+	{"text", []byte("hello, world\n"), "text/plain; charset=utf-8"},
+	{"binary", []byte{0x01, 0x02, 0x03, 0x00, 0xfe}, mimeOctetStream},
+	{"empty", nil, mimeOctetStream},
+}
+
+func TestDetectContentType(t *testing.T) {
+	for _, tt := range sniffTests {
+		if got := DetectContentType(tt.data); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// 创建zip，第一个条目按ODF/EPUB规范以Store写入并在本地文件头记录大小
+func makeZip(t *testing.T, first string, firstBody string, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	body := []byte(firstBody)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               first,
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(body),
+		CompressedSize64:   uint64(len(body)),
+		UncompressedSize64: uint64(len(body)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(body)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(strings.Repeat("content ", 2000)))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectZipContentType(t *testing.T) {
+	docx := "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	tests := []struct {
+		name   string
+		data   []byte
+		head   string //仅根据文件头识别的结果
+		byFile string //读取zip目录识别的结果
+	}{
+		{"docx", makeZip(t, "[Content_Types].xml", "<Types/>", "_rels/.rels", "word/document.xml"), mimeZip, docx},
+		{"docx first", makeZip(t, "word/document.xml", "<w/>"), docx, docx},
+		// 第一个条目记录了大小，可以继续读取第二个条目的文件头
+		{"xlsx", makeZip(t, "[Content_Types].xml", "<Types/>", "xl/workbook.xml"), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"pptx", makeZip(t, "[Content_Types].xml", "<Types/>", "ppt/presentation.xml"), "application/vnd.openxmlformats-officedocument.presentationml.presentation", "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+		{"epub", makeZip(t, "mimetype", "application/epub+zip", "META-INF/container.xml"), "application/epub+zip", "application/epub+zip"},
+		{"odt", makeZip(t, "mimetype", "application/vnd.oasis.opendocument.text", "content.xml"), "application/vnd.oasis.opendocument.text", "application/vnd.oasis.opendocument.text"},
+		{"jar", makeZip(t, "META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n", "a/B.class"), "application/java-archive", "application/java-archive"},
+		{"apk", makeZip(t, "META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n", "classes.dex", "AndroidManifest.xml"), "application/java-archive", "application/vnd.android.package-archive"},
+		{"zip", makeZip(t, "readme.txt", "hello", "data/a.bin"), mimeZip, mimeZip},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		head := tt.data
+		if len(head) > SniffLen {
+			head = head[:SniffLen]
+		}
+		if got := DetectContentType(head); got != tt.head {
+			t.Errorf("%s head: got %q, want %q", tt.name, got, tt.head)
+		}
+		p := filepath.Join(dir, strings.Replace(tt.name, " ", "_", -1))
+		if err := os.WriteFile(p, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := DetectFileContentType(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.byFile {
+			t.Errorf("%s file: got %q, want %q", tt.name, got, tt.byFile)
+		}
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	png := pad("\x89PNG\r\n\x1a\n", 64)
+	exe := pad("MZ\x90\x00", 64)
+	ole := pad("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1", 64)
+	zipHead := makeZip(t, "[Content_Types].xml", "<Types/>")
+	tests := []struct {
+		fileName string
+		data     []byte
+		want     string
+		mismatch bool
+	}{
+		{"a.png", png, "image/png", false},
+		{"A.PNG", png, "image/png", false},
+		{"noext", png, "image/png", false},
+		{"a.jpg", png, "image/png", true},
+		{"avatar.jpg", exe, "application/x-msdownload", true},
+		{"a.png", []byte("<html><script>alert(1)</script></html>"), "text/html; charset=utf-8", true},
+		{"a.png", []byte{0x01, 0x02, 0x03, 0x00}, mimeOctetStream, true},
+		{"a.txt", []byte("hello\n"), "text/plain", false},
+		{"a.csv", []byte("a,b\n1,2\n"), "text/csv", false},
+		{"a.json", []byte(`{"a":1}`), "application/json", false},
+		{"a.html", []byte("hello\n"), "text/plain; charset=utf-8", true},
+		{"a.txt", []byte("#!/bin/sh\n"), "text/x-shellscript", false},
+		{"a.docx", zipHead, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", false},
+		{"a.zip", zipHead, mimeZip, false},
+		{"a.pdf", zipHead, mimeZip, true},
+		{"a.doc", ole, "application/msword", false},
+		{"a.xls", ole, "application/x-xls", false},
+		{"a.jpg", ole, mimeOle, true},
+		{"a.mp4", pad("\x00\x00\x00\x14ftypqt  ", 64), "video/quicktime", false},
+		{"a.mp3", pad("ID3\x04", 64), "audio/mpeg", false},
+		{"a.svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml", false},
+		{"a.unknownext", []byte{0x01, 0x02, 0x00}, mimeOctetStream, false},
+	}
+	for _, tt := range tests {
+		res := CheckContentType(tt.fileName, tt.data)
+		if res.ContentType != tt.want || res.Mismatch != tt.mismatch {
+			t.Errorf("%s: got %q mismatch=%v (byContent %q, byExt %q), want %q mismatch=%v",
+				tt.fileName, res.ContentType, res.Mismatch, res.ByContent, res.ByExt, tt.want, tt.mismatch)
+		}
+	}
+}
+
+func TestDetectReaderContentType(t *testing.T) {
+	data := append(pad("%PDF-1.4\n", 100), bytes.Repeat([]byte("x"), 3*SniffLen)...)
+	typ, r, err := DetectReaderContentType(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != "application/pdf" {
+		t.Fatalf("got %q", typ)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("reader does not return the full content")
+	}
+
+	typ, r, err = DetectReaderContentType(strings.NewReader("short"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); typ != "text/plain; charset=utf-8" || string(got) != "short" {
+		t.Fatalf("got %q, %q", typ, got)
+	}
+}
+
+func TestDetectFileType(t *testing.T) {
+	dir := t.TempDir()
+	docx := makeZip(t, "[Content_Types].xml", "<Types/>", "word/document.xml")
+	tests := []struct {
+		name     string
+		data     []byte
+		want     string
+		mismatch bool
+	}{
+		{"photo.txt", pad("\x89PNG\r\n\x1a\n", 64), "image", true},
+		{"photo", pad("\xFF\xD8\xFF\xE0", 64), "image", false},
+		{"movie.bin", pad("\x00\x00\x00\x20ftypisom", 64), "video", false},
+		{"song.mp3", pad("ID3\x04", 64), "audio", false},
+		{"report.zip", docx, "text", false},
+		{"report.docx", docx, "text", false},
+		{"notes.txt", []byte("hello"), "file", false},
+		{"setup.jpg", pad("MZ\x90\x00", 64), "file", true},
+	}
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(p, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := DetectFileType(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		res, err := CheckFileContentType(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Mismatch != tt.mismatch {
+			t.Errorf("%s: mismatch %v, want %v (%+v)", tt.name, res.Mismatch, tt.mismatch, res)
+		}
+	}
+	if _, err := DetectFileType(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("got %v, want not exist", err)
+	}
+}
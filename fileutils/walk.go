@@ -0,0 +1,557 @@
+package fileutils
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	//SkipDir 回调处理目录时返回SkipDir，跳过该目录下的所有内容
+	SkipDir = filepath.SkipDir
+	//StopWalk 回调返回StopWalk时立即结束遍历，Walk返回nil
+	StopWalk = errors.New("stop walk")
+)
+
+//WalkOptions 遍历参数，零值字段不生效
+type WalkOptions struct {
+	//Include 文件名匹配任一模式时才返回，为空时返回所有文件，不影响目录的遍历
+	//模式语法同.gitignore：不含/时匹配文件名，含/时匹配相对root的路径，**匹配任意层目录，如 "*.go"、"src/**/*.js"
+	Include []string
+	//Exclude 匹配任一模式的文件或目录被忽略，目录被忽略时不再遍历其中的内容，语法同Include
+	Exclude []string
+	//IncludeRegexp 相对root的路径（以/分隔）匹配任一正则时才返回，不影响目录的遍历
+	IncludeRegexp []string
+	//ExcludeRegexp 相对root的路径匹配任一正则的文件或目录被忽略
+	ExcludeRegexp []string
+	//IgnoreFiles 每个目录下按.gitignore规则读取的忽略文件名，如 ".gitignore"
+	IgnoreFiles []string
+	//MaxDepth 最大遍历深度，root下的直接子项深度为1，0表示不限制
+	MaxDepth int
+	//FollowSymlinks 跟随符号链接，指向目录的链接会被遍历，形成循环的链接会被忽略
+	//默认不跟随，链接本身作为文件返回
+	FollowSymlinks bool
+	//IncludeDirs 回调中同时返回目录，目录不经过Include与大小、时间过滤
+	IncludeDirs bool
+	//MinSize、MaxSize 文件大小范围（字节），0表示不限制
+	MinSize int64
+	MaxSize int64
+	//ModifiedAfter、ModifiedBefore 文件修改时间范围，零值表示不限制
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+	//Concurrency 大于1时使用多个协程同时读取目录，回调会在多个协程中并发执行且顺序不确定
+	Concurrency int
+	//OnError 读取目录或文件信息出错时调用，返回nil时忽略该项继续遍历，默认结束遍历并返回错误
+	OnError func(path string, err error) error
+}
+
+//WalkEntry 遍历到的文件或目录
+type WalkEntry struct {
+	Path    string //完整路径，以root开头
+	RelPath string //相对root的路径，以/分隔
+	Info    os.FileInfo
+	Depth   int
+}
+
+//Walk 流式遍历root下的文件，每找到一个符合条件的文件调用一次fn，不会一次性加载所有路径
+//顺序遍历时同一目录下按文件名排序，fn返回SkipDir跳过目录，返回StopWalk结束遍历，返回其他错误时结束遍历并返回该错误
+//opts 为非必需参数
+func Walk(root string, fn func(entry WalkEntry) error, opts ...WalkOptions) error {
+	var o WalkOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	w, err := newWalker(root, o, fn)
+	if err != nil {
+		return err
+	}
+	if o.Concurrency > 1 {
+		w.walkConcurrent()
+	} else {
+		w.walkDir(w.rootTask())
+	}
+	if err = w.err; err == StopWalk {
+		return nil
+	}
+	return err
+}
+
+//FindFiles 返回root下所有符合条件的文件路径，opts 为非必需参数
+func FindFiles(root string, opts ...WalkOptions) ([]string, error) {
+	var mu sync.Mutex
+	var paths []string
+	err := Walk(root, func(entry WalkEntry) error {
+		if !entry.Info.IsDir() {
+			mu.Lock()
+			paths = append(paths, entry.Path)
+			mu.Unlock()
+		}
+		return nil
+	}, opts...)
+	return paths, err
+}
+
+type walker struct {
+	root          string
+	o             WalkOptions
+	fn            func(entry WalkEntry) error
+	include       []*globRule
+	exclude       []*globRule
+	includeRegexp []*regexp.Regexp
+	excludeRegexp []*regexp.Regexp
+	stopped       int32
+	mu            sync.Mutex
+	err           error //第一个导致结束遍历的错误
+}
+
+// 待遍历的目录
+type walkTask struct {
+	path    string
+	rel     string
+	real    string //目录的真实路径，用于判断符号链接循环
+	depth   int
+	ignores []*ignoreRules
+}
+
+func newWalker(root string, o WalkOptions, fn func(entry WalkEntry) error) (*walker, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("not a directory, path：" + root)
+	}
+	w := &walker{root: root, o: o, fn: fn}
+	for _, p := range o.Include {
+		w.include = append(w.include, compileGlob(p))
+	}
+	for _, p := range o.Exclude {
+		w.exclude = append(w.exclude, compileGlob(p))
+	}
+	for _, p := range o.IncludeRegexp {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		w.includeRegexp = append(w.includeRegexp, re)
+	}
+	for _, p := range o.ExcludeRegexp {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		w.excludeRegexp = append(w.excludeRegexp, re)
+	}
+	return w, nil
+}
+
+func (w *walker) rootTask() walkTask {
+	real, err := realPath(w.root)
+	if err != nil {
+		real = w.root
+	}
+	return walkTask{path: w.root, real: real}
+}
+
+// 解析符号链接后的绝对路径
+func realPath(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(real)
+}
+
+// 顺序遍历目录，遇到子目录时立即递归
+func (w *walker) walkDir(t walkTask) error {
+	_, err := w.readDir(t, w.walkDir)
+	return err
+}
+
+// 读取目录t，符合条件的条目调用fn；descend不为nil时立即递归遍历子目录，否则返回子目录任务
+func (w *walker) readDir(t walkTask, descend func(t walkTask) error) ([]walkTask, error) {
+	entries, err := os.ReadDir(t.path)
+	if err != nil {
+		return nil, w.onError(t.path, err)
+	}
+	ignores := t.ignores
+	for _, name := range w.o.IgnoreFiles {
+		rules, err := readIgnoreFile(filepath.Join(t.path, name), t.rel)
+		if err != nil {
+			if err = w.onError(filepath.Join(t.path, name), err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if rules != nil {
+			ignores = append(ignores[:len(ignores):len(ignores)], rules)
+		}
+	}
+
+	var subs []walkTask
+	for _, de := range entries {
+		if w.isStopped() {
+			return nil, StopWalk
+		}
+		entry := WalkEntry{
+			Path:    filepath.Join(t.path, de.Name()),
+			RelPath: joinRel(t.rel, de.Name()),
+			Depth:   t.depth + 1,
+		}
+		info, err := de.Info()
+		if err != nil {
+			if err = w.onError(entry.Path, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		real := filepath.Join(t.real, de.Name())
+		if info.Mode()&os.ModeSymlink != 0 && w.o.FollowSymlinks {
+			target, err := os.Stat(entry.Path)
+			if err != nil {
+				// 悬空链接作为文件返回
+				target = info
+			} else if target.IsDir() {
+				if real, err = realPath(entry.Path); err != nil {
+					if err = w.onError(entry.Path, err); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if isAncestor(real, t.real) {
+					continue
+				}
+			}
+			info = target
+		}
+		entry.Info = info
+		isDir := info.IsDir()
+		if w.excluded(entry.RelPath, isDir, ignores) {
+			continue
+		}
+		if isDir {
+			if w.o.IncludeDirs {
+				if err := w.fn(entry); err != nil {
+					if err == SkipDir {
+						continue
+					}
+					return nil, w.stop(err)
+				}
+			}
+			if w.o.MaxDepth > 0 && entry.Depth >= w.o.MaxDepth {
+				continue
+			}
+			sub := walkTask{path: entry.Path, rel: entry.RelPath, real: real, depth: entry.Depth, ignores: ignores}
+			if descend == nil {
+				subs = append(subs, sub)
+				continue
+			}
+			if err := descend(sub); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !w.matchFile(entry) {
+			continue
+		}
+		if err := w.fn(entry); err != nil && err != SkipDir {
+			return nil, w.stop(err)
+		}
+	}
+	return subs, nil
+}
+
+// 多个协程从共享队列中取目录读取，子目录放回队列，出错时所有协程退出
+func (w *walker) walkConcurrent() {
+	var (
+		mu    sync.Mutex
+		cond  = sync.NewCond(&mu)
+		queue = []walkTask{w.rootTask()}
+		busy  int
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < w.o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				for len(queue) == 0 && busy > 0 && !w.isStopped() {
+					cond.Wait()
+				}
+				if len(queue) == 0 || w.isStopped() {
+					mu.Unlock()
+					cond.Broadcast()
+					return
+				}
+				t := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+				busy++
+				mu.Unlock()
+
+				subs, _ := w.readDir(t, nil)
+
+				mu.Lock()
+				busy--
+				queue = append(queue, subs...)
+				mu.Unlock()
+				cond.Broadcast()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *walker) excluded(rel string, isDir bool, ignores []*ignoreRules) bool {
+	for _, g := range w.exclude {
+		if g.match(rel, isDir) {
+			return true
+		}
+	}
+	for _, re := range w.excludeRegexp {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return ignored(ignores, rel, isDir)
+}
+
+func (w *walker) matchFile(entry WalkEntry) bool {
+	info := entry.Info
+	if w.o.MinSize > 0 && info.Size() < w.o.MinSize {
+		return false
+	}
+	if w.o.MaxSize > 0 && info.Size() > w.o.MaxSize {
+		return false
+	}
+	if !w.o.ModifiedAfter.IsZero() && !info.ModTime().After(w.o.ModifiedAfter) {
+		return false
+	}
+	if !w.o.ModifiedBefore.IsZero() && !info.ModTime().Before(w.o.ModifiedBefore) {
+		return false
+	}
+	if len(w.include) == 0 && len(w.includeRegexp) == 0 {
+		return true
+	}
+	for _, g := range w.include {
+		if g.match(entry.RelPath, false) {
+			return true
+		}
+	}
+	for _, re := range w.includeRegexp {
+		if re.MatchString(entry.RelPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *walker) onError(path string, err error) error {
+	if w.o.OnError == nil {
+		return w.stop(err)
+	}
+	if err = w.o.OnError(path, err); err != nil {
+		return w.stop(err)
+	}
+	return nil
+}
+
+// 记录第一个错误并结束遍历，并发模式下其他协程尽快退出
+func (w *walker) stop(err error) error {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+	atomic.StoreInt32(&w.stopped, 1)
+	return err
+}
+
+func (w *walker) isStopped() bool {
+	return atomic.LoadInt32(&w.stopped) != 0
+}
+
+func joinRel(dir string, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// dir与path相同或为path的上级目录
+func isAncestor(dir string, path string) bool {
+	if dir == path {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+// =================== .gitignore ======================
+
+// 单个忽略文件中的规则，base为忽略文件所在目录相对root的路径
+type ignoreRules struct {
+	base  string
+	rules []*globRule
+}
+
+// 按.gitignore语法编译的模式
+type globRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func (g *globRule) match(rel string, isDir bool) bool {
+	if g.dirOnly && !isDir {
+		return false
+	}
+	return g.re.MatchString(rel)
+}
+
+func readIgnoreFile(filePath string, base string) (*ignoreRules, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	rules := &ignoreRules{base: base}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line[:len(line)-2], " ") + " "
+		} else {
+			line = strings.TrimRight(line, " \t")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := false
+		if strings.HasPrefix(line, "!") {
+			negate, line = true, line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		rule := compileGlob(line)
+		rule.negate = negate
+		rules.rules = append(rules.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// 按.gitignore规则判断是否忽略：由上级目录到下级目录、由前到后，最后匹配的规则生效
+func ignored(ignores []*ignoreRules, rel string, isDir bool) bool {
+	result := false
+	for _, ir := range ignores {
+		p := rel
+		if ir.base != "" {
+			if !strings.HasPrefix(rel, ir.base+"/") {
+				continue
+			}
+			p = rel[len(ir.base)+1:]
+		}
+		for _, rule := range ir.rules {
+			if rule.match(p, isDir) {
+				result = !rule.negate
+			}
+		}
+	}
+	return result
+}
+
+//GlobMatcher 按.gitignore语法编译的模式，语法同WalkOptions.Include，可在多个协程中同时使用
+type GlobMatcher struct {
+	rule *globRule
+}
+
+//NewGlobMatcher 编译pattern：不含/时匹配任意层级的名称，含/时匹配完整的相对路径，**匹配任意层目录，以/结尾时只匹配目录
+//如 "*.txt"、"src/*.go"、"docs/**/*.md"、"build/"
+func NewGlobMatcher(pattern string) *GlobMatcher {
+	return &GlobMatcher{rule: compileGlob(pattern)}
+}
+
+//Match rel为以/分隔的相对路径，isDir表示rel是否为目录
+func (m *GlobMatcher) Match(rel string, isDir bool) bool {
+	return m.rule.match(rel, isDir)
+}
+
+// 将.gitignore模式转换为正则：不含/时匹配任意层级的名称，含/时相对基准目录匹配
+func compileGlob(pattern string) *globRule {
+	rule := &globRule{}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				switch {
+				case i+1 < len(pattern) && pattern[i+1] == '/':
+					// **/ 匹配零或多层目录
+					i++
+					b.WriteString("(?:.*/)?")
+				default:
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		// 非法的字符类按字面匹配
+		re = regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+	rule.re = re
+	return rule
+}
@@ -0,0 +1,338 @@
+package fileutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGlobMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		isDir   bool
+		want    bool
+	}{
+		// 不含/时匹配任意层级的名称
+		{"*.txt", "a.txt", false, true},
+		{"*.txt", "a/b/c.txt", false, true},
+		{"*.txt", "a.txt/b", false, false},
+		{"docs", "docs", true, true},
+		{"docs", "a/docs", true, true},
+		// 含/时匹配完整的相对路径
+		{"/*.txt", "a.txt", false, true},
+		{"/*.txt", "a/b.txt", false, false},
+		{"src/*.go", "src/main.go", false, true},
+		{"src/*.go", "src/pkg/x.go", false, false},
+		{"src/*.go", "a/src/main.go", false, false},
+		// **匹配任意层目录
+		{"**/*.go", "main.go", false, true},
+		{"**/*.go", "a/b/main.go", false, true},
+		{"src/**/*.go", "src/main.go", false, true},
+		{"src/**/*.go", "src/a/b/main.go", false, true},
+		{"src/**", "src/a/b", false, true},
+		// 以/结尾时只匹配目录
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"build/", "a/build", true, true},
+		// ?、字符类与转义
+		{"?.go", "a.go", false, true},
+		{"?.go", "ab.go", false, false},
+		{"[ab].go", "b.go", false, true},
+		{"[!ab].go", "b.go", false, false},
+		{"[!ab].go", "c.go", false, true},
+		{`\*.go`, "*.go", false, true},
+		{`\*.go`, "a.go", false, false},
+		{"a.b", "axb", false, false},
+	}
+	for _, tt := range tests {
+		if got := NewGlobMatcher(tt.pattern).Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("NewGlobMatcher(%q).Match(%q, %v) = %v, want %v", tt.pattern, tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+// 在dir下按相对路径创建文件，以/结尾的路径创建目录
+func makeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// 返回遍历到的相对路径，目录以/结尾，并发遍历时排序后返回
+func walkRel(t *testing.T, root string, opts WalkOptions) []string {
+	t.Helper()
+	var mu sync.Mutex
+	var rels []string
+	err := Walk(root, func(entry WalkEntry) error {
+		rel := entry.RelPath
+		if entry.Info.IsDir() {
+			rel += "/"
+		}
+		mu.Lock()
+		rels = append(rels, rel)
+		mu.Unlock()
+		return nil
+	}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Concurrency > 1 {
+		sort.Strings(rels)
+	}
+	return rels
+}
+
+var walkTree = map[string]string{
+	"a.go":             "package a",
+	"b.txt":            "bbbbbbbbbb",
+	"src/main.go":      "package main",
+	"src/main_test.go": "package main",
+	"src/lib/x.js":     "x",
+	"src/lib/y.min.js": "y",
+	"docs/readme.md":   "readme",
+	"empty/":           "",
+	"vendor/v.go":      "package v",
+}
+
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, walkTree)
+
+	// 顺序遍历时同一目录下按文件名排序，Path以root开头，Depth从1开始
+	var entries []WalkEntry
+	err := Walk(root, func(entry WalkEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}, WalkOptions{IncludeDirs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels []string
+	for _, e := range entries {
+		rels = append(rels, e.RelPath)
+		if e.Path != filepath.Join(root, filepath.FromSlash(e.RelPath)) {
+			t.Fatalf("%s: path %s", e.RelPath, e.Path)
+		}
+		if e.Depth != strings.Count(e.RelPath, "/")+1 {
+			t.Fatalf("%s: depth %d", e.RelPath, e.Depth)
+		}
+	}
+	want := []string{"a.go", "b.txt", "docs", "docs/readme.md", "empty", "src", "src/lib", "src/lib/x.js", "src/lib/y.min.js", "src/main.go", "src/main_test.go", "vendor", "vendor/v.go"}
+	if !reflect.DeepEqual(rels, want) {
+		t.Fatalf("got %v, want %v", rels, want)
+	}
+
+	files, err := FindFiles(root, WalkOptions{Include: []string{"*.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 || files[0] != filepath.Join(root, "a.go") {
+		t.Fatalf("FindFiles: %v", files)
+	}
+
+	if err := Walk(filepath.Join(root, "a.go"), func(WalkEntry) error { return nil }); err == nil {
+		t.Fatal("expected error for a file root")
+	}
+	if err := Walk(filepath.Join(root, "missing"), func(WalkEntry) error { return nil }); !os.IsNotExist(err) {
+		t.Fatalf("got %v, want not exist", err)
+	}
+}
+
+func TestWalkFilters(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, walkTree)
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a.go"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		opts WalkOptions
+		want []string
+	}{
+		{"include", WalkOptions{Include: []string{"*.go"}}, []string{"a.go", "src/main.go", "src/main_test.go", "vendor/v.go"}},
+		{"include anchored", WalkOptions{Include: []string{"src/*.go"}}, []string{"src/main.go", "src/main_test.go"}},
+		{"include doublestar", WalkOptions{Include: []string{"src/**/*.js"}}, []string{"src/lib/x.js", "src/lib/y.min.js"}},
+		{"exclude", WalkOptions{Include: []string{"*.go", "*.js"}, Exclude: []string{"*_test.go", "*.min.js", "vendor/"}}, []string{"a.go", "src/lib/x.js", "src/main.go"}},
+		{"exclude dir with dirs", WalkOptions{IncludeDirs: true, Exclude: []string{"src", "docs"}}, []string{"a.go", "b.txt", "empty/", "vendor/", "vendor/v.go"}},
+		{"regexp", WalkOptions{IncludeRegexp: []string{`^src/.*\.go$`}, ExcludeRegexp: []string{`_test\.go$`}}, []string{"src/main.go"}},
+		{"max depth", WalkOptions{MaxDepth: 1, IncludeDirs: true}, []string{"a.go", "b.txt", "docs/", "empty/", "src/", "vendor/"}},
+		{"max depth 2", WalkOptions{MaxDepth: 2, Include: []string{"*.go", "*.js"}}, []string{"a.go", "src/main.go", "src/main_test.go", "vendor/v.go"}},
+		{"size", WalkOptions{MinSize: 9, MaxSize: 10}, []string{"a.go", "b.txt", "vendor/v.go"}},
+		{"modified after", WalkOptions{ModifiedAfter: time.Now().Add(-24 * time.Hour), Include: []string{"*.go"}}, []string{"src/main.go", "src/main_test.go", "vendor/v.go"}},
+		{"modified before", WalkOptions{ModifiedBefore: time.Now().Add(-24 * time.Hour)}, []string{"a.go"}},
+	}
+	for _, tt := range tests {
+		if got := walkRel(t, root, tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		tt.opts.Concurrency = 4
+		want := append([]string(nil), tt.want...)
+		sort.Strings(want)
+		if got := walkRel(t, root, tt.opts); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s concurrent: got %v, want %v", tt.name, got, want)
+		}
+	}
+
+	if err := Walk(root, func(WalkEntry) error { return nil }, WalkOptions{IncludeRegexp: []string{"("}}); err == nil {
+		t.Fatal("expected error for invalid regexp")
+	}
+}
+
+func TestWalkIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, map[string]string{
+		".gitignore":        "*.log\n# comment\nbuild/\n!keep.log\n/root.txt\n",
+		"a.log":             "",
+		"keep.log":          "",
+		"root.txt":          "",
+		"build/out.bin":     "",
+		"src/.gitignore":    "*.tmp\n!important.log\n",
+		"src/root.txt":      "",
+		"src/x.tmp":         "",
+		"src/important.log": "",
+		"src/other.log":     "",
+		"src/build":         "build is a file here",
+		"other/x.tmp":       "",
+	})
+	got := walkRel(t, root, WalkOptions{IgnoreFiles: []string{".gitignore"}, Exclude: []string{".gitignore"}})
+	// build/只匹配目录，src/build为文件时不被忽略
+	want := []string{"keep.log", "other/x.tmp", "src/build", "src/important.log", "src/root.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	got = walkRel(t, root, WalkOptions{IgnoreFiles: []string{".gitignore"}, Exclude: []string{".gitignore"}, Concurrency: 3})
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("concurrent: got %v, want %v", got, want)
+	}
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, map[string]string{"real/a.txt": "a", "real/sub/b.txt": "b"})
+	if err := os.Symlink("real", filepath.Join(root, "link")); err != nil {
+		t.Skip("symlink not supported:", err)
+	}
+	// 指向上级目录的链接形成循环
+	if err := os.Symlink("..", filepath.Join(root, "real", "sub", "loop")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("missing", filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := walkRel(t, root, WalkOptions{})
+	want := []string{"dangling", "link", "real/a.txt", "real/sub/b.txt", "real/sub/loop"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("no follow: got %v, want %v", got, want)
+	}
+	got = walkRel(t, root, WalkOptions{FollowSymlinks: true})
+	want = []string{"dangling", "link/a.txt", "link/sub/b.txt", "real/a.txt", "real/sub/b.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("follow: got %v, want %v", got, want)
+	}
+	got = walkRel(t, root, WalkOptions{FollowSymlinks: true, Concurrency: 4})
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("follow concurrent: got %v, want %v", got, want)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("d%02d/f.txt", i)] = "x"
+	}
+	makeTree(t, root, files)
+
+	// SkipDir跳过目录，StopWalk结束遍历且返回nil
+	var seen []string
+	err := Walk(root, func(entry WalkEntry) error {
+		if entry.Info.IsDir() {
+			if entry.RelPath == "d01" {
+				return SkipDir
+			}
+			return nil
+		}
+		seen = append(seen, entry.RelPath)
+		if entry.RelPath == "d03/f.txt" {
+			return StopWalk
+		}
+		return nil
+	}, WalkOptions{IncludeDirs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"d00/f.txt", "d02/f.txt", "d03/f.txt"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("got %v, want %v", seen, want)
+	}
+
+	errStop := errors.New("stop")
+	for _, concurrency := range []int{0, 4} {
+		var n int32
+		err := Walk(root, func(entry WalkEntry) error {
+			if atomic.AddInt32(&n, 1) == 3 {
+				return errStop
+			}
+			return nil
+		}, WalkOptions{Concurrency: concurrency})
+		if err != errStop {
+			t.Fatalf("concurrency %d: got %v, want %v", concurrency, err, errStop)
+		}
+		if n >= 20 {
+			t.Fatalf("concurrency %d: walk continued after error, %d callbacks", concurrency, n)
+		}
+		n = 0
+		err = Walk(root, func(entry WalkEntry) error {
+			atomic.AddInt32(&n, 1)
+			return StopWalk
+		}, WalkOptions{Concurrency: concurrency})
+		if err != nil {
+			t.Fatalf("concurrency %d: got %v", concurrency, err)
+		}
+	}
+}
+
+func TestWalkOnError(t *testing.T) {
+	root := t.TempDir()
+	// 与忽略文件同名的目录无法按文件读取
+	makeTree(t, root, map[string]string{"a.txt": "a", "sub/.ignore/": "", "sub/b.txt": "b"})
+	opts := WalkOptions{IgnoreFiles: []string{".ignore"}}
+	if err := Walk(root, func(WalkEntry) error { return nil }, opts); err == nil {
+		t.Fatal("expected error reading ignore file")
+	}
+	var failed []string
+	opts.OnError = func(path string, err error) error {
+		failed = append(failed, path)
+		return nil
+	}
+	got := walkRel(t, root, opts)
+	if want := []string{"a.txt", "sub/b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if len(failed) != 1 || failed[0] != filepath.Join(root, "sub", ".ignore") {
+		t.Fatalf("OnError paths %v", failed)
+	}
+}
@@ -0,0 +1,523 @@
+package fileutils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//WatchOp 文件变化类型，合并后的事件可能同时包含多种类型
+type WatchOp uint32
+
+const (
+	WatchCreate WatchOp = 1 << iota //创建，包括移入被监听的目录
+	WatchWrite                      //内容修改
+	WatchRemove                     //删除
+	WatchRename                     //重命名或移出，Path为原路径，新路径会收到WatchCreate；轮询模式下表现为WatchRemove
+)
+
+func (op WatchOp) String() string {
+	var names []string
+	for _, n := range []struct {
+		op   WatchOp
+		name string
+	}{{WatchCreate, "CREATE"}, {WatchWrite, "WRITE"}, {WatchRemove, "REMOVE"}, {WatchRename, "RENAME"}} {
+		if op&n.op != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+//WatchEvent 文件变化事件
+type WatchEvent struct {
+	Path  string
+	Op    WatchOp
+	IsDir bool
+}
+
+var (
+	ErrWatcherClosed = errors.New("watcher closed")
+	//ErrEventOverflow 内核事件队列溢出，部分事件已丢失，需重新读取监听的文件
+	ErrEventOverflow = errors.New("watch event queue overflow")
+)
+
+// 轮询默认间隔
+const DefaultPollInterval = time.Second
+
+//WatchOptions 监听参数，零值字段使用默认值
+type WatchOptions struct {
+	//Recursive 递归监听子目录，包括之后新建的子目录
+	Recursive bool
+	//Debounce 同一文件在该时间内的多个事件合并为一个，在最后一次变化后Debounce时间发出，0表示不合并
+	Debounce time.Duration
+	//Include 相对监听路径的名称匹配任一模式时才发出事件，语法同WalkOptions.Include
+	Include []string
+	//Exclude 匹配任一模式的文件或目录不发出事件，目录不会被递归监听，语法同WalkOptions.Exclude
+	Exclude []string
+	//Poll 强制使用轮询，用于网络文件系统等不支持内核通知的场景
+	//不支持inotify的系统或inotify初始化失败时自动使用轮询
+	Poll bool
+	//PollInterval 轮询间隔，默认DefaultPollInterval
+	PollInterval time.Duration
+}
+
+//Watcher 文件监听器，从Events读取事件，从Errors读取错误，不再使用时需调用Close
+type Watcher struct {
+	Events <-chan WatchEvent
+	Errors <-chan error
+
+	o       WatchOptions
+	include []*globRule
+	exclude []*globRule
+	backend watchBackend
+	events  chan WatchEvent
+	errors  chan error
+	raw     chan WatchEvent
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	roots  map[string]bool
+	closed bool
+}
+
+// 监听实现：inotify或轮询，事件通过emit发出
+type watchBackend interface {
+	add(path string, recursive bool) error
+	remove(path string) error
+	close() error
+}
+
+//NewWatcher 创建文件监听器，之后通过Add添加监听路径，opts 为非必需参数
+func NewWatcher(opts ...WatchOptions) (*Watcher, error) {
+	var o WatchOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultPollInterval
+	}
+	w := &Watcher{
+		o:      o,
+		events: make(chan WatchEvent, 64),
+		errors: make(chan error, 8),
+		raw:    make(chan WatchEvent, 256),
+		done:   make(chan struct{}),
+		roots:  make(map[string]bool),
+	}
+	w.Events, w.Errors = w.events, w.errors
+	for _, p := range o.Include {
+		w.include = append(w.include, compileGlob(p))
+	}
+	for _, p := range o.Exclude {
+		w.exclude = append(w.exclude, compileGlob(p))
+	}
+	var err error
+	if !o.Poll {
+		w.backend, err = newNativeBackend(w)
+	}
+	if o.Poll || err != nil {
+		w.backend = newPollBackend(w, o.PollInterval)
+	}
+	w.wg.Add(1)
+	go w.loop()
+	return w, nil
+}
+
+//Watch 创建监听器并监听path，path可以是文件或目录，opts 为非必需参数
+func Watch(path string, opts ...WatchOptions) (*Watcher, error) {
+	w, err := NewWatcher(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(path); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+//Add 添加监听路径，path可以是文件或目录，目录在Recursive为true时递归监听
+func (w *Watcher) Add(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWatcherClosed
+	}
+	w.roots[abs] = true
+	w.mu.Unlock()
+	if err := w.backend.add(abs, w.o.Recursive); err != nil {
+		w.mu.Lock()
+		delete(w.roots, abs)
+		w.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+//Remove 移除通过Add添加的监听路径
+func (w *Watcher) Remove(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	delete(w.roots, abs)
+	w.mu.Unlock()
+	return w.backend.remove(abs)
+}
+
+//Close 停止监听并关闭Events与Errors
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	close(w.done)
+	err := w.backend.close()
+	w.wg.Wait()
+	close(w.events)
+	close(w.errors)
+	return err
+}
+
+// 后端发出原始事件，Close后丢弃
+func (w *Watcher) emit(ev WatchEvent) {
+	if !w.watched(ev.Path, ev.IsDir) {
+		return
+	}
+	select {
+	case w.raw <- ev:
+	case <-w.done:
+	}
+}
+
+// 后端发出错误，Errors未被读取时丢弃，不阻塞监听
+func (w *Watcher) emitError(err error) {
+	select {
+	case w.errors <- err:
+	case <-w.done:
+	default:
+	}
+}
+
+// 后端判断是否需要监听目录
+func (w *Watcher) excludedDir(path string) bool {
+	rel, ok := w.relPath(path)
+	if !ok || rel == "" {
+		return false
+	}
+	for _, g := range w.exclude {
+		if g.match(rel, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// 按Include与Exclude过滤事件，监听的根路径本身不过滤
+func (w *Watcher) watched(path string, isDir bool) bool {
+	rel, ok := w.relPath(path)
+	if !ok || rel == "" {
+		return true
+	}
+	for _, g := range w.exclude {
+		if g.match(rel, isDir) {
+			return false
+		}
+	}
+	if len(w.include) == 0 || isDir {
+		return len(w.include) == 0
+	}
+	for _, g := range w.include {
+		if g.match(rel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// 事件路径相对于所在监听目录的路径，监听的是文件时返回文件名
+func (w *Watcher) relPath(path string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	best := ""
+	for root := range w.roots {
+		if (path == root || isAncestor(root, path)) && len(root) > len(best) {
+			best = root
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	if best == path {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return "", true
+		}
+		return filepath.Base(path), true
+	}
+	rel, err := filepath.Rel(best, path)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// 合并事件后按顺序发出
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+	queue := make(map[string]*watchPending)
+	seq := 0
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	send := func(ev WatchEvent) bool {
+		select {
+		case w.events <- ev:
+			return true
+		case <-w.done:
+			return false
+		}
+	}
+	for {
+		select {
+		case <-w.done:
+			return
+		case ev := <-w.raw:
+			if w.o.Debounce <= 0 {
+				if !send(ev) {
+					return
+				}
+				continue
+			}
+			p, ok := queue[ev.Path]
+			if !ok {
+				seq++
+				queue[ev.Path] = &watchPending{ev: ev, seq: seq, deadline: time.Now().Add(w.o.Debounce)}
+			} else if coalesce(&p.ev, ev) {
+				p.deadline = time.Now().Add(w.o.Debounce)
+			} else {
+				delete(queue, ev.Path)
+			}
+			resetTimer(timer, queue)
+		case <-timer.C:
+			now := time.Now()
+			var ready []*watchPending
+			for path, p := range queue {
+				if !p.deadline.After(now) {
+					ready = append(ready, p)
+					delete(queue, path)
+				}
+			}
+			sort.Slice(ready, func(i, j int) bool { return ready[i].seq < ready[j].seq })
+			for _, p := range ready {
+				if !send(p.ev) {
+					return
+				}
+			}
+			resetTimer(timer, queue)
+		}
+	}
+}
+
+// 合并同一路径的事件，返回false表示两者相互抵消（创建后又删除）
+func coalesce(prev *WatchEvent, ev WatchEvent) bool {
+	if prev.Op&WatchCreate != 0 && ev.Op&(WatchRemove|WatchRename) != 0 {
+		return false
+	}
+	if prev.Op&(WatchRemove|WatchRename) != 0 && ev.Op&WatchCreate != 0 {
+		// 删除后重新创建，如编辑器保存时替换文件
+		prev.Op = WatchWrite
+		prev.IsDir = ev.IsDir
+		return true
+	}
+	prev.Op |= ev.Op
+	prev.IsDir = ev.IsDir
+	return true
+}
+
+// 等待合并的事件
+type watchPending struct {
+	ev       WatchEvent
+	seq      int
+	deadline time.Time
+}
+
+// 定时器重置为最早到期的事件
+func resetTimer(timer *time.Timer, queue map[string]*watchPending) {
+	timer.Stop()
+	select {
+	case <-timer.C:
+	default:
+	}
+	var next time.Time
+	for _, p := range queue {
+		if next.IsZero() || p.deadline.Before(next) {
+			next = p.deadline
+		}
+	}
+	if !next.IsZero() {
+		timer.Reset(time.Until(next))
+	}
+}
+
+// =================== 轮询 ======================
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+type pollBackend struct {
+	w        *Watcher
+	interval time.Duration
+	mu       sync.Mutex
+	roots    map[string]bool
+	states   map[string]map[string]fileState //监听路径 -> 路径 -> 状态
+	stop     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+func newPollBackend(w *Watcher, interval time.Duration) *pollBackend {
+	b := &pollBackend{
+		w:        w,
+		interval: interval,
+		roots:    make(map[string]bool),
+		states:   make(map[string]map[string]fileState),
+		stop:     make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+func (b *pollBackend) add(path string, recursive bool) error {
+	states, err := b.scan(path, recursive)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.roots[path] = recursive
+	b.states[path] = states
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *pollBackend) remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.roots, path)
+	delete(b.states, path)
+	return nil
+}
+
+func (b *pollBackend) close() error {
+	b.once.Do(func() { close(b.stop) })
+	b.wg.Wait()
+	return nil
+}
+
+func (b *pollBackend) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.poll()
+		}
+	}
+}
+
+// 对比前后两次扫描的结果发出事件
+func (b *pollBackend) poll() {
+	b.mu.Lock()
+	roots := make(map[string]bool, len(b.roots))
+	for root, recursive := range b.roots {
+		roots[root] = recursive
+	}
+	b.mu.Unlock()
+	for root, recursive := range roots {
+		states, err := b.scan(root, recursive)
+		if err != nil && !os.IsNotExist(err) {
+			b.w.emitError(err)
+			continue
+		}
+		b.mu.Lock()
+		old, ok := b.states[root]
+		if ok {
+			b.states[root] = states
+		}
+		b.mu.Unlock()
+		if !ok {
+			continue
+		}
+		var events []WatchEvent
+		for path, s := range states {
+			prev, existed := old[path]
+			switch {
+			case !existed:
+				events = append(events, WatchEvent{Path: path, Op: WatchCreate, IsDir: s.isDir})
+			case prev.isDir != s.isDir:
+				events = append(events, WatchEvent{Path: path, Op: WatchRemove, IsDir: prev.isDir}, WatchEvent{Path: path, Op: WatchCreate, IsDir: s.isDir})
+			case !s.isDir && (!prev.modTime.Equal(s.modTime) || prev.size != s.size):
+				events = append(events, WatchEvent{Path: path, Op: WatchWrite})
+			}
+		}
+		for path, s := range old {
+			if _, ok := states[path]; !ok {
+				events = append(events, WatchEvent{Path: path, Op: WatchRemove, IsDir: s.isDir})
+			}
+		}
+		// 按路径排序，保证父目录的创建先于子项
+		sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+		for _, ev := range events {
+			b.w.emit(ev)
+		}
+	}
+}
+
+// 扫描监听路径，path不存在时返回空结果
+func (b *pollBackend) scan(path string, recursive bool) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return states, nil
+		}
+		return nil, err
+	}
+	if !info.IsDir() {
+		states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return states, nil
+	}
+	o := WalkOptions{IncludeDirs: true, OnError: func(string, error) error { return nil }}
+	if !recursive {
+		o.MaxDepth = 1
+	}
+	err = Walk(path, func(entry WalkEntry) error {
+		if entry.Info.IsDir() && b.w.excludedDir(entry.Path) {
+			return SkipDir
+		}
+		states[entry.Path] = fileState{modTime: entry.Info.ModTime(), size: entry.Info.Size(), isDir: entry.Info.IsDir()}
+		return nil
+	}, o)
+	return states, err
+}
@@ -0,0 +1,324 @@
+//go:build linux
+
+package fileutils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_DELETE_SELF | unix.IN_MOVE_SELF | unix.IN_ONLYDIR
+
+// inotify监听的目录
+// 监听单个文件时实际监听其所在目录，以便收到编辑器通过重命名替换文件产生的事件
+// 同一目录通过多个路径（如符号链接）监听时共用一个inotifyDir，path为首次监听时的路径
+type inotifyDir struct {
+	path      string
+	wd        int
+	all       bool           //目录本身被监听（监听的目录或递归的子目录），否则只关注files中的文件
+	root      bool           //通过Add添加的目录
+	recursive bool           //新建的子目录需要监听
+	files     map[string]int //通过Add添加的、位于该目录下的文件名及添加的次数
+	aliases   []string       //同一目录的其他路径
+}
+
+type inotifyBackend struct {
+	w     *Watcher
+	fd    int
+	file  *os.File
+	mu    sync.Mutex
+	dirs  map[int]*inotifyDir
+	paths map[string]*inotifyDir //inotifyDir.path及其他路径 -> 目录
+	roots map[string]bool        //通过Add添加的目录路径 -> 是否递归
+	files map[string]*inotifyDir //通过Add添加的文件路径 -> 所在目录
+	wg    sync.WaitGroup
+}
+
+func newNativeBackend(w *Watcher) (watchBackend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	b := &inotifyBackend{
+		w:     w,
+		fd:    fd,
+		file:  os.NewFile(uintptr(fd), "inotify"), //非阻塞fd由runtime轮询，Close时Read立即返回
+		dirs:  make(map[int]*inotifyDir),
+		paths: make(map[string]*inotifyDir),
+		roots: make(map[string]bool),
+		files: make(map[string]*inotifyDir),
+	}
+	b.wg.Add(1)
+	go b.read()
+	return b, nil
+}
+
+func (b *inotifyBackend) add(path string, recursive bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !info.IsDir() {
+		if _, ok := b.files[path]; ok {
+			return nil
+		}
+		d, err := b.watchDir(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		d.files[filepath.Base(path)]++
+		b.files[path] = d
+		return nil
+	}
+	if _, ok := b.roots[path]; ok {
+		return nil
+	}
+	// watchDir返回的可能是通过其他路径监听的同一目录，以返回的目录为准
+	d, err := b.watchTree(path, recursive)
+	if err != nil {
+		return err
+	}
+	d.root = true
+	b.roots[path] = recursive
+	return nil
+}
+
+// 监听目录，recursive时同时监听所有子目录，调用时需持有锁
+func (b *inotifyBackend) watchTree(path string, recursive bool) (*inotifyDir, error) {
+	d, err := b.watchDir(path)
+	if err != nil {
+		return nil, err
+	}
+	// 目录可能同时位于非递归与递归监听的路径下，任一路径递归即需递归
+	d.all, d.recursive = true, d.recursive || recursive
+	if !recursive {
+		return d, nil
+	}
+	return d, Walk(path, func(entry WalkEntry) error {
+		if !entry.Info.IsDir() {
+			return nil
+		}
+		if b.w.excludedDir(entry.Path) {
+			return SkipDir
+		}
+		sub, err := b.watchDir(entry.Path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return SkipDir
+			}
+			return err
+		}
+		sub.all, sub.recursive = true, true
+		return nil
+	}, WalkOptions{IncludeDirs: true})
+}
+
+func (b *inotifyBackend) watchDir(path string) (*inotifyDir, error) {
+	wd, err := unix.InotifyAddWatch(b.fd, path, inotifyMask)
+	if err != nil {
+		return nil, &os.PathError{Op: "inotify_add_watch", Path: path, Err: err}
+	}
+	if d, ok := b.dirs[wd]; ok {
+		// 已监听的目录，或同一目录的另一个路径（如符号链接），沿用已有的监听
+		if _, ok := b.paths[path]; !ok {
+			b.paths[path] = d
+			d.aliases = append(d.aliases, path)
+		}
+		return d, nil
+	}
+	d := &inotifyDir{path: path, wd: wd, files: make(map[string]int)}
+	b.dirs[wd] = d
+	b.paths[path] = d
+	return d, nil
+}
+
+func (b *inotifyBackend) remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.roots[path]; ok {
+		delete(b.roots, path)
+		return b.rewatch()
+	}
+	if d, ok := b.files[path]; ok {
+		delete(b.files, path)
+		name := filepath.Base(path)
+		if d.files[name]--; d.files[name] <= 0 {
+			delete(d.files, name)
+		}
+		b.unwatchIfUnused(d)
+	}
+	return nil
+}
+
+// 移除监听路径后重新计算各目录的监听状态：按剩余的Add路径重新标记，不再需要的目录取消监听
+// 目录可能被多个监听路径覆盖（嵌套或通过符号链接），无法只按路径前缀判断，调用时需持有锁
+func (b *inotifyBackend) rewatch() error {
+	for _, d := range b.dirs {
+		d.all, d.root, d.recursive = false, false, false
+	}
+	var err error
+	for path, recursive := range b.roots {
+		d, werr := b.watchTree(path, recursive)
+		if d != nil {
+			d.root = true
+		}
+		if werr != nil && !os.IsNotExist(werr) && err == nil {
+			err = werr
+		}
+	}
+	for _, d := range b.dirs {
+		b.unwatchIfUnused(d)
+	}
+	return err
+}
+
+// path及其下所有已监听的目录，包括通过其他路径监听的同一目录
+func (b *inotifyBackend) subDirs(path string) []*inotifyDir {
+	var dirs []*inotifyDir
+	seen := make(map[*inotifyDir]bool)
+	for p, d := range b.paths {
+		if (p == path || isAncestor(path, p)) && !seen[d] {
+			seen[d] = true
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+func (b *inotifyBackend) unwatchIfUnused(d *inotifyDir) {
+	if d.all || len(d.files) > 0 || b.dirs[d.wd] != d {
+		// 目录已被删除时内核已移除监听，wd可能已分配给其他目录
+		return
+	}
+	unix.InotifyRmWatch(b.fd, uint32(d.wd))
+	b.forget(d)
+}
+
+func (b *inotifyBackend) forget(d *inotifyDir) {
+	delete(b.dirs, d.wd)
+	for _, p := range append(d.aliases, d.path) {
+		if b.paths[p] == d {
+			delete(b.paths, p)
+		}
+	}
+}
+
+func (b *inotifyBackend) close() error {
+	err := b.file.Close()
+	b.wg.Wait()
+	return err
+}
+
+func (b *inotifyBackend) read() {
+	defer b.wg.Done()
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := b.file.Read(buf)
+		if err != nil {
+			select {
+			case <-b.w.done:
+			default:
+				b.w.emitError(err)
+			}
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			name := string(bytes.TrimRight(buf[nameStart:nameStart+int(raw.Len)], "\x00"))
+			offset = nameStart + int(raw.Len)
+			b.handle(int(raw.Wd), raw.Mask, name)
+		}
+	}
+}
+
+func (b *inotifyBackend) handle(wd int, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		b.w.emitError(ErrEventOverflow)
+		return
+	}
+	b.mu.Lock()
+	d, ok := b.dirs[wd]
+	if !ok {
+		b.mu.Unlock()
+		return
+	}
+	if mask&unix.IN_IGNORED != 0 {
+		b.forget(d)
+		b.mu.Unlock()
+		return
+	}
+	path, all, root, recursive := d.path, d.all, d.root, d.recursive
+	watchedFile := name != "" && d.files[name] > 0
+	isDir := mask&unix.IN_ISDIR != 0
+	if name != "" && isDir && mask&unix.IN_MOVED_FROM != 0 {
+		// 移出的目录仍被内核监听，但路径已失效
+		for _, sub := range b.subDirs(filepath.Join(path, name)) {
+			unix.InotifyRmWatch(b.fd, uint32(sub.wd))
+			b.forget(sub)
+		}
+	}
+	b.mu.Unlock()
+
+	if name == "" {
+		// 目录自身被删除或移走，子目录的变化已由上级目录的事件体现
+		if !root {
+			return
+		}
+		switch {
+		case mask&unix.IN_DELETE_SELF != 0:
+			b.w.emit(WatchEvent{Path: path, Op: WatchRemove, IsDir: true})
+		case mask&unix.IN_MOVE_SELF != 0:
+			b.w.emit(WatchEvent{Path: path, Op: WatchRename, IsDir: true})
+		}
+		return
+	}
+	if !all && !watchedFile {
+		return
+	}
+	ev := WatchEvent{Path: filepath.Join(path, name), IsDir: isDir}
+	switch {
+	case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+		ev.Op = WatchCreate
+	case mask&unix.IN_MODIFY != 0:
+		ev.Op = WatchWrite
+	case mask&unix.IN_DELETE != 0:
+		ev.Op = WatchRemove
+	case mask&unix.IN_MOVED_FROM != 0:
+		ev.Op = WatchRename
+	default:
+		return
+	}
+	b.w.emit(ev)
+	if ev.Op == WatchCreate && isDir && all && recursive && !b.w.excludedDir(ev.Path) {
+		b.watchNewDir(ev.Path)
+	}
+}
+
+// 监听新建的子目录，并为监听建立前已在其中创建的内容补发事件
+func (b *inotifyBackend) watchNewDir(path string) {
+	b.mu.Lock()
+	_, err := b.watchTree(path, true)
+	b.mu.Unlock()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			b.w.emitError(err)
+		}
+		return
+	}
+	Walk(path, func(entry WalkEntry) error {
+		if entry.Info.IsDir() && b.w.excludedDir(entry.Path) {
+			return SkipDir
+		}
+		b.w.emit(WatchEvent{Path: entry.Path, Op: WatchCreate, IsDir: entry.Info.IsDir()})
+		return nil
+	}, WalkOptions{IncludeDirs: true, OnError: func(string, error) error { return nil }})
+}
@@ -0,0 +1,130 @@
+//go:build linux
+
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInotifyBackend(t *testing.T) {
+	w, err := NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, ok := w.backend.(*inotifyBackend); !ok {
+		t.Fatalf("backend is %T, want *inotifyBackend", w.backend)
+	}
+}
+
+// 同一目录通过符号链接再次添加时沿用已有的监听，移除其中一个路径不影响另一个
+func TestWatchSymlinkAlias(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real")
+	link := filepath.Join(dir, "link")
+	if err := os.MkdirAll(filepath.Join(real, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", link); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(WatchOptions{Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Add(real); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(filepath.Join(link, "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(real, "sub", "a.txt")
+	writeFile(t, p, "a")
+	waitEvent(t, w, p, WatchCreate)
+
+	if err := w.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Remove(filepath.Join(link, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	q := filepath.Join(real, "sub", "b.txt")
+	writeFile(t, q, "b")
+	waitEvent(t, w, q, WatchCreate)
+
+	if err := w.Remove(real); err != nil {
+		t.Fatal(err)
+	}
+	drainEvents(w, 100*time.Millisecond)
+	b := w.backend.(*inotifyBackend)
+	b.mu.Lock()
+	n := len(b.dirs)
+	b.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("%d directories still watched after removing all paths", n)
+	}
+	writeFile(t, filepath.Join(real, "sub", "c.txt"), "c")
+	expectNoEvent(t, w, "", 200*time.Millisecond)
+}
+
+// 嵌套的监听路径：移除外层递归监听后，内层递归监听的子目录仍被监听
+func TestWatchNestedRemove(t *testing.T) {
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(filepath.Join(inner, "c"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(WatchOptions{Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(inner); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "a", "x.txt"), "x")
+	p := filepath.Join(inner, "c", "y.txt")
+	writeFile(t, p, "y")
+	ev := waitEvent(t, w, p, WatchCreate)
+	if ev.Path != p {
+		t.Fatalf("got %+v", ev)
+	}
+	expectNoEvent(t, w, filepath.Join(dir, "a", "x.txt"), 200*time.Millisecond)
+}
+
+// 监听的目录被删除后，之前添加的文件可以安全移除
+func TestWatchRemoveAfterDelete(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(sub, "a.txt")
+	writeFile(t, p, "a")
+	w, err := Watch(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := os.RemoveAll(sub); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, w, p, WatchRemove)
+	if err := w.Remove(p); err != nil {
+		t.Fatal(err)
+	}
+}
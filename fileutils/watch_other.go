@@ -0,0 +1,10 @@
+//go:build !linux
+
+package fileutils
+
+import "errors"
+
+// 非Linux系统使用轮询
+func newNativeBackend(w *Watcher) (watchBackend, error) {
+	return nil, errors.New("native file watching is not supported on this platform")
+}
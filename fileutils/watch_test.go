@@ -0,0 +1,317 @@
+package fileutils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const watchTimeout = 5 * time.Second
+
+// 读取事件直到出现path上包含op的事件
+func waitEvent(t *testing.T, w *Watcher, path string, op WatchOp) WatchEvent {
+	t.Helper()
+	timeout := time.After(watchTimeout)
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				t.Fatalf("events closed while waiting for %s %s", op, path)
+			}
+			if ev.Path == path && ev.Op&op != 0 {
+				return ev
+			}
+		case err := <-w.Errors:
+			t.Fatalf("watch error: %v", err)
+		case <-timeout:
+			t.Fatalf("timeout waiting for %s %s", op, path)
+		}
+	}
+}
+
+// 在d时间内不应收到path上的事件，path为空时不应收到任何事件
+func expectNoEvent(t *testing.T, w *Watcher, path string, d time.Duration) {
+	t.Helper()
+	timeout := time.After(d)
+	for {
+		select {
+		case ev := <-w.Events:
+			if path == "" || ev.Path == path {
+				t.Fatalf("unexpected event %s %s", ev.Op, ev.Path)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// 丢弃d时间内收到的事件
+func drainEvents(w *Watcher, d time.Duration) {
+	timeout := time.After(d)
+	for {
+		select {
+		case <-w.Events:
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 分别使用inotify（Linux）与轮询测试
+var watchModes = []struct {
+	name string
+	opts WatchOptions
+}{
+	{"native", WatchOptions{}},
+	{"poll", WatchOptions{Poll: true, PollInterval: 20 * time.Millisecond}},
+}
+
+func TestWatchDir(t *testing.T) {
+	for _, mode := range watchModes {
+		t.Run(mode.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := Watch(dir, mode.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			p := filepath.Join(dir, "a.txt")
+			writeFile(t, p, "1")
+			waitEvent(t, w, p, WatchCreate)
+			// 轮询依赖修改时间与大小，写入不同长度的内容
+			writeFile(t, p, "22")
+			waitEvent(t, w, p, WatchWrite)
+			q := filepath.Join(dir, "b.txt")
+			if err := os.Rename(p, q); err != nil {
+				t.Fatal(err)
+			}
+			waitEvent(t, w, p, WatchRename|WatchRemove)
+			waitEvent(t, w, q, WatchCreate)
+			if err := os.Remove(q); err != nil {
+				t.Fatal(err)
+			}
+			waitEvent(t, w, q, WatchRemove)
+
+			// 非递归监听不发出子目录下的事件
+			sub := filepath.Join(dir, "sub")
+			if err := os.Mkdir(sub, 0755); err != nil {
+				t.Fatal(err)
+			}
+			waitEvent(t, w, sub, WatchCreate)
+			writeFile(t, filepath.Join(sub, "c.txt"), "c")
+			expectNoEvent(t, w, filepath.Join(sub, "c.txt"), 200*time.Millisecond)
+		})
+	}
+}
+
+func TestWatchRecursive(t *testing.T) {
+	for _, mode := range watchModes {
+		t.Run(mode.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, "old", "deep"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			opts := mode.opts
+			opts.Recursive = true
+			w, err := Watch(dir, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			p := filepath.Join(dir, "old", "deep", "a.txt")
+			writeFile(t, p, "a")
+			waitEvent(t, w, p, WatchCreate)
+
+			// 新建的子目录被监听，监听建立前已创建的内容也会发出事件
+			nested := filepath.Join(dir, "new", "x", "y")
+			if err := os.MkdirAll(nested, 0755); err != nil {
+				t.Fatal(err)
+			}
+			q := filepath.Join(nested, "b.txt")
+			writeFile(t, q, "b")
+			waitEvent(t, w, q, WatchCreate)
+			writeFile(t, q, "bb")
+			waitEvent(t, w, q, WatchWrite)
+		})
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	for _, mode := range watchModes {
+		t.Run(mode.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := filepath.Join(dir, "config.yaml")
+			writeFile(t, p, "v1")
+			w, err := Watch(p, mode.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			writeFile(t, filepath.Join(dir, "other.txt"), "x")
+			writeFile(t, p, "v22")
+			ev := waitEvent(t, w, p, WatchWrite)
+			if ev.IsDir {
+				t.Fatalf("unexpected IsDir event %+v", ev)
+			}
+			// 原子替换（写入临时文件后重命名）
+			if err := WriteFile(p, "v333"); err != nil {
+				t.Fatal(err)
+			}
+			waitEvent(t, w, p, WatchCreate|WatchWrite)
+			expectNoEvent(t, w, filepath.Join(dir, "other.txt"), 100*time.Millisecond)
+
+			if err := w.Remove(p); err != nil {
+				t.Fatal(err)
+			}
+			// 丢弃移除前已发出的事件后再修改
+			drainEvents(w, 100*time.Millisecond)
+			writeFile(t, p, "v4444")
+			expectNoEvent(t, w, "", 200*time.Millisecond)
+		})
+	}
+}
+
+func TestWatchFilter(t *testing.T) {
+	for _, mode := range watchModes {
+		t.Run(mode.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "node_modules"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			opts := mode.opts
+			opts.Recursive = true
+			opts.Include = []string{"*.go"}
+			opts.Exclude = []string{"node_modules/", "*_test.go"}
+			w, err := Watch(dir, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			writeFile(t, filepath.Join(dir, "node_modules", "x.go"), "x")
+			writeFile(t, filepath.Join(dir, "a_test.go"), "t")
+			writeFile(t, filepath.Join(dir, "a.txt"), "t")
+			p := filepath.Join(dir, "a.go")
+			writeFile(t, p, "a")
+			// 按顺序发出，收到a.go之前的事件均应被过滤
+			select {
+			case ev := <-w.Events:
+				if ev.Path != p {
+					t.Fatalf("unexpected event %s %s", ev.Op, ev.Path)
+				}
+			case <-time.After(watchTimeout):
+				t.Fatal("timeout waiting for a.go")
+			}
+		})
+	}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Watch(dir, WatchOptions{Debounce: 150 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 连续多次写入合并为一个事件
+	p := filepath.Join(dir, "a.txt")
+	for i := 0; i < 5; i++ {
+		writeFile(t, p, string(rune('a'+i)))
+		time.Sleep(20 * time.Millisecond)
+	}
+	ev := waitEvent(t, w, p, WatchCreate)
+	if ev.Op&WatchRemove != 0 {
+		t.Fatalf("unexpected op %s", ev.Op)
+	}
+	expectNoEvent(t, w, p, 300*time.Millisecond)
+
+	// 创建后又删除的文件相互抵消
+	q := filepath.Join(dir, "tmp.txt")
+	writeFile(t, q, "x")
+	if err := os.Remove(q); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "marker"), "m")
+	waitEvent(t, w, filepath.Join(dir, "marker"), WatchCreate)
+	expectNoEvent(t, w, q, 300*time.Millisecond)
+}
+
+func TestCoalesce(t *testing.T) {
+	tests := []struct {
+		prev, next WatchOp
+		want       WatchOp
+		keep       bool
+	}{
+		{WatchWrite, WatchWrite, WatchWrite, true},
+		{WatchCreate, WatchWrite, WatchCreate | WatchWrite, true},
+		{WatchCreate, WatchRemove, 0, false},
+		{WatchCreate, WatchRename, 0, false},
+		{WatchRemove, WatchCreate, WatchWrite, true},
+		{WatchRename, WatchCreate, WatchWrite, true},
+		{WatchWrite, WatchRemove, WatchWrite | WatchRemove, true},
+	}
+	for _, tt := range tests {
+		ev := WatchEvent{Path: "a", Op: tt.prev}
+		keep := coalesce(&ev, WatchEvent{Path: "a", Op: tt.next})
+		if keep != tt.keep || (keep && ev.Op != tt.want) {
+			t.Errorf("coalesce(%s, %s) = %s, %v; want %s, %v", tt.prev, tt.next, ev.Op, keep, tt.want, tt.keep)
+		}
+	}
+	if s := (WatchCreate | WatchWrite).String(); s != "CREATE|WRITE" {
+		t.Fatalf("String() = %q", s)
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	for _, mode := range watchModes {
+		t.Run(mode.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := Watch(dir, mode.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 未读取的事件不会阻塞Close
+			for i := 0; i < 100; i++ {
+				writeFile(t, filepath.Join(dir, string(rune('a'+i%26))+".txt"), string(make([]byte, i)))
+			}
+			done := make(chan error, 1)
+			go func() { done <- w.Close() }()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(watchTimeout):
+				t.Fatal("Close blocked")
+			}
+			for range w.Events {
+			}
+			if _, ok := <-w.Errors; ok {
+				t.Fatal("Errors not closed")
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("second Close: %v", err)
+			}
+			if err := w.Add(dir); !errors.Is(err, ErrWatcherClosed) {
+				t.Fatalf("Add after Close: got %v, want ErrWatcherClosed", err)
+			}
+		})
+	}
+
+	if _, err := Watch(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Fatalf("got %v, want not exist", err)
+	}
+}
@@ -0,0 +1,261 @@
+package fileutils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// 新建文件的默认权限
+const DefaultFilePerm os.FileMode = 0644
+
+//WriteOptions 写入参数，零值字段使用默认值
+type WriteOptions struct {
+	//Perm 文件权限，默认文件已存在时保留原权限，否则为DefaultFilePerm
+	Perm os.FileMode
+	//CreateDir 文件夹不存在时自动创建
+	CreateDir bool
+	//Append 追加写入原文件，写入后同步到磁盘
+	//追加无法通过临时文件完成，中途失败时文件末尾可能只写入了部分内容
+	Append bool
+}
+
+//WriteFileAtomic 原子写入文件：先写入同目录的临时文件并同步到磁盘，再重命名替换目标文件并同步目录
+//写入过程中崩溃或失败时，目标文件保持原内容不变，不会出现只写入一半的文件
+//filePath为符号链接时写入链接指向的文件；opts 为非必需参数
+func WriteFileAtomic(filePath string, write func(w io.Writer) error, opts ...WriteOptions) error {
+	if filePath == "" {
+		return errors.New("path can not be empty")
+	}
+	o := writeOptions(opts)
+	if o.CreateDir {
+		if err := CreateDir(filepath.Dir(filePath)); err != nil {
+			return err
+		}
+	}
+	if o.Append {
+		return appendFile(filePath, write, o.Perm)
+	}
+	target, err := resolveLink(filePath)
+	if err != nil {
+		return err
+	}
+	perm := o.Perm
+	if perm == 0 {
+		perm = DefaultFilePerm
+		if info, err := os.Stat(target); err == nil {
+			perm = info.Mode().Perm()
+		}
+	}
+
+	dir := filepath.Dir(target)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return err
+	}
+	renamed = true
+	return SyncDir(dir)
+}
+
+//WriteBytes 原子写入字节内容，opts 为非必需参数
+func WriteBytes(filePath string, data []byte, opts ...WriteOptions) error {
+	return WriteFileAtomic(filePath, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}, opts...)
+}
+
+//WriteReader 原子写入r中的全部内容，opts 为非必需参数
+func WriteReader(filePath string, r io.Reader, opts ...WriteOptions) error {
+	return WriteFileAtomic(filePath, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	}, opts...)
+}
+
+//AppendFile 追加写入文本内容并同步到磁盘，文件不存在时创建
+// @param force 文件夹不存在时自动创建
+func AppendFile(filePath string, body string, forces ...bool) error {
+	return WriteBytes(filePath, []byte(body), WriteOptions{Append: true, CreateDir: len(forces) > 0 && forces[0]})
+}
+
+//ReplaceFile 用replacement文件替换filePath，并将filePath的原内容保存到backupPath
+//backupPath 为非必需参数，默认为filePath+".bak"，已存在的备份会被覆盖
+//替换通过重命名完成，任何时刻filePath都是完整的原文件或新文件；替换后保留filePath的原权限
+//filePath不存在时不生成备份；replacement与filePath不在同一文件系统时先复制再替换
+func ReplaceFile(filePath string, replacement string, backupPath ...string) error {
+	if filePath == "" || replacement == "" {
+		return errors.New("path can not be empty")
+	}
+	backup := filePath + ".bak"
+	if len(backupPath) > 0 && backupPath[0] != "" {
+		backup = backupPath[0]
+	}
+	info, err := os.Stat(filePath)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if exists {
+		if err := backupFile(filePath, backup, info.Mode().Perm()); err != nil {
+			return err
+		}
+		if err := os.Chmod(replacement, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(replacement, filePath); err != nil {
+		// 跨文件系统无法重命名，复制到filePath所在目录后再原子替换
+		src, oerr := os.Open(replacement)
+		if oerr != nil {
+			return err
+		}
+		err = WriteReader(filePath, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+		return os.Remove(replacement)
+	}
+	if err := SyncDir(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(replacement); dir != filepath.Dir(filePath) {
+		return SyncDir(dir)
+	}
+	return nil
+}
+
+//ReplaceFileBytes 原子写入data替换filePath，并将原内容保存到backupPath
+//backupPath 为非必需参数，默认为filePath+".bak"
+func ReplaceFileBytes(filePath string, data []byte, backupPath ...string) error {
+	backup := filePath + ".bak"
+	if len(backupPath) > 0 && backupPath[0] != "" {
+		backup = backupPath[0]
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		if err := backupFile(filePath, backup, info.Mode().Perm()); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return WriteBytes(filePath, data)
+}
+
+//SyncDir 将文件夹同步到磁盘，使其中文件的创建、重命名在崩溃后仍然有效
+//Windows不支持同步文件夹，直接返回nil
+func SyncDir(dirPath string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func writeOptions(opts []WriteOptions) WriteOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return WriteOptions{}
+}
+
+func appendFile(filePath string, write func(w io.Writer) error, perm os.FileMode) error {
+	if perm == 0 {
+		perm = DefaultFilePerm
+	}
+	_, statErr := os.Stat(filePath)
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, perm)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && os.IsNotExist(statErr) {
+		// 新建的文件需同步目录项
+		err = SyncDir(filepath.Dir(filePath))
+	}
+	return err
+}
+
+// 符号链接返回其指向的文件路径，文件不存在时返回原路径
+func resolveLink(filePath string) (string, error) {
+	info, err := os.Lstat(filePath)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return filePath, nil
+	}
+	target, err := filepath.EvalSymlinks(filePath)
+	if os.IsNotExist(err) {
+		// 悬空链接，写入链接指向的路径
+		link, lerr := os.Readlink(filePath)
+		if lerr != nil {
+			return "", lerr
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(filePath), link)
+		}
+		return link, nil
+	}
+	return target, err
+}
+
+// 原子生成备份：优先创建硬链接，不支持时复制内容
+func backupFile(filePath string, backup string, perm os.FileMode) error {
+	if filepath.Clean(filePath) == filepath.Clean(backup) {
+		return errors.New("backup path is the same as file path")
+	}
+	dir := filepath.Dir(backup)
+	tmp := filepath.Join(dir, "."+filepath.Base(backup)+".link.tmp")
+	os.Remove(tmp)
+	if err := os.Link(filePath, tmp); err == nil {
+		if err := os.Rename(tmp, backup); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return SyncDir(dir)
+	}
+	src, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return WriteReader(backup, src, WriteOptions{Perm: perm})
+}
@@ -0,0 +1,264 @@
+package fileutils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readString(t *testing.T, filePath string) string {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func checkPerm(t *testing.T, filePath string, perm os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != perm {
+		t.Fatalf("%s: perm %v, want %v", filePath, info.Mode().Perm(), perm)
+	}
+}
+
+// 检查dir下没有遗留的临时文件
+func checkNoTemp(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Fatalf("temporary file left: %s", e.Name())
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "config.json")
+	if err := WriteFile(p, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p); got != "v1" {
+		t.Fatalf("got %q", got)
+	}
+	checkPerm(t, p, DefaultFilePerm)
+
+	// 已存在的文件保留原权限
+	if err := os.Chmod(p, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBytes(p, []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p); got != "v2" {
+		t.Fatalf("got %q", got)
+	}
+	checkPerm(t, p, 0600)
+	if err := WriteReader(p, strings.NewReader("v3"), WriteOptions{Perm: 0640}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p); got != "v3" {
+		t.Fatalf("got %q", got)
+	}
+	checkPerm(t, p, 0640)
+
+	// 写入失败时保留原内容且不留下临时文件
+	errWrite := errors.New("write failed")
+	err := WriteFileAtomic(p, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("got %v, want %v", err, errWrite)
+	}
+	if got := readString(t, p); got != "v3" {
+		t.Fatalf("content changed after failed write: %q", got)
+	}
+	checkNoTemp(t, dir)
+
+	if err := WriteBytes("", nil); err == nil {
+		t.Fatal("expected error for empty path")
+	}
+}
+
+func TestWriteFileCreateDir(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "a", "b", "c.txt")
+	if err := WriteFile(p, "x"); err == nil {
+		t.Fatal("expected error when directory does not exist")
+	}
+	if err := WriteFile(p, "x", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p); got != "x" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Skip("symlink not supported:", err)
+	}
+	if err := WriteFile(link, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("link replaced by regular file: %v", err)
+	}
+	if got := readString(t, target); got != "new" {
+		t.Fatalf("got %q", got)
+	}
+	checkPerm(t, target, 0600)
+
+	// 悬空链接写入其指向的路径
+	dangling := filepath.Join(dir, "dangling.txt")
+	if err := os.Symlink("missing.txt", dangling); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(dangling, "created"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, filepath.Join(dir, "missing.txt")); got != "created" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestAppendFile(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "sub", "app.log")
+	if err := AppendFile(p, "a"); err == nil {
+		t.Fatal("expected error when directory does not exist")
+	}
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		if err := AppendFile(p, line, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readString(t, p); got != "a\nb\nc\n" {
+		t.Fatalf("got %q", got)
+	}
+	checkPerm(t, p, DefaultFilePerm)
+
+	q := filepath.Join(dir, "perm.log")
+	if err := WriteBytes(q, []byte("x"), WriteOptions{Append: true, Perm: 0600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBytes(q, []byte("y"), WriteOptions{Append: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, q); got != "xy" {
+		t.Fatalf("got %q", got)
+	}
+	checkPerm(t, q, 0600)
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(p, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	repl := filepath.Join(dir, "app.conf.new")
+	if err := os.WriteFile(repl, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceFile(p, repl); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p); got != "new" {
+		t.Fatalf("got %q", got)
+	}
+	if got := readString(t, p+".bak"); got != "old" {
+		t.Fatalf("backup: got %q", got)
+	}
+	checkPerm(t, p, 0600)
+	if _, err := os.Stat(repl); !os.IsNotExist(err) {
+		t.Fatalf("replacement still exists: %v", err)
+	}
+
+	// 指定备份路径，已存在的备份被覆盖
+	backup := filepath.Join(dir, "backup", "app.conf.1")
+	if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backup, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(repl, []byte("newer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceFile(p, repl, backup); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p); got != "newer" {
+		t.Fatalf("got %q", got)
+	}
+	if got := readString(t, backup); got != "new" {
+		t.Fatalf("backup: got %q", got)
+	}
+	checkNoTemp(t, filepath.Dir(backup))
+
+	// 原文件不存在时不生成备份
+	fresh := filepath.Join(dir, "fresh.conf")
+	if err := os.WriteFile(repl, []byte("fresh"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceFile(fresh, repl); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, fresh); got != "fresh" {
+		t.Fatalf("got %q", got)
+	}
+	if _, err := os.Stat(fresh + ".bak"); !os.IsNotExist(err) {
+		t.Fatalf("unexpected backup: %v", err)
+	}
+
+	if err := ReplaceFile(p, repl, p); err == nil {
+		t.Fatal("expected error when backup path equals file path")
+	}
+	if got := readString(t, p); got != "newer" {
+		t.Fatalf("content changed after failed replace: %q", got)
+	}
+}
+
+func TestReplaceFileBytes(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "data.txt")
+	if err := ReplaceFileBytes(p, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p + ".bak"); !os.IsNotExist(err) {
+		t.Fatalf("unexpected backup: %v", err)
+	}
+	if err := os.Chmod(p, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceFileBytes(p, []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, p); got != "v2" {
+		t.Fatalf("got %q", got)
+	}
+	if got := readString(t, p+".bak"); got != "v1" {
+		t.Fatalf("backup: got %q", got)
+	}
+	checkPerm(t, p, 0640)
+	checkNoTemp(t, dir)
+}
@@ -1,16 +1,24 @@
 module github.com/youngchan1988/gocommon
 
-go 1.16
+// securityutils 的 ECDH 使用 Go 1.20 引入的 crypto/ecdh；klauspost/compress 固定在支持 Go 1.20 的 v1.17.9
+go 1.20
 
 require (
 	github.com/asktop/decimal v0.0.0-20200813110750-1fd2e930e988 // indirect
 	github.com/asktop/gotools v0.0.0-20210326083124-f1d92b51ec7e
+	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/jonboulle/clockwork v0.2.2 // indirect
+	github.com/klauspost/compress v1.17.9
+	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
 	github.com/lestrrat-go/strftime v1.0.4 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rs/zerolog v1.21.0
 	github.com/shopspring/decimal v1.2.0
 	github.com/stretchr/testify v1.4.0 // indirect
+	github.com/zeebo/blake3 v0.2.3
+	golang.org/x/crypto v0.10.0
+	golang.org/x/sys v0.9.0
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
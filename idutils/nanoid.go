@@ -0,0 +1,32 @@
+package idutils
+
+import "errors"
+
+//DefaultNanoIDSize NanoID默认长度，碰撞概率与UUID v4相当
+const DefaultNanoIDSize = 21
+
+//NanoID 生成URL安全的短ID，size为非必需参数，默认21位
+func NanoID(size ...int) (string, error) {
+	n := DefaultNanoIDSize
+	if len(size) > 0 {
+		n = size[0]
+	}
+	return NanoIDWithAlphabet(AlphabetURLSafe, n)
+}
+
+//NanoIDWithAlphabet 使用自定义字符集生成短ID
+func NanoIDWithAlphabet(alphabet string, size int) (string, error) {
+	if size <= 0 {
+		return "", errors.New("nanoid size must be greater than 0")
+	}
+	return randomString(size, alphabet)
+}
+
+//IsNanoID 判断id是否为合法的NanoID，size为期望长度，alphabet为非必需参数，默认AlphabetURLSafe
+func IsNanoID(id string, size int, alphabet ...string) bool {
+	chars := AlphabetURLSafe
+	if len(alphabet) > 0 {
+		chars = alphabet[0]
+	}
+	return len(id) == size && InAlphabet(id, chars)
+}
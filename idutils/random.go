@@ -0,0 +1,127 @@
+// Package idutils
+// Description: 密码学安全的随机数、随机字符串以及UUID、ULID、Snowflake、NanoID等ID生成
+//
+package idutils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"math/bits"
+
+	"github.com/youngchan1988/gocommon/securityutils"
+)
+
+//常用字符集
+const (
+	AlphabetNumeric      = "0123456789"
+	AlphabetLower        = "abcdefghijklmnopqrstuvwxyz"
+	AlphabetUpper        = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	AlphabetAlpha        = AlphabetUpper + AlphabetLower
+	AlphabetAlphanumeric = AlphabetNumeric + AlphabetAlpha
+	AlphabetHex          = "0123456789abcdef"
+	AlphabetURLSafe      = "_-" + AlphabetAlphanumeric                                //NanoID默认字符集
+	AlphabetReadable     = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz" //去除0、O、1、I、l、o等易混淆字符
+)
+
+//RandomBytes 生成n位密码学安全的随机字节
+func RandomBytes(n int) ([]byte, error) {
+	return securityutils.RandomBytes(n)
+}
+
+//RandomString 生成长度为n的随机字符串，alphabet为非必需参数，默认AlphabetAlphanumeric
+//字符均匀分布，不存在取模偏差
+func RandomString(n int, alphabet ...string) (string, error) {
+	chars := AlphabetAlphanumeric
+	if len(alphabet) > 0 {
+		chars = alphabet[0]
+	}
+	return randomString(n, chars)
+}
+
+//RandomHex 生成byteLen位随机字节并以十六进制字符串返回，长度为2*byteLen
+func RandomHex(byteLen int) (string, error) {
+	b, err := RandomBytes(byteLen)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+//RandomToken 生成byteLen位随机字节并以URL安全的无填充base64返回，适用于会话、重置密码等令牌
+func RandomToken(byteLen int) (string, error) {
+	b, err := RandomBytes(byteLen)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+//RandomInt 生成[0, max)范围内的随机整数
+func RandomInt(max int64) (int64, error) {
+	if max <= 0 {
+		return 0, errors.New("max must be greater than 0")
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(max))
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64(), nil
+}
+
+//RandomIntRange 生成[min, max]范围内的随机整数
+func RandomIntRange(min int64, max int64) (int64, error) {
+	if min > max {
+		return 0, errors.New("min must not be greater than max")
+	}
+	n, err := rand.Int(rand.Reader, new(big.Int).Add(new(big.Int).Sub(big.NewInt(max), big.NewInt(min)), big.NewInt(1)))
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64() + min, nil
+}
+
+// 按掩码取随机字节并丢弃超出字符集范围的值，保证均匀分布
+func randomString(n int, alphabet string) (string, error) {
+	if n < 0 {
+		return "", errors.New("length can not be negative")
+	}
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return "", errors.New("alphabet length must be between 2 and 256")
+	}
+	mask := byte(1<<uint(bits.Len(uint(len(alphabet)-1))) - 1)
+	// 预估所需随机字节数，减少读取次数
+	step := n*2 + 16
+	dst := make([]byte, 0, n)
+	for len(dst) < n {
+		buf, err := RandomBytes(step)
+		if err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if idx := int(b & mask); idx < len(alphabet) {
+				dst = append(dst, alphabet[idx])
+				if len(dst) == n {
+					break
+				}
+			}
+		}
+	}
+	return string(dst), nil
+}
+
+//InAlphabet 判断s的所有字符是否都属于alphabet
+func InAlphabet(s string, alphabet string) bool {
+	var set [256]bool
+	for i := 0; i < len(alphabet); i++ {
+		set[alphabet[i]] = true
+	}
+	for i := 0; i < len(s); i++ {
+		if !set[s[i]] {
+			return false
+		}
+	}
+	return true
+}
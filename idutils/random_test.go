@@ -0,0 +1,94 @@
+package idutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRandomString(t *testing.T) {
+	for _, alphabet := range []string{AlphabetNumeric, AlphabetHex, AlphabetReadable, "ab"} {
+		s, err := RandomString(64, alphabet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 64 || !InAlphabet(s, alphabet) {
+			t.Fatalf("unexpected string %q for alphabet %q", s, alphabet)
+		}
+	}
+	if s, _ := RandomString(0); s != "" {
+		t.Fatal("zero length should return empty string")
+	}
+	if _, err := RandomString(8, "a"); err == nil {
+		t.Fatal("single character alphabet was accepted")
+	}
+	if _, err := RandomString(-1); err == nil {
+		t.Fatal("negative length was accepted")
+	}
+}
+
+func TestRandomStringDistribution(t *testing.T) {
+	// 字符集长度不是2的幂，取模偏差会使前几个字符明显偏多
+	const alphabet = "abcdefghij"
+	s, err := RandomString(100000, alphabet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range alphabet {
+		n := strings.Count(s, string(c))
+		if n < 9000 || n > 11000 {
+			t.Fatalf("character %c appeared %d times", c, n)
+		}
+	}
+}
+
+func TestRandomHelpers(t *testing.T) {
+	if h, err := RandomHex(16); err != nil || len(h) != 32 || !InAlphabet(h, AlphabetHex) {
+		t.Fatalf("RandomHex: %q %v", h, err)
+	}
+	if tok, err := RandomToken(32); err != nil || len(tok) != 43 || !InAlphabet(tok, AlphabetURLSafe) {
+		t.Fatalf("RandomToken: %q %v", tok, err)
+	}
+	for i := 0; i < 1000; i++ {
+		n, err := RandomIntRange(-3, 3)
+		if err != nil || n < -3 || n > 3 {
+			t.Fatalf("RandomIntRange: %d %v", n, err)
+		}
+		if n, _ := RandomInt(5); n < 0 || n >= 5 {
+			t.Fatalf("RandomInt: %d", n)
+		}
+	}
+	if _, err := RandomInt(0); err == nil {
+		t.Fatal("max 0 was accepted")
+	}
+	if _, err := RandomIntRange(2, 1); err == nil {
+		t.Fatal("min greater than max was accepted")
+	}
+}
+
+func TestNanoID(t *testing.T) {
+	id, err := NanoID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsNanoID(id, DefaultNanoIDSize) {
+		t.Fatalf("invalid nanoid %q", id)
+	}
+	custom, err := NanoIDWithAlphabet(AlphabetNumeric, 10)
+	if err != nil || !IsNanoID(custom, 10, AlphabetNumeric) {
+		t.Fatalf("custom nanoid %q: %v", custom, err)
+	}
+	if IsNanoID(id+"!", DefaultNanoIDSize+1) {
+		t.Fatal("invalid character was accepted")
+	}
+	if _, err := NanoID(0); err == nil {
+		t.Fatal("size 0 was accepted")
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		id, _ := NanoID(8)
+		if seen[id] {
+			t.Fatalf("duplicate nanoid %s", id)
+		}
+		seen[id] = true
+	}
+}
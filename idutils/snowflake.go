@@ -0,0 +1,146 @@
+package idutils
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Snowflake ID结构（64位）：
+// | 0(1) | 毫秒时间戳，相对epoch(41) | workerID(10) | 序列号(12) |
+const (
+	SnowflakeWorkerBits   = 10
+	SnowflakeSequenceBits = 12
+	SnowflakeMaxWorkerID  = 1<<SnowflakeWorkerBits - 1
+
+	snowflakeTimeBits    = 41
+	snowflakeMaxSequence = 1<<SnowflakeSequenceBits - 1
+	snowflakeMaxTime     = 1<<snowflakeTimeBits - 1
+	// 时钟回拨在该范围内时等待追平，超出则返回error
+	snowflakeMaxBackward = 10 * time.Millisecond
+)
+
+//DefaultSnowflakeEpoch 默认起始时间 2020-01-01 00:00:00 UTC
+var DefaultSnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//Snowflake Snowflake ID生成器，可并发使用
+type Snowflake struct {
+	mu       sync.Mutex
+	epoch    int64 // 毫秒
+	workerID int64
+	lastMs   int64
+	sequence int64
+	now      func() time.Time
+}
+
+//SnowflakeID 解析后的Snowflake ID
+type SnowflakeID struct {
+	ID       int64
+	Time     time.Time
+	WorkerID int64
+	Sequence int64
+}
+
+//NewSnowflake 创建Snowflake ID生成器，workerID取值范围[0, 1023]，同一集群内必须唯一
+//epoch 为非必需参数，默认DefaultSnowflakeEpoch，解析时必须使用相同的epoch
+func NewSnowflake(workerID int64, epoch ...time.Time) (*Snowflake, error) {
+	if workerID < 0 || workerID > SnowflakeMaxWorkerID {
+		return nil, errors.New("snowflake worker id must be between 0 and " + strconv.Itoa(SnowflakeMaxWorkerID))
+	}
+	e := DefaultSnowflakeEpoch
+	if len(epoch) > 0 {
+		e = epoch[0]
+	}
+	if e.After(time.Now()) {
+		return nil, errors.New("snowflake epoch can not be in the future")
+	}
+	return &Snowflake{epoch: toMillis(e), workerID: workerID, lastMs: -1, now: time.Now}, nil
+}
+
+//Next 生成下一个ID，同一毫秒内序列号用尽时等待下一毫秒
+func (s *Snowflake) Next() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := toMillis(s.now()) - s.epoch
+	if ms < s.lastMs {
+		backward := time.Duration(s.lastMs-ms) * time.Millisecond
+		if backward > snowflakeMaxBackward {
+			return 0, errors.New("snowflake clock moved backwards by " + backward.String())
+		}
+		ms = s.waitUntil(s.lastMs)
+	}
+	if ms == s.lastMs {
+		s.sequence = (s.sequence + 1) & snowflakeMaxSequence
+		if s.sequence == 0 {
+			ms = s.waitUntil(s.lastMs + 1)
+		}
+	} else {
+		s.sequence = 0
+	}
+	if ms > snowflakeMaxTime {
+		return 0, errors.New("snowflake timestamp overflow, epoch is too early")
+	}
+	s.lastMs = ms
+	return ms<<(SnowflakeWorkerBits+SnowflakeSequenceBits) | s.workerID<<SnowflakeSequenceBits | s.sequence, nil
+}
+
+//NextString 生成下一个ID的十进制字符串，便于前端等不支持64位整数的场景
+func (s *Snowflake) NextString() (string, error) {
+	id, err := s.Next()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+//Parse 使用生成器的epoch解析ID
+func (s *Snowflake) Parse(id int64) (*SnowflakeID, error) {
+	return parseSnowflake(id, s.epoch)
+}
+
+//ParseSnowflake 解析Snowflake ID，epoch 为非必需参数，默认DefaultSnowflakeEpoch
+func ParseSnowflake(id int64, epoch ...time.Time) (*SnowflakeID, error) {
+	e := DefaultSnowflakeEpoch
+	if len(epoch) > 0 {
+		e = epoch[0]
+	}
+	return parseSnowflake(id, toMillis(e))
+}
+
+//ParseSnowflakeString 解析十进制字符串形式的Snowflake ID
+func ParseSnowflakeString(id string, epoch ...time.Time) (*SnowflakeID, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid snowflake id")
+	}
+	return ParseSnowflake(n, epoch...)
+}
+
+func parseSnowflake(id int64, epoch int64) (*SnowflakeID, error) {
+	if id < 0 {
+		return nil, errors.New("invalid snowflake id")
+	}
+	ms := id>>(SnowflakeWorkerBits+SnowflakeSequenceBits) + epoch
+	return &SnowflakeID{
+		ID:       id,
+		Time:     time.Unix(ms/1000, ms%1000*int64(time.Millisecond)),
+		WorkerID: id >> SnowflakeSequenceBits & SnowflakeMaxWorkerID,
+		Sequence: id & snowflakeMaxSequence,
+	}, nil
+}
+
+func (s *Snowflake) waitUntil(ms int64) int64 {
+	for {
+		now := toMillis(s.now()) - s.epoch
+		if now >= ms {
+			return now
+		}
+		time.Sleep(time.Duration(ms-now) * time.Millisecond)
+	}
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,103 @@
+package idutils
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnowflake(t *testing.T) {
+	s, err := NewSnowflake(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for i := 0; i < 10000; i++ {
+		id, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("ids are not increasing: %d <= %d", id, last)
+		}
+		last = id
+	}
+	parsed, err := s.Parse(last)
+	if err != nil || parsed.WorkerID != 42 || time.Since(parsed.Time) > time.Second {
+		t.Fatalf("unexpected parsed id %+v: %v", parsed, err)
+	}
+	str, _ := s.NextString()
+	if p, err := ParseSnowflakeString(str); err != nil || p.WorkerID != 42 {
+		t.Fatalf("ParseSnowflakeString: %v", err)
+	}
+	if _, err := NewSnowflake(SnowflakeMaxWorkerID + 1); err == nil {
+		t.Fatal("worker id out of range was accepted")
+	}
+	if _, err := NewSnowflake(1, time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("future epoch was accepted")
+	}
+	if _, err := ParseSnowflake(-1); err == nil {
+		t.Fatal("negative id was accepted")
+	}
+}
+
+func TestSnowflakeClock(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, _ := NewSnowflake(1, epoch)
+	now := epoch.Add(time.Hour)
+	s.now = func() time.Time { return now }
+
+	// 同一毫秒内序列号递增
+	a, _ := s.Next()
+	b, _ := s.Next()
+	pa, _ := s.Parse(a)
+	pb, _ := s.Parse(b)
+	if !pa.Time.Equal(now) || pb.Sequence != pa.Sequence+1 {
+		t.Fatalf("unexpected ids %+v %+v", pa, pb)
+	}
+	// 小幅回拨等待追平
+	var calls int
+	s.now = func() time.Time {
+		calls++
+		if calls == 1 {
+			return now.Add(-5 * time.Millisecond)
+		}
+		return now.Add(time.Millisecond)
+	}
+	c, err := s.Next()
+	if err != nil || c <= b {
+		t.Fatalf("small clock drift: %d %v", c, err)
+	}
+	// 大幅回拨返回error
+	s.now = func() time.Time { return now.Add(-time.Second) }
+	if _, err := s.Next(); err == nil {
+		t.Fatal("large clock drift was accepted")
+	}
+}
+
+func TestSnowflakeConcurrent(t *testing.T) {
+	s, _ := NewSnowflake(7)
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				id, err := s.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("duplicate id %d", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
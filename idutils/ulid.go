@@ -0,0 +1,174 @@
+package idutils
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+//ULID 48位毫秒时间戳 + 80位随机数，使用Crockford Base32编码为26位字符串，字典序即时间序
+type ULID [16]byte
+
+const (
+	ulidLen      = 26
+	ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	ulidMaxTime  = 1<<48 - 1
+)
+
+var errInvalidULID = errors.New("invalid ulid format")
+
+var ulidIndex = func() [256]int8 {
+	var index [256]int8
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(ulidAlphabet); i++ {
+		index[ulidAlphabet[i]] = int8(i)
+		index[strings.ToLower(ulidAlphabet[i : i+1])[0]] = int8(i)
+	}
+	return index
+}()
+
+var ulidMonotonic struct {
+	sync.Mutex
+	lastMs int64
+	last   ULID
+}
+
+//NewULID 生成ULID，同一毫秒内随机部分递增，保证同一进程内生成的ULID严格递增
+func NewULID() (ULID, error) {
+	ulidMonotonic.Lock()
+	defer ulidMonotonic.Unlock()
+
+	ms := time.Now().UnixNano() / int64(time.Millisecond)
+	if ms <= ulidMonotonic.lastMs {
+		// 随机部分加1，溢出时报错（同一毫秒内生成超过2^80个）
+		u := ulidMonotonic.last
+		for i := 15; i >= 6; i-- {
+			u[i]++
+			if u[i] != 0 {
+				ulidMonotonic.last = u
+				return u, nil
+			}
+		}
+		return ULID{}, errors.New("ulid random part overflow")
+	}
+	u, err := NewULIDWithTime(time.Unix(0, ms*int64(time.Millisecond)))
+	if err != nil {
+		return u, err
+	}
+	ulidMonotonic.lastMs = ms
+	ulidMonotonic.last = u
+	return u, nil
+}
+
+//NewULIDWithTime 使用指定时间生成ULID，不保证同一毫秒内递增
+func NewULIDWithTime(t time.Time) (ULID, error) {
+	var u ULID
+	ms := t.UnixNano() / int64(time.Millisecond)
+	if ms < 0 || ms > ulidMaxTime {
+		return u, errors.New("ulid time out of range")
+	}
+	b, err := RandomBytes(10)
+	if err != nil {
+		return u, err
+	}
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	copy(u[6:], b)
+	return u, nil
+}
+
+//ULIDString 生成ULID字符串，随机源不可用时panic
+func ULIDString() string {
+	u, err := NewULID()
+	if err != nil {
+		panic(err)
+	}
+	return u.String()
+}
+
+//ParseULID 解析26位ULID字符串，不区分大小写
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != ulidLen {
+		return u, errInvalidULID
+	}
+	// 26*5=130位，首字符只能表示高3位，超过7即溢出
+	if v := ulidIndex[s[0]]; v < 0 || v > 7 {
+		return u, errInvalidULID
+	}
+	var acc uint32
+	var nbits uint
+	pos := 0
+	for i := 0; i < ulidLen; i++ {
+		v := ulidIndex[s[i]]
+		if v < 0 {
+			return u, errInvalidULID
+		}
+		acc = acc<<5 | uint32(v)
+		nbits += 5
+		if i == 0 {
+			// 丢弃首字符的2位填充
+			nbits -= 2
+			acc &= 0x7
+		}
+		for nbits >= 8 {
+			nbits -= 8
+			u[pos] = byte(acc >> nbits)
+			pos++
+		}
+		acc &= 1<<nbits - 1
+	}
+	return u, nil
+}
+
+//IsULID 判断s是否为合法的ULID
+func IsULID(s string) bool {
+	_, err := ParseULID(s)
+	return err == nil
+}
+
+//String 26位Crockford Base32字符串
+func (u ULID) String() string {
+	var dst [ulidLen]byte
+	// 128位前补2个0位凑成130位，每5位一个字符
+	var acc uint32
+	nbits := uint(2)
+	pos := 0
+	for _, b := range u {
+		acc = acc<<8 | uint32(b)
+		nbits += 8
+		for nbits >= 5 {
+			nbits -= 5
+			dst[pos] = ulidAlphabet[acc>>nbits&0x1f]
+			pos++
+		}
+		acc &= 1<<nbits - 1
+	}
+	return string(dst[:])
+}
+
+//Time 生成时间
+func (u ULID) Time() time.Time {
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.Unix(ms/1000, ms%1000*int64(time.Millisecond))
+}
+
+func (u ULID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *ULID) UnmarshalText(data []byte) error {
+	id, err := ParseULID(string(data))
+	if err != nil {
+		return err
+	}
+	*u = id
+	return nil
+}
@@ -0,0 +1,201 @@
+package idutils
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+//UUID RFC 9562 UUID
+type UUID [16]byte
+
+//NilUUID 全0 UUID
+var NilUUID UUID
+
+var errInvalidUUID = errors.New("invalid uuid format")
+
+//NewUUIDv4 生成随机UUID(v4)
+func NewUUIDv4() (UUID, error) {
+	var u UUID
+	b, err := RandomBytes(16)
+	if err != nil {
+		return u, err
+	}
+	copy(u[:], b)
+	u.setVersion(4)
+	return u, nil
+}
+
+var uuidV7 struct {
+	sync.Mutex
+	lastMs  int64
+	counter uint16
+}
+
+//NewUUIDv7 生成按时间排序的UUID(v7)：48位毫秒时间戳 + 12位计数器 + 62位随机数
+//同一毫秒内计数器递增，保证同一进程内生成的UUID严格递增
+func NewUUIDv7() (UUID, error) {
+	var u UUID
+	b, err := RandomBytes(16)
+	if err != nil {
+		return u, err
+	}
+	copy(u[:], b)
+
+	uuidV7.Lock()
+	ms := time.Now().UnixNano() / int64(time.Millisecond)
+	if ms > uuidV7.lastMs {
+		uuidV7.lastMs = ms
+		// 新的毫秒使用随机起始值，保留一半空间用于递增
+		uuidV7.counter = binary.BigEndian.Uint16(b[6:8]) & 0x7ff
+	} else {
+		uuidV7.counter++
+		if uuidV7.counter > 0xfff {
+			// 计数器溢出时借用下一毫秒
+			uuidV7.lastMs++
+			uuidV7.counter = 0
+		}
+		ms = uuidV7.lastMs
+	}
+	counter := uuidV7.counter
+	uuidV7.Unlock()
+
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	binary.BigEndian.PutUint16(u[6:8], counter)
+	u.setVersion(7)
+	return u, nil
+}
+
+//UUIDv4 生成随机UUID(v4)字符串，随机源不可用时panic
+func UUIDv4() string {
+	u, err := NewUUIDv4()
+	if err != nil {
+		panic(err)
+	}
+	return u.String()
+}
+
+//UUIDv7 生成按时间排序的UUID(v7)字符串，随机源不可用时panic
+func UUIDv7() string {
+	u, err := NewUUIDv7()
+	if err != nil {
+		panic(err)
+	}
+	return u.String()
+}
+
+//ParseUUID 解析UUID，支持以下格式：
+//xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx、{xxxxxxxx-...}、urn:uuid:xxxxxxxx-...、32位十六进制
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	switch {
+	case len(s) == 45 && strings.EqualFold(s[:9], "urn:uuid:"):
+		s = s[9:]
+	case len(s) == 38 && s[0] == '{' && s[37] == '}':
+		s = s[1:37]
+	}
+	switch len(s) {
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, errInvalidUUID
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	case 32:
+	default:
+		return u, errInvalidUUID
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, errInvalidUUID
+	}
+	return u, nil
+}
+
+//IsUUID 判断s是否为合法的RFC 9562 UUID（任意版本），versions为非必需参数，指定时同时校验版本
+func IsUUID(s string, versions ...int) bool {
+	u, err := ParseUUID(s)
+	if err != nil || u.Variant() != 2 {
+		return false
+	}
+	if len(versions) == 0 {
+		return true
+	}
+	for _, v := range versions {
+		if u.Version() == v {
+			return true
+		}
+	}
+	return false
+}
+
+//String 标准格式 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+//Version 版本号
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+//Variant 变体，RFC 9562 UUID为2
+func (u UUID) Variant() int {
+	switch {
+	case u[8]&0x80 == 0:
+		return 0
+	case u[8]&0xc0 == 0x80:
+		return 2
+	case u[8]&0xe0 == 0xc0:
+		return 6
+	}
+	return 7
+}
+
+//Time v7 UUID的生成时间，其他版本返回false
+func (u UUID) Time() (time.Time, bool) {
+	if u.Version() != 7 {
+		return time.Time{}, false
+	}
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.Unix(ms/1000, ms%1000*int64(time.Millisecond)), true
+}
+
+//IsNil 是否为全0 UUID
+func (u UUID) IsNil() bool {
+	return u == NilUUID
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(data []byte) error {
+	id, err := ParseUUID(string(data))
+	if err != nil {
+		return err
+	}
+	*u = id
+	return nil
+}
+
+func (u *UUID) setVersion(version byte) {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+}
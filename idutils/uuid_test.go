@@ -0,0 +1,133 @@
+package idutils
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseUUID(t *testing.T) {
+	// RFC 9562 附录A.6 v7示例
+	const s = "017f22e2-79b0-7cc3-98c4-dc0c0c07398f"
+	for _, v := range []string{s, strings.ToUpper(s), "{" + s + "}", "urn:uuid:" + s, strings.ReplaceAll(s, "-", "")} {
+		u, err := ParseUUID(v)
+		if err != nil {
+			t.Fatalf("%s: %v", v, err)
+		}
+		if u.String() != s {
+			t.Fatalf("%s: parsed as %s", v, u)
+		}
+	}
+	u, _ := ParseUUID(s)
+	if u.Version() != 7 || u.Variant() != 2 {
+		t.Fatalf("unexpected version %d variant %d", u.Version(), u.Variant())
+	}
+	if ts, ok := u.Time(); !ok || ts.UnixNano()/int64(time.Millisecond) != 0x017f22e279b0 {
+		t.Fatalf("unexpected time %v", ts)
+	}
+	for _, v := range []string{"", s[:35], s + "0", strings.Replace(s, "-", "_", 1), strings.Replace(s, "f", "g", 1)} {
+		if _, err := ParseUUID(v); err == nil {
+			t.Fatalf("%q was accepted", v)
+		}
+	}
+	if !IsUUID(s, 4, 7) || IsUUID(s, 4) || IsUUID(NilUUID.String()) || !NilUUID.IsNil() {
+		t.Fatal("IsUUID mismatch")
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	v4, err := NewUUIDv4()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v4.Version() != 4 || v4.Variant() != 2 || !IsUUID(UUIDv4(), 4) {
+		t.Fatalf("invalid v4 %s", v4)
+	}
+	if _, ok := v4.Time(); ok {
+		t.Fatal("v4 has no timestamp")
+	}
+
+	before := time.Now().Add(-time.Millisecond)
+	ids := make([]string, 5000)
+	for i := range ids {
+		u, err := NewUUIDv7()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = u.String()
+	}
+	// 同一进程内生成的v7严格递增
+	if !sort.StringsAreSorted(ids) {
+		t.Fatal("v7 uuids are not monotonic")
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] == ids[i-1] {
+			t.Fatalf("duplicate v7 uuid %s", ids[i])
+		}
+	}
+	u, _ := ParseUUID(ids[0])
+	if ts, ok := u.Time(); !ok || ts.Before(before) || ts.After(time.Now()) {
+		t.Fatalf("unexpected v7 time %v", ts)
+	}
+	if u.Version() != 7 || u.Variant() != 2 {
+		t.Fatalf("invalid v7 %s", u)
+	}
+}
+
+func TestUUIDJSON(t *testing.T) {
+	u, _ := NewUUIDv4()
+	data, err := json.Marshal(map[string]UUID{"id": u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]UUID
+	if err := json.Unmarshal(data, &m); err != nil || m["id"] != u {
+		t.Fatalf("json round trip: %s %v", data, err)
+	}
+	if err := json.Unmarshal([]byte(`{"id":"x"}`), &m); err == nil {
+		t.Fatal("invalid uuid was accepted")
+	}
+}
+
+func TestULID(t *testing.T) {
+	// ULID规范示例，时间部分01ARYZ6S41对应1469918176385毫秒
+	u, err := ParseULID("01ARYZ6S41TSV4RRFFQ69G5FAV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Time().UnixNano()/int64(time.Millisecond) != 1469918176385 {
+		t.Fatalf("unexpected time %v", u.Time())
+	}
+	if u.String() != "01ARYZ6S41TSV4RRFFQ69G5FAV" {
+		t.Fatalf("round trip mismatch %s", u)
+	}
+	if lower, _ := ParseULID("01aryz6s41tsv4rrffq69g5fav"); lower != u {
+		t.Fatal("lower case ulid mismatch")
+	}
+	if !IsULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ") || IsULID("80000000000000000000000000") {
+		t.Fatal("ulid overflow check mismatch")
+	}
+	for _, s := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		if IsULID(s) {
+			t.Fatalf("%q was accepted", s)
+		}
+	}
+
+	ts := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	fixed, err := NewULIDWithTime(ts)
+	if err != nil || !fixed.Time().Equal(ts) {
+		t.Fatalf("NewULIDWithTime: %v", err)
+	}
+	if _, err := NewULIDWithTime(time.Unix(-1, 0)); err == nil {
+		t.Fatal("negative time was accepted")
+	}
+	ids := make([]string, 5000)
+	for i := range ids {
+		ids[i] = ULIDString()
+		if i > 0 && ids[i] <= ids[i-1] {
+			t.Fatalf("ulids are not monotonic: %s <= %s", ids[i], ids[i-1])
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package jwtutils
+// Description: JWT(JWS)签发与校验、JWK/JWKS密钥管理以及JWE加密
+//
+package jwtutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/youngchan1988/gocommon/securityutils"
+)
+
+//签名算法
+const (
+	HS256 = "HS256"
+	HS384 = "HS384"
+	HS512 = "HS512"
+	RS256 = "RS256"
+	RS512 = "RS512"
+	PS256 = "PS256"
+	PS512 = "PS512"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// 计算签名，key类型需与算法匹配：
+// HS* 为[]byte或string，RS*/PS* 为*rsa.PrivateKey，ES256 为*ecdsa.PrivateKey，EdDSA 为ed25519.PrivateKey
+func sign(alg string, signingInput []byte, key interface{}) ([]byte, error) {
+	switch alg {
+	case HS256, HS384, HS512:
+		secret, err := hmacKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return hmacSign(alg, signingInput, secret), nil
+	case RS256, RS512, PS256, PS512:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKeyType
+		}
+		return securityutils.RsaSignByte(signingInput, rsaKey, rsaAlgorithm(alg))
+	case ES256:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve.Params().BitSize != 256 {
+			return nil, ErrInvalidKeyType
+		}
+		digest := sha256.Sum256(signingInput)
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS使用定长的 r|s 格式，而非ASN.1
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	case EdDSA:
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKeyType
+		}
+		return securityutils.Ed25519SignByte(signingInput, edKey)
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// 校验签名，key类型需与算法匹配：
+// HS* 为[]byte或string，RS*/PS* 为*rsa.PublicKey，ES256 为*ecdsa.PublicKey，EdDSA 为ed25519.PublicKey
+// 传入私钥时自动取其公钥
+func verify(alg string, signingInput []byte, sig []byte, key interface{}) error {
+	switch alg {
+	case HS256, HS384, HS512:
+		secret, err := hmacKey(key)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(sig, hmacSign(alg, signingInput, secret)) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case RS256, RS512, PS256, PS512:
+		var rsaKey *rsa.PublicKey
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			rsaKey = k
+		case *rsa.PrivateKey:
+			rsaKey = &k.PublicKey
+		default:
+			return ErrInvalidKeyType
+		}
+		if securityutils.RsaVerifyByte(signingInput, sig, rsaKey, rsaAlgorithm(alg)) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case ES256:
+		var ecKey *ecdsa.PublicKey
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			ecKey = k
+		case *ecdsa.PrivateKey:
+			ecKey = &k.PublicKey
+		default:
+			return ErrInvalidKeyType
+		}
+		if ecKey.Curve.Params().BitSize != 256 {
+			return ErrInvalidKeyType
+		}
+		if len(sig) != 64 {
+			return ErrInvalidSignature
+		}
+		digest := sha256.Sum256(signingInput)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case EdDSA:
+		var edKey ed25519.PublicKey
+		switch k := key.(type) {
+		case ed25519.PublicKey:
+			edKey = k
+		case ed25519.PrivateKey:
+			edKey = k.Public().(ed25519.PublicKey)
+		default:
+			return ErrInvalidKeyType
+		}
+		if securityutils.Ed25519VerifyByte(signingInput, sig, edKey) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return ErrUnsupportedAlgorithm
+}
+
+func hmacKey(key interface{}) ([]byte, error) {
+	var secret []byte
+	switch k := key.(type) {
+	case []byte:
+		secret = k
+	case string:
+		secret = []byte(k)
+	default:
+		return nil, ErrInvalidKeyType
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("hmac key can not be empty")
+	}
+	return secret, nil
+}
+
+func hmacSign(alg string, src []byte, key []byte) []byte {
+	switch alg {
+	case HS384:
+		return securityutils.HmacSha384Byte(src, key)
+	case HS512:
+		return securityutils.HmacSha512Byte(src, key)
+	}
+	return securityutils.HmacSha256Byte(src, key)
+}
+
+func rsaAlgorithm(alg string) securityutils.RsaSignAlgorithm {
+	switch alg {
+	case RS512:
+		return securityutils.RsaPKCS1v15Sha512
+	case PS256:
+		return securityutils.RsaPSSSha256
+	case PS512:
+		return securityutils.RsaPSSSha512
+	}
+	return securityutils.RsaPKCS1v15Sha256
+}
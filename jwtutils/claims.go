@@ -0,0 +1,130 @@
+package jwtutils
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/youngchan1988/gocommon/cast"
+)
+
+//Claims JWT声明
+type Claims map[string]interface{}
+
+//NewClaims 创建包含常用注册声明的Claims，ttl为有效期，<=0时不设置exp
+func NewClaims(issuer string, subject string, ttl time.Duration, audience ...string) Claims {
+	now := time.Now()
+	c := Claims{"iat": now.Unix()}
+	if issuer != "" {
+		c["iss"] = issuer
+	}
+	if subject != "" {
+		c["sub"] = subject
+	}
+	if ttl > 0 {
+		c["exp"] = now.Add(ttl).Unix()
+	}
+	if len(audience) == 1 {
+		c["aud"] = audience[0]
+	} else if len(audience) > 1 {
+		c["aud"] = audience
+	}
+	return c
+}
+
+//Issuer iss
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+//Subject sub
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+//ID jti
+func (c Claims) ID() string {
+	s, _ := c["jti"].(string)
+	return s
+}
+
+//Audience aud，兼容字符串与字符串数组两种格式
+func (c Claims) Audience() []string {
+	switch v := c["aud"].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		aud := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+		return aud
+	}
+	return nil
+}
+
+//HasAudience aud是否包含audience
+func (c Claims) HasAudience(audience string) bool {
+	for _, a := range c.Audience() {
+		if a == audience {
+			return true
+		}
+	}
+	return false
+}
+
+//ExpiresAt exp
+func (c Claims) ExpiresAt() (time.Time, bool) {
+	return c.Time("exp")
+}
+
+//NotBefore nbf
+func (c Claims) NotBefore() (time.Time, bool) {
+	return c.Time("nbf")
+}
+
+//IssuedAt iat
+func (c Claims) IssuedAt() (time.Time, bool) {
+	return c.Time("iat")
+}
+
+//Time 读取NumericDate类型的声明（秒级时间戳，允许小数）
+//声明不存在或不是数值时返回false
+func (c Claims) Time(name string) (time.Time, bool) {
+	v, ok := c[name]
+	if !ok || v == nil {
+		return time.Time{}, false
+	}
+	var f float64
+	switch n := v.(type) {
+	case json.Number:
+		var err error
+		if f, err = n.Float64(); err != nil {
+			return time.Time{}, false
+		}
+	case string, bool:
+		return time.Time{}, false
+	default:
+		var err error
+		if f, err = cast.InterfaceToFloat64(v); err != nil {
+			return time.Time{}, false
+		}
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+//Set 设置声明，time.Time会转换为NumericDate
+func (c Claims) Set(name string, value interface{}) Claims {
+	if t, ok := value.(time.Time); ok {
+		value = t.Unix()
+	}
+	c[name] = value
+	return c
+}
@@ -0,0 +1,313 @@
+package jwtutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/youngchan1988/gocommon/securityutils"
+)
+
+//JWE密钥管理算法
+const (
+	Dir        = "dir"          //直接使用共享密钥作为内容加密密钥
+	RSAOAEP256 = "RSA-OAEP-256" //RSA-OAEP(SHA-256)加密内容密钥
+	A128KW     = "A128KW"       //AES-128密钥包裹
+	A256KW     = "A256KW"       //AES-256密钥包裹
+)
+
+//JWE内容加密算法
+const (
+	A128GCM = "A128GCM"
+	A256GCM = "A256GCM"
+)
+
+//JWEHeader JWE头部
+type JWEHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+	Cty string `json:"cty,omitempty"`
+	//Crit 必须被接收方理解的扩展头部，Decrypt不支持任何扩展，出现时拒绝
+	Crit []string `json:"crit,omitempty"`
+}
+
+var ErrDecryption = errors.New("jwe: decryption failed")
+
+//Encrypt 生成JWE紧凑序列化
+//key：dir 与 A*KW 为[]byte，RSA-OAEP-256 为*rsa.PublicKey
+//嵌套JWT时先Sign再Encrypt，并将cty设置为"JWT"
+func Encrypt(plaintext []byte, alg string, enc string, key interface{}, kid ...string) (string, error) {
+	header := JWEHeader{Alg: alg, Enc: enc}
+	if len(kid) > 0 {
+		header.Kid = kid[0]
+	}
+	return EncryptWithHeader(header, plaintext, key)
+}
+
+//EncryptJWT 将已签名的JWT加密为嵌套JWT
+func EncryptJWT(token string, alg string, enc string, key interface{}, kid ...string) (string, error) {
+	header := JWEHeader{Alg: alg, Enc: enc, Cty: "JWT"}
+	if len(kid) > 0 {
+		header.Kid = kid[0]
+	}
+	return EncryptWithHeader(header, []byte(token), key)
+}
+
+//EncryptWithHeader 使用自定义头部生成JWE
+func EncryptWithHeader(header JWEHeader, plaintext []byte, key interface{}) (string, error) {
+	cekSize, err := jweKeySize(header.Enc)
+	if err != nil {
+		return "", err
+	}
+	var cek, encryptedKey []byte
+	switch header.Alg {
+	case Dir:
+		k, ok := key.([]byte)
+		if !ok {
+			return "", ErrInvalidKeyType
+		}
+		if len(k) != cekSize {
+			return "", errors.New("jwe: key size does not match enc")
+		}
+		cek = k
+	case RSAOAEP256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return "", ErrInvalidKeyType
+		}
+		if cek, err = securityutils.RandomBytes(cekSize); err != nil {
+			return "", err
+		}
+		if encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, cek, nil); err != nil {
+			return "", err
+		}
+	case A128KW, A256KW:
+		kek, ok := key.([]byte)
+		if !ok {
+			return "", ErrInvalidKeyType
+		}
+		if len(kek) != kwKeySize(header.Alg) {
+			return "", errors.New("jwe: key size does not match alg")
+		}
+		if cek, err = securityutils.RandomBytes(cekSize); err != nil {
+			return "", err
+		}
+		if encryptedKey, err = aesKeyWrap(kek, cek); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := b64Encode(headerJSON)
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv, err := securityutils.RandomBytes(gcm.NonceSize())
+	if err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(protected))
+	tagPos := len(sealed) - gcm.Overhead()
+	return strings.Join([]string{
+		protected,
+		b64Encode(encryptedKey),
+		b64Encode(iv),
+		b64Encode(sealed[:tagPos]),
+		b64Encode(sealed[tagPos:]),
+	}, "."), nil
+}
+
+//Decrypt 解密JWE紧凑序列化，返回头部与明文
+//key：dir 与 A*KW 为[]byte，RSA-OAEP-256 为*rsa.PrivateKey
+//头部包含crit扩展时返回ErrUnsupportedCritical
+func Decrypt(token string, key interface{}) (*JWEHeader, []byte, error) {
+	return decrypt(token, key, nil)
+}
+
+func decrypt(token string, key interface{}, crit []string) (*JWEHeader, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, ErrMalformed
+	}
+	header := new(JWEHeader)
+	headerJSON, err := b64Decode(parts[0])
+	if err != nil {
+		return nil, nil, ErrMalformed
+	}
+	if err := json.Unmarshal(headerJSON, header); err != nil {
+		return nil, nil, ErrMalformed
+	}
+	if err := checkCrit(headerJSON, header.Crit, crit); err != nil {
+		return nil, nil, err
+	}
+	cekSize, err := jweKeySize(header.Enc)
+	if err != nil {
+		return nil, nil, err
+	}
+	var decoded [4][]byte
+	for i := range decoded {
+		if decoded[i], err = b64Decode(parts[i+1]); err != nil {
+			return nil, nil, ErrMalformed
+		}
+	}
+	encryptedKey, iv, ciphertext, tag := decoded[0], decoded[1], decoded[2], decoded[3]
+
+	var cek []byte
+	switch header.Alg {
+	case Dir:
+		k, ok := key.([]byte)
+		if !ok {
+			return nil, nil, ErrInvalidKeyType
+		}
+		if len(encryptedKey) != 0 {
+			return nil, nil, ErrMalformed
+		}
+		cek = k
+	case RSAOAEP256:
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, ErrInvalidKeyType
+		}
+		if cek, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, encryptedKey, nil); err != nil {
+			return nil, nil, ErrDecryption
+		}
+	case A128KW, A256KW:
+		kek, ok := key.([]byte)
+		if !ok {
+			return nil, nil, ErrInvalidKeyType
+		}
+		if len(kek) != kwKeySize(header.Alg) {
+			return nil, nil, errors.New("jwe: key size does not match alg")
+		}
+		if cek, err = aesKeyUnwrap(kek, encryptedKey); err != nil {
+			return nil, nil, ErrDecryption
+		}
+	default:
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	if len(cek) != cekSize {
+		return nil, nil, ErrDecryption
+	}
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(iv) != gcm.NonceSize() || len(tag) != gcm.Overhead() {
+		return nil, nil, ErrMalformed
+	}
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, nil, ErrDecryption
+	}
+	return header, plaintext, nil
+}
+
+//ParseEncrypted 解密嵌套JWT并校验内部签名及声明，JWE头部的crit同样按p.Crit校验
+func (p *Parser) ParseEncrypted(token string, decryptKey interface{}, verifyKey interface{}) (*Token, error) {
+	_, plaintext, err := decrypt(token, decryptKey, p.Crit)
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse(string(plaintext), verifyKey)
+}
+
+func jweKeySize(enc string) (int, error) {
+	switch enc {
+	case A128GCM:
+		return 16, nil
+	case A256GCM:
+		return 32, nil
+	}
+	return 0, errors.New("jwe: unsupported enc " + enc)
+}
+
+// A128KW、A256KW的密钥长度
+func kwKeySize(alg string) int {
+	if alg == A128KW {
+		return 16
+	}
+	return 32
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ------------------- AES Key Wrap (RFC 3394) ----------------------
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+func aesKeyWrap(kek []byte, cek []byte) ([]byte, error) {
+	if len(cek)%8 != 0 || len(cek) < 16 {
+		return nil, errors.New("jwe: key to wrap must be a multiple of 8 bytes")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(cek) / 8
+	r := make([]byte, len(cek))
+	copy(r, cek)
+	a := make([]byte, 8)
+	copy(a, keyWrapIV)
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(buf[:8], a)
+			copy(buf[8:], r[i*8:i*8+8])
+			block.Encrypt(buf, buf)
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf[:8])^t)
+			copy(r[i*8:], buf[8:])
+		}
+	}
+	return append(a, r...), nil
+}
+
+func aesKeyUnwrap(kek []byte, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, errors.New("jwe: wrapped key size error")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	a := make([]byte, 8)
+	copy(a, wrapped[:8])
+	r := make([]byte, n*8)
+	copy(r, wrapped[8:])
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(a)^t)
+			copy(buf[8:], r[i*8:i*8+8])
+			block.Decrypt(buf, buf)
+			copy(a, buf[:8])
+			copy(r[i*8:], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, errors.New("jwe: key unwrap integrity check failed")
+	}
+	return r, nil
+}
@@ -0,0 +1,166 @@
+package jwtutils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAesKeyWrap(t *testing.T) {
+	// RFC 3394 §4.1、§4.6
+	cases := []struct{ kek, key, wrapped string }{
+		{"000102030405060708090A0B0C0D0E0F", "00112233445566778899AABBCCDDEEFF", "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"},
+		{"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F", "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+			"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"},
+	}
+	for _, c := range cases {
+		kek, _ := hex.DecodeString(c.kek)
+		key, _ := hex.DecodeString(c.key)
+		wrapped, err := aesKeyWrap(kek, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(wrapped), c.wrapped) {
+			t.Fatalf("wrap mismatch: %X", wrapped)
+		}
+		unwrapped, err := aesKeyUnwrap(kek, wrapped)
+		if err != nil || !bytes.Equal(unwrapped, key) {
+			t.Fatalf("unwrap failed: %v", err)
+		}
+		wrapped[0] ^= 1
+		if _, err := aesKeyUnwrap(kek, wrapped); err == nil {
+			t.Fatal("tampered wrapped key was accepted")
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	k16 := []byte("0123456789abcdef")
+	k32 := []byte("0123456789abcdef0123456789abcdef")
+	cases := []struct {
+		alg, enc   string
+		encKey     interface{}
+		decKey     interface{}
+		wrongKey   interface{}
+		encKeySize int
+	}{
+		{Dir, A128GCM, k16, k16, []byte("fedcba9876543210"), 0},
+		{Dir, A256GCM, k32, k32, k16, 0},
+		{A128KW, A128GCM, k16, k16, []byte("fedcba9876543210"), 24},
+		{A256KW, A256GCM, k32, k32, []byte("fedcba9876543210fedcba9876543210"), 40},
+		{RSAOAEP256, A256GCM, &rsaKey.PublicKey, rsaKey, k32, 256},
+	}
+	for _, c := range cases {
+		token, err := Encrypt([]byte("hello jwe"), c.alg, c.enc, c.encKey, "k1")
+		if err != nil {
+			t.Fatalf("%s/%s: %v", c.alg, c.enc, err)
+		}
+		parts := strings.Split(token, ".")
+		if ek, _ := b64Decode(parts[1]); len(ek) != c.encKeySize {
+			t.Fatalf("%s/%s: encrypted key size %d", c.alg, c.enc, len(ek))
+		}
+		header, plain, err := Decrypt(token, c.decKey)
+		if err != nil || string(plain) != "hello jwe" {
+			t.Fatalf("%s/%s: %v", c.alg, c.enc, err)
+		}
+		if header.Alg != c.alg || header.Enc != c.enc || header.Kid != "k1" {
+			t.Fatalf("%s/%s: unexpected header %+v", c.alg, c.enc, header)
+		}
+		if _, _, err := Decrypt(token, c.wrongKey); err == nil {
+			t.Fatalf("%s/%s: wrong key was accepted", c.alg, c.enc)
+		}
+		// 篡改头部、iv、密文或tag都必须失败
+		for i := 0; i < 5; i++ {
+			if i == 1 {
+				continue
+			}
+			raw, _ := b64Decode(parts[i])
+			if len(raw) == 0 {
+				continue
+			}
+			raw[len(raw)-1] ^= 1
+			if _, _, err := Decrypt(replacePart(token, i, b64Encode(raw)), c.decKey); err == nil {
+				t.Fatalf("%s/%s: tampered part %d was accepted", c.alg, c.enc, i)
+			}
+		}
+	}
+}
+
+func TestDecryptKeySize(t *testing.T) {
+	k16 := []byte("0123456789abcdef")
+	token, err := Encrypt([]byte("x"), A128KW, A256GCM, k16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 头部为A128KW，但传入32位KEK
+	if _, _, err := Decrypt(token, append(k16, k16...)); err == nil || err == ErrDecryption {
+		t.Fatalf("KEK size mismatch should be reported, got %v", err)
+	}
+	if _, err := Encrypt([]byte("x"), A256KW, A256GCM, k16); err == nil {
+		t.Fatal("16 byte KEK for A256KW was accepted")
+	}
+	if _, err := Encrypt([]byte("x"), Dir, A256GCM, k16); err == nil {
+		t.Fatal("16 byte key for A256GCM was accepted")
+	}
+	if _, err := Encrypt([]byte("x"), "PBES2-HS256+A128KW", A128GCM, k16); err != ErrUnsupportedAlgorithm {
+		t.Fatalf("expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+}
+
+func TestDecryptCrit(t *testing.T) {
+	k16 := []byte("0123456789abcdef")
+	token, err := EncryptWithHeader(JWEHeader{Alg: Dir, Enc: A128GCM, Crit: []string{"exp"}}, []byte("x"), k16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// crit中的扩展不在头部中
+	if _, _, err := Decrypt(token, k16); err != ErrMalformed {
+		t.Fatalf("expected ErrMalformed, got %v", err)
+	}
+	header, _ := json.Marshal(map[string]interface{}{"alg": Dir, "enc": A128GCM, "crit": []string{"exp"}, "exp": 1})
+	parts := strings.Split(token, ".")
+	parts[0] = b64Encode(header)
+	gcm, _ := newGCM(k16)
+	iv := make([]byte, gcm.NonceSize())
+	sealed := gcm.Seal(nil, iv, []byte("x"), []byte(parts[0]))
+	parts[2], parts[3], parts[4] = b64Encode(iv), b64Encode(sealed[:1]), b64Encode(sealed[1:])
+	token = strings.Join(parts, ".")
+	if _, _, err := Decrypt(token, k16); err != ErrUnsupportedCritical {
+		t.Fatalf("expected ErrUnsupportedCritical, got %v", err)
+	}
+	p := NewParser(HS256)
+	p.Crit = []string{"exp"}
+	if _, _, err := decrypt(token, k16, p.Crit); err != nil {
+		t.Fatalf("understood critical extension was rejected: %v", err)
+	}
+}
+
+func TestNestedJWT(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	secret := []byte("signing secret")
+	signed, err := Sign(NewClaims("iss", "user", time.Minute), HS256, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := EncryptJWT(signed, RSAOAEP256, A128GCM, &rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := Decrypt(token, rsaKey)
+	if err != nil || header.Cty != "JWT" {
+		t.Fatalf("unexpected header %+v: %v", header, err)
+	}
+	p := NewParser(HS256)
+	p.Issuer = "iss"
+	tok, err := p.ParseEncrypted(token, rsaKey, secret)
+	if err != nil || tok.Claims.Subject() != "user" {
+		t.Fatalf("ParseEncrypted: %v", err)
+	}
+	if _, err := p.ParseEncrypted(token, rsaKey, []byte("other")); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
@@ -0,0 +1,485 @@
+package jwtutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/youngchan1988/gocommon/syncutils"
+)
+
+//JWK JSON Web Key (RFC 7517)，支持RSA、EC(P-256)、OKP(Ed25519)与oct
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+
+	// RSA
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	Dp string `json:"dp,omitempty"`
+	Dq string `json:"dq,omitempty"`
+	Qi string `json:"qi,omitempty"`
+
+	// EC / OKP
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+
+	// 私钥（RSA、EC、OKP共用）
+	D string `json:"d,omitempty"`
+
+	// oct
+	K string `json:"k,omitempty"`
+}
+
+//JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+//ParseJWK 解析JWK
+func ParseJWK(data []byte) (*JWK, error) {
+	k := new(JWK)
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, err
+	}
+	if k.Kty == "" {
+		return nil, errors.New("jwk: kty can not be empty")
+	}
+	return k, nil
+}
+
+//ParseJWKS 解析JWKS
+func ParseJWKS(data []byte) (*JWKS, error) {
+	s := new(JWKS)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//Get 按kid查找密钥，不存在返回nil
+func (s *JWKS) Get(kid string) *JWK {
+	for _, k := range s.Keys {
+		if k.Kid == kid {
+			return k
+		}
+	}
+	return nil
+}
+
+//NewJWK 由密钥创建JWK
+//key 支持*rsa.PrivateKey、*rsa.PublicKey、*ecdsa.PrivateKey、*ecdsa.PublicKey、ed25519.PrivateKey、ed25519.PublicKey、[]byte
+//alg 为非必需参数，不传时按密钥类型推断
+func NewJWK(key interface{}, kid string, alg ...string) (*JWK, error) {
+	k := &JWK{Kid: kid}
+	switch v := key.(type) {
+	case *rsa.PrivateKey:
+		k.setRSAPublic(&v.PublicKey)
+		v.Precompute()
+		k.D = b64BigInt(v.D)
+		k.P = b64BigInt(v.Primes[0])
+		k.Q = b64BigInt(v.Primes[1])
+		k.Dp = b64BigInt(v.Precomputed.Dp)
+		k.Dq = b64BigInt(v.Precomputed.Dq)
+		k.Qi = b64BigInt(v.Precomputed.Qinv)
+	case *rsa.PublicKey:
+		k.setRSAPublic(v)
+	case *ecdsa.PrivateKey:
+		if err := k.setECPublic(&v.PublicKey); err != nil {
+			return nil, err
+		}
+		k.D = b64Encode(v.D.FillBytes(make([]byte, 32)))
+	case *ecdsa.PublicKey:
+		if err := k.setECPublic(v); err != nil {
+			return nil, err
+		}
+	case ed25519.PrivateKey:
+		k.Kty, k.Crv = "OKP", "Ed25519"
+		k.X = b64Encode(v.Public().(ed25519.PublicKey))
+		k.D = b64Encode(v.Seed())
+	case ed25519.PublicKey:
+		k.Kty, k.Crv = "OKP", "Ed25519"
+		k.X = b64Encode(v)
+	case []byte:
+		k.Kty = "oct"
+		k.K = b64Encode(v)
+	case string:
+		k.Kty = "oct"
+		k.K = b64Encode([]byte(v))
+	default:
+		return nil, ErrInvalidKeyType
+	}
+	if len(alg) > 0 {
+		k.Alg = alg[0]
+	} else {
+		k.Alg = k.defaultAlg()
+	}
+	return k, nil
+}
+
+func (k *JWK) setRSAPublic(pub *rsa.PublicKey) {
+	k.Kty = "RSA"
+	k.N = b64BigInt(pub.N)
+	k.E = b64BigInt(big.NewInt(int64(pub.E)))
+}
+
+func (k *JWK) setECPublic(pub *ecdsa.PublicKey) error {
+	if pub.Curve != elliptic.P256() {
+		return errors.New("jwk: only P-256 curve is supported")
+	}
+	k.Kty, k.Crv = "EC", "P-256"
+	k.X = b64Encode(pub.X.FillBytes(make([]byte, 32)))
+	k.Y = b64Encode(pub.Y.FillBytes(make([]byte, 32)))
+	return nil
+}
+
+func (k *JWK) defaultAlg() string {
+	switch k.Kty {
+	case "RSA":
+		return RS256
+	case "EC":
+		return ES256
+	case "OKP":
+		return EdDSA
+	case "oct":
+		return HS256
+	}
+	return ""
+}
+
+//IsPrivate 是否包含私钥或对称密钥
+func (k *JWK) IsPrivate() bool {
+	return k.D != "" || k.K != ""
+}
+
+//Public 返回只包含公钥部分的JWK，可用于对外发布
+func (k *JWK) Public() *JWK {
+	if k.Kty == "oct" {
+		return nil
+	}
+	return &JWK{Kty: k.Kty, Kid: k.Kid, Use: k.Use, Alg: k.Alg, Crv: k.Crv, N: k.N, E: k.E, X: k.X, Y: k.Y}
+}
+
+//Key 转换为Go密钥，包含私钥时返回私钥，否则返回公钥，oct返回[]byte
+func (k *JWK) Key() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		return k.rsaKey()
+	case "EC":
+		return k.ecKey()
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("jwk: unsupported okp curve " + k.Crv)
+		}
+		if k.D != "" {
+			seed, err := b64Decode(k.D)
+			if err != nil || len(seed) != ed25519.SeedSize {
+				return nil, errors.New("jwk: invalid ed25519 private key")
+			}
+			return ed25519.NewKeyFromSeed(seed), nil
+		}
+		x, err := b64Decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("jwk: invalid ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return b64Decode(k.K)
+	}
+	return nil, errors.New("jwk: unsupported kty " + k.Kty)
+}
+
+func (k *JWK) rsaKey() (interface{}, error) {
+	n, err1 := b64DecodeBigInt(k.N)
+	e, err2 := b64DecodeBigInt(k.E)
+	if err1 != nil || err2 != nil || !e.IsInt64() {
+		return nil, errors.New("jwk: invalid rsa public key")
+	}
+	pub := rsa.PublicKey{N: n, E: int(e.Int64())}
+	if k.D == "" {
+		return &pub, nil
+	}
+	d, err1 := b64DecodeBigInt(k.D)
+	p, err2 := b64DecodeBigInt(k.P)
+	q, err3 := b64DecodeBigInt(k.Q)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, errors.New("jwk: invalid rsa private key")
+	}
+	priv := &rsa.PrivateKey{PublicKey: pub, D: d, Primes: []*big.Int{p, q}}
+	if err := priv.Validate(); err != nil {
+		return nil, err
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+func (k *JWK) ecKey() (interface{}, error) {
+	if k.Crv != "P-256" {
+		return nil, errors.New("jwk: unsupported ec curve " + k.Crv)
+	}
+	x, err1 := b64DecodeBigInt(k.X)
+	y, err2 := b64DecodeBigInt(k.Y)
+	if err1 != nil || err2 != nil {
+		return nil, errors.New("jwk: invalid ec public key")
+	}
+	curve := elliptic.P256()
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("jwk: ec point is not on curve")
+	}
+	pub := ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if k.D == "" {
+		return &pub, nil
+	}
+	d, err := b64DecodeBigInt(k.D)
+	if err != nil {
+		return nil, errors.New("jwk: invalid ec private key")
+	}
+	return &ecdsa.PrivateKey{PublicKey: pub, D: d}, nil
+}
+
+//Thumbprint RFC 7638 JWK指纹（SHA-256，base64url），可用作kid
+func (k *JWK) Thumbprint() (string, error) {
+	var members interface{}
+	switch k.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	case "oct":
+		members = struct {
+			K   string `json:"k"`
+			Kty string `json:"kty"`
+		}{k.K, k.Kty}
+	default:
+		return "", errors.New("jwk: unsupported kty " + k.Kty)
+	}
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return b64Encode(sum[:]), nil
+}
+
+// =================== KeySet ======================
+
+//KeySet 按kid管理的密钥集合，支持密钥轮换，线程安全
+//签发使用当前密钥，校验按token头部的kid选择密钥，轮换后旧密钥仍可用于校验直到被移除
+type KeySet struct {
+	mu      *syncutils.RWMutex
+	keys    []*JWK
+	current string
+}
+
+//NewKeySet 创建密钥集合
+func NewKeySet(keys ...*JWK) (*KeySet, error) {
+	s := &KeySet{mu: syncutils.New()}
+	for _, k := range keys {
+		if err := s.Add(k); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+//Add 添加密钥，kid相同时替换，第一个添加的私钥默认作为签发密钥
+func (s *KeySet) Add(k *JWK) error {
+	if k == nil || k.Kid == "" {
+		return errors.New("jwk: kid can not be empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(k.Kid)
+	s.keys = append(s.keys, k)
+	if s.current == "" && k.IsPrivate() {
+		s.current = k.Kid
+	}
+	return nil
+}
+
+//Rotate 添加新的私钥并设为签发密钥，旧密钥保留用于校验
+func (s *KeySet) Rotate(k *JWK) error {
+	if k == nil || !k.IsPrivate() {
+		return errors.New("jwk: rotate key must be private key")
+	}
+	if err := s.Add(k); err != nil {
+		return err
+	}
+	return s.SetCurrent(k.Kid)
+}
+
+//SetCurrent 设置签发密钥
+func (s *KeySet) SetCurrent(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.keys {
+		if k.Kid == kid {
+			if !k.IsPrivate() {
+				return errors.New("jwk: current key must be private key")
+			}
+			s.current = kid
+			return nil
+		}
+	}
+	return ErrKeyNotFound
+}
+
+//Remove 移除密钥
+func (s *KeySet) Remove(kid string) {
+	s.mu.Lock()
+	s.remove(kid)
+	if s.current == kid {
+		s.current = ""
+	}
+	s.mu.Unlock()
+}
+
+func (s *KeySet) remove(kid string) {
+	for i, k := range s.keys {
+		if k.Kid == kid {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+//Replace 使用JWKS整体替换密钥，适用于定期拉取远端JWKS的场景
+//当前签发密钥仍存在时保留
+func (s *KeySet) Replace(jwks *JWKS) error {
+	keys := make([]*JWK, 0, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kid == "" {
+			return errors.New("jwk: kid can not be empty")
+		}
+		keys = append(keys, k)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	current := ""
+	for _, k := range keys {
+		if k.Kid == s.current && k.IsPrivate() {
+			current = k.Kid
+		}
+	}
+	s.current = current
+	return nil
+}
+
+//Get 按kid查找密钥，不存在返回nil
+func (s *KeySet) Get(kid string) *JWK {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range s.keys {
+		if k.Kid == kid {
+			return k
+		}
+	}
+	return nil
+}
+
+//Current 当前签发密钥，没有则返回nil
+func (s *KeySet) Current() *JWK {
+	s.mu.RLock()
+	kid := s.current
+	s.mu.RUnlock()
+	if kid == "" {
+		return nil
+	}
+	return s.Get(kid)
+}
+
+//PublicJWKS 导出全部公钥，用于发布到 /.well-known/jwks.json，对称密钥不会导出
+func (s *KeySet) PublicJWKS() *JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	jwks := &JWKS{Keys: make([]*JWK, 0, len(s.keys))}
+	for _, k := range s.keys {
+		if pub := k.Public(); pub != nil {
+			jwks.Keys = append(jwks.Keys, pub)
+		}
+	}
+	return jwks
+}
+
+//Sign 使用当前签发密钥签发JWT，头部写入kid
+func (s *KeySet) Sign(claims Claims) (string, error) {
+	k := s.Current()
+	if k == nil {
+		return "", ErrKeyNotFound
+	}
+	key, err := k.Key()
+	if err != nil {
+		return "", err
+	}
+	alg := k.Alg
+	if alg == "" {
+		alg = k.defaultAlg()
+	}
+	return Sign(claims, alg, key, k.Kid)
+}
+
+//VerifyKey 按头部kid选择校验密钥，JWK声明了alg时必须与头部一致
+//头部没有kid且集合中只有一个密钥时使用该密钥
+func (s *KeySet) VerifyKey(header *Header) (interface{}, error) {
+	var k *JWK
+	if header.Kid != "" {
+		k = s.Get(header.Kid)
+	} else {
+		s.mu.RLock()
+		if len(s.keys) == 1 {
+			k = s.keys[0]
+		}
+		s.mu.RUnlock()
+	}
+	if k == nil {
+		return nil, ErrKeyNotFound
+	}
+	if k.Alg != "" && k.Alg != header.Alg {
+		return nil, ErrAlgorithmNotAllowed
+	}
+	if k.Use != "" && k.Use != "sig" {
+		return nil, ErrKeyNotFound
+	}
+	return k.Key()
+}
+
+func b64BigInt(i *big.Int) string {
+	return b64Encode(i.Bytes())
+}
+
+func b64DecodeBigInt(s string) (*big.Int, error) {
+	b, err := b64Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("jwk: empty integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
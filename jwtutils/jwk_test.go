@@ -0,0 +1,131 @@
+package jwtutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 §3.1
+	k, err := ParseJWK([]byte(`{"kty":"RSA","e":"AQAB","alg":"RS256","kid":"2011-04-29",` +
+		`"n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp, err := k.Thumbprint()
+	if err != nil || tp != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Fatalf("thumbprint mismatch: %s %v", tp, err)
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	for _, c := range []struct {
+		key interface{}
+		alg string
+	}{{rsaKey, RS256}, {ecKey, ES256}, {edKey, EdDSA}, {[]byte("secret"), HS256}} {
+		jwk, err := NewJWK(c.key, "kid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jwk.Alg != c.alg || !jwk.IsPrivate() {
+			t.Fatalf("%s: unexpected jwk %+v", c.alg, jwk)
+		}
+		data, _ := json.Marshal(jwk)
+		parsed, err := ParseJWK(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := parsed.Key()
+		if err != nil {
+			t.Fatalf("%s: %v", c.alg, err)
+		}
+		token, err := Sign(Claims{"sub": "u"}, c.alg, key)
+		if err != nil {
+			t.Fatalf("%s: %v", c.alg, err)
+		}
+		verifyKey := c.key
+		if pub := parsed.Public(); pub != nil {
+			if pub.IsPrivate() {
+				t.Fatalf("%s: public jwk contains private key", c.alg)
+			}
+			if verifyKey, err = pub.Key(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := Parse(token, verifyKey, c.alg); err != nil {
+			t.Fatalf("%s: %v", c.alg, err)
+		}
+	}
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := NewJWK(p384, "kid"); err == nil {
+		t.Fatal("P-384 key should be rejected")
+	}
+	if _, err := ParseJWK([]byte(`{"kid":"x"}`)); err == nil {
+		t.Fatal("jwk without kty was accepted")
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	k1, _ := NewJWK(rsaTestKey(t), "k1")
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	k2, _ := NewJWK(ecKey, "k2")
+	set, err := NewKeySet(k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := set.Sign(NewClaims("", "u", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Rotate(k2); err != nil {
+		t.Fatal(err)
+	}
+	if set.Current().Kid != "k2" {
+		t.Fatal("rotated key should become current")
+	}
+	current, err := set.Sign(NewClaims("", "u", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 轮换后新旧token都可以校验
+	for _, token := range []string{old, current} {
+		if _, err := Parse(token, set, RS256, ES256); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 只发布公钥
+	jwks := set.PublicJWKS()
+	if len(jwks.Keys) != 2 || jwks.Get("k1").IsPrivate() || jwks.Get("k2").IsPrivate() {
+		t.Fatalf("unexpected public jwks %+v", jwks.Keys)
+	}
+	data, _ := json.Marshal(jwks)
+	parsed, _ := ParseJWKS(data)
+	verifySet, _ := NewKeySet()
+	if err := verifySet.Replace(parsed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(current, verifySet, ES256); err != nil {
+		t.Fatalf("verify with published jwks: %v", err)
+	}
+
+	set.Remove("k1")
+	if _, err := Parse(old, set, RS256, ES256); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound after removal, got %v", err)
+	}
+	// JWK声明的alg与头部不一致
+	forged, _ := Sign(Claims{}, HS256, []byte("x"), "k2")
+	if _, err := Parse(forged, set, HS256); err != ErrAlgorithmNotAllowed {
+		t.Fatalf("expected ErrAlgorithmNotAllowed, got %v", err)
+	}
+	if err := set.Rotate(jwks.Get("k1")); err == nil {
+		t.Fatal("public key can not be used for signing")
+	}
+}
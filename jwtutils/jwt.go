@@ -0,0 +1,258 @@
+package jwtutils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed            = errors.New("jwt: token is malformed")
+	ErrUnsupportedAlgorithm = errors.New("jwt: unsupported algorithm")
+	ErrAlgorithmNotAllowed  = errors.New("jwt: algorithm is not allowed")
+	ErrInvalidKeyType       = errors.New("jwt: key type does not match algorithm")
+	ErrKeyNotFound          = errors.New("jwt: key not found")
+	ErrInvalidSignature     = errors.New("jwt: signature is invalid")
+	ErrTokenExpired         = errors.New("jwt: token is expired")
+	ErrTokenNotValidYet     = errors.New("jwt: token is not valid yet")
+	ErrTokenUsedBeforeIssue = errors.New("jwt: token used before issued")
+	ErrInvalidIssuer        = errors.New("jwt: issuer is invalid")
+	ErrInvalidAudience      = errors.New("jwt: audience is invalid")
+	ErrMissingClaim         = errors.New("jwt: required claim is missing")
+	ErrUnsupportedCritical  = errors.New("jwt: unsupported critical header extension")
+)
+
+//Header JWS头部
+type Header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Cty string `json:"cty,omitempty"`
+	//Crit 必须被接收方理解的扩展头部，未被Parser.Crit声明的扩展会导致校验失败（RFC 7515 §4.1.11）
+	Crit []string `json:"crit,omitempty"`
+}
+
+//Token 解析后的JWT
+type Token struct {
+	Raw    string
+	Header Header
+	Claims Claims
+}
+
+//KeyFunc 根据头部(alg、kid)返回校验签名的密钥
+type KeyFunc func(header *Header) (interface{}, error)
+
+//Sign 签发JWT
+//key类型需与算法匹配：HS* 为[]byte或string，RS*/PS* 为*rsa.PrivateKey，ES256 为*ecdsa.PrivateKey，EdDSA 为ed25519.PrivateKey
+//kid 为非必需参数，写入头部用于校验方按kid选择密钥
+func Sign(claims Claims, alg string, key interface{}, kid ...string) (string, error) {
+	header := Header{Alg: alg, Typ: "JWT"}
+	if len(kid) > 0 {
+		header.Kid = kid[0]
+	}
+	return SignWithHeader(header, claims, key)
+}
+
+//SignWithHeader 使用自定义头部签发JWT
+func SignWithHeader(header Header, claims Claims, key interface{}) (string, error) {
+	if claims == nil {
+		claims = Claims{}
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return signPayload(header, payload, key)
+}
+
+func signPayload(header Header, payload []byte, key interface{}) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	signingInput := b64Encode(headerJSON) + "." + b64Encode(payload)
+	sig, err := sign(header.Alg, []byte(signingInput), key)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + b64Encode(sig), nil
+}
+
+//Parser JWT校验器
+type Parser struct {
+	Algorithms []string         //允许的签名算法，必须设置，防止算法混淆攻击
+	Issuer     string           //非空时校验iss
+	Audience   string           //非空时校验aud包含该值
+	Leeway     time.Duration    //校验exp、nbf、iat时允许的时钟偏差
+	RequireExp bool             //为true时exp必须存在
+	Now        func() time.Time //当前时间，默认time.Now
+	Crit       []string         //调用方能够处理的crit扩展头部，token的crit中出现其它扩展时拒绝
+}
+
+//NewParser 创建JWT校验器，algorithms为允许的签名算法
+func NewParser(algorithms ...string) *Parser {
+	return &Parser{Algorithms: algorithms}
+}
+
+//Parse 使用默认校验规则解析并校验JWT
+//key 为校验密钥、KeyFunc 或 *KeySet
+func Parse(token string, key interface{}, algorithms ...string) (*Token, error) {
+	return NewParser(algorithms...).Parse(token, key)
+}
+
+//Parse 解析JWT并校验签名及声明
+//key 为校验密钥、KeyFunc 或 *KeySet，签名校验失败时不会校验声明
+func (p *Parser) Parse(token string, key interface{}) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	t := &Token{Raw: token}
+	headerJSON, err := b64Decode(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if err := json.Unmarshal(headerJSON, &t.Header); err != nil {
+		return nil, ErrMalformed
+	}
+	if err := checkCrit(headerJSON, t.Header.Crit, p.Crit); err != nil {
+		return nil, err
+	}
+	if !p.allowed(t.Header.Alg) {
+		return nil, ErrAlgorithmNotAllowed
+	}
+	sig, err := b64Decode(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	verifyKey, err := resolveKey(key, &t.Header)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(t.Header.Alg, []byte(parts[0]+"."+parts[1]), sig, verifyKey); err != nil {
+		return nil, err
+	}
+	payload, err := b64Decode(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	d := json.NewDecoder(bytes.NewReader(payload))
+	d.UseNumber()
+	if err := d.Decode(&t.Claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if err := p.Validate(t.Claims); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+//Validate 校验声明：exp、nbf、iat、iss、aud
+func (p *Parser) Validate(claims Claims) error {
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+	// 已注册的时间声明存在时必须是NumericDate，不能当作缺失而跳过校验
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		if _, present := claims[name]; present {
+			if _, ok := claims.Time(name); !ok {
+				return ErrMalformed
+			}
+		}
+	}
+	if exp, ok := claims.ExpiresAt(); ok {
+		if !now.Before(exp.Add(p.Leeway)) {
+			return ErrTokenExpired
+		}
+	} else if p.RequireExp {
+		return ErrMissingClaim
+	}
+	if nbf, ok := claims.NotBefore(); ok && now.Add(p.Leeway).Before(nbf) {
+		return ErrTokenNotValidYet
+	}
+	if iat, ok := claims.IssuedAt(); ok && now.Add(p.Leeway).Before(iat) {
+		return ErrTokenUsedBeforeIssue
+	}
+	if p.Issuer != "" && claims.Issuer() != p.Issuer {
+		return ErrInvalidIssuer
+	}
+	if p.Audience != "" && !claims.HasAudience(p.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+func (p *Parser) allowed(alg string) bool {
+	for _, a := range p.Algorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// 不能出现在crit中的已注册头部（RFC 7515 §4.1.11、RFC 7516 §4.1）
+var registeredHeaders = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true, "x5c": true, "x5t": true, "x5t#S256": true,
+	"typ": true, "cty": true, "crit": true, "enc": true, "zip": true, "epk": true, "apu": true, "apv": true,
+	"iv": true, "tag": true, "p2s": true, "p2c": true,
+}
+
+// 校验crit头部：不能为空列表、不能包含已注册头部、扩展必须出现在头部中且被调用方理解
+func checkCrit(headerJSON []byte, crit []string, understood []string) error {
+	if crit == nil {
+		return nil
+	}
+	if len(crit) == 0 {
+		return ErrMalformed
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(headerJSON, &fields); err != nil {
+		return ErrMalformed
+	}
+	for _, name := range crit {
+		if registeredHeaders[name] {
+			return ErrMalformed
+		}
+		if _, ok := fields[name]; !ok {
+			return ErrMalformed
+		}
+		known := false
+		for _, u := range understood {
+			if u == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrUnsupportedCritical
+		}
+	}
+	return nil
+}
+
+func resolveKey(key interface{}, header *Header) (interface{}, error) {
+	switch k := key.(type) {
+	case KeyFunc:
+		return k(header)
+	case func(header *Header) (interface{}, error):
+		return k(header)
+	case *KeySet:
+		return k.VerifyKey(header)
+	case nil:
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func b64Encode(src []byte) string {
+	return base64.RawURLEncoding.EncodeToString(src)
+}
+
+func b64Decode(src string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(src)
+}
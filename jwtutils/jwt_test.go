@@ -0,0 +1,220 @@
+package jwtutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testRsaOnce sync.Once
+	testRsaKey  *rsa.PrivateKey
+)
+
+func rsaTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testRsaOnce.Do(func() {
+		testRsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	})
+	if testRsaKey == nil {
+		t.Fatal("generate rsa key failed")
+	}
+	return testRsaKey
+}
+
+// 各算法的签名密钥与校验密钥
+func signingKeys(t *testing.T) map[string][2]interface{} {
+	rsaKey := rsaTestKey(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	return map[string][2]interface{}{
+		HS256: {secret, secret},
+		HS384: {secret, string(secret)},
+		HS512: {secret, secret},
+		RS256: {rsaKey, &rsaKey.PublicKey},
+		RS512: {rsaKey, &rsaKey.PublicKey},
+		PS256: {rsaKey, &rsaKey.PublicKey},
+		PS512: {rsaKey, &rsaKey.PublicKey},
+		ES256: {ecKey, &ecKey.PublicKey},
+		EdDSA: {edKey, edPub},
+	}
+}
+
+// 替换token的某一段
+func replacePart(token string, i int, part string) string {
+	parts := strings.Split(token, ".")
+	parts[i] = part
+	return strings.Join(parts, ".")
+}
+
+func TestRFC7515HS256(t *testing.T) {
+	// RFC 7515 附录A.1
+	token := "eyJ0eXAiOiJKV1QiLA0KICJhbGciOiJIUzI1NiJ9" +
+		".eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ" +
+		".dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	key, err := b64Decode("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewParser(HS256)
+	p.Now = func() time.Time { return time.Unix(1300819000, 0) }
+	tok, err := p.Parse(token, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Claims.Issuer() != "joe" || tok.Claims["http://example.com/is_root"] != true {
+		t.Fatalf("unexpected claims %v", tok.Claims)
+	}
+	p.Now = nil
+	if _, err := p.Parse(token, key); err != ErrTokenExpired {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestSignAndParse(t *testing.T) {
+	for alg, keys := range signingKeys(t) {
+		claims := NewClaims("issuer", "user", time.Hour, "api")
+		token, err := Sign(claims, alg, keys[0], "k1")
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		tok, err := Parse(token, keys[1], alg)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if tok.Header.Alg != alg || tok.Header.Kid != "k1" || tok.Claims.Subject() != "user" || !tok.Claims.HasAudience("api") {
+			t.Fatalf("%s: unexpected token %+v", alg, tok)
+		}
+		// 私钥也可用于校验
+		if _, err := Parse(token, keys[0], alg); err != nil {
+			t.Fatalf("%s: verify with private key: %v", alg, err)
+		}
+		// 篡改载荷
+		forged := replacePart(token, 1, b64Encode([]byte(`{"sub":"admin"}`)))
+		if _, err := Parse(forged, keys[1], alg); err != ErrInvalidSignature {
+			t.Fatalf("%s: forged payload: %v", alg, err)
+		}
+		if _, err := Parse(token, keys[1], "HS256", "PS256", "EdDSA", "ES256", "RS512"); alg == RS256 && err != ErrAlgorithmNotAllowed {
+			t.Fatalf("%s: algorithm outside the allow list: %v", alg, err)
+		}
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	rsaKey := rsaTestKey(t)
+	// 攻击者把公钥当作HMAC密钥签发HS256 token
+	pubJSON, _ := json.Marshal(rsaKey.PublicKey)
+	forged, err := Sign(Claims{"sub": "admin"}, HS256, pubJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(forged, &rsaKey.PublicKey, RS256); err != ErrAlgorithmNotAllowed {
+		t.Fatalf("expected ErrAlgorithmNotAllowed, got %v", err)
+	}
+	if _, err := Parse(forged, &rsaKey.PublicKey, RS256, HS256); err != ErrInvalidKeyType {
+		t.Fatalf("expected ErrInvalidKeyType, got %v", err)
+	}
+	// alg=none
+	none := b64Encode([]byte(`{"alg":"none"}`)) + "." + b64Encode([]byte(`{"sub":"admin"}`)) + "."
+	if _, err := Parse(none, &rsaKey.PublicKey, RS256); err != ErrAlgorithmNotAllowed {
+		t.Fatalf("expected ErrAlgorithmNotAllowed for none, got %v", err)
+	}
+	if _, err := Parse(none, nil, "none"); err == nil {
+		t.Fatal("alg none was accepted")
+	}
+}
+
+func TestValidateClaims(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := &Parser{Algorithms: []string{HS256}, Issuer: "iss", Audience: "aud", Leeway: time.Minute, Now: func() time.Time { return now }}
+	cases := []struct {
+		claims Claims
+		err    error
+	}{
+		{Claims{"iss": "iss", "aud": "aud", "exp": now.Unix() + 10}, nil},
+		{Claims{"iss": "iss", "aud": []interface{}{"x", "aud"}, "exp": now.Unix() - 30}, nil},
+		{Claims{"iss": "iss", "aud": "aud", "exp": now.Unix() - 61}, ErrTokenExpired},
+		{Claims{"iss": "iss", "aud": "aud", "nbf": now.Unix() + 61}, ErrTokenNotValidYet},
+		{Claims{"iss": "iss", "aud": "aud", "iat": now.Unix() + 61}, ErrTokenUsedBeforeIssue},
+		{Claims{"iss": "other", "aud": "aud"}, ErrInvalidIssuer},
+		{Claims{"iss": "iss", "aud": "other"}, ErrInvalidAudience},
+		{Claims{"iss": "iss", "aud": "aud", "exp": json.Number("1700000000.5")}, nil},
+		{Claims{"iss": "iss", "aud": "aud", "exp": "1"}, ErrMalformed},
+		{Claims{"iss": "iss", "aud": "aud", "exp": nil}, ErrMalformed},
+		{Claims{"iss": "iss", "aud": "aud", "nbf": true}, ErrMalformed},
+		{Claims{"iss": "iss", "aud": "aud", "iat": []interface{}{1}}, ErrMalformed},
+	}
+	for i, c := range cases {
+		if err := p.Validate(c.claims); err != c.err {
+			t.Fatalf("case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+	p.RequireExp = true
+	if err := p.Validate(Claims{"iss": "iss", "aud": "aud"}); err != ErrMissingClaim {
+		t.Fatalf("expected ErrMissingClaim, got %v", err)
+	}
+
+	// 签名有效但exp为字符串的token不能绕过过期校验
+	key := []byte("0123456789abcdef0123456789abcdef")
+	token, err := Sign(Claims{"sub": "user", "exp": "1"}, HS256, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(token, key, HS256); err != ErrMalformed {
+		t.Fatalf("expected ErrMalformed for string exp, got %v", err)
+	}
+}
+
+func TestCritHeader(t *testing.T) {
+	secret := []byte("secret")
+	sign := func(header string) string {
+		h := b64Encode([]byte(header))
+		p := b64Encode([]byte(`{"sub":"u"}`))
+		return h + "." + p + "." + b64Encode(hmacSign(HS256, []byte(h+"."+p), secret))
+	}
+	cases := []struct {
+		header string
+		err    error
+	}{
+		{`{"alg":"HS256","crit":["exp"],"exp":1}`, ErrUnsupportedCritical},
+		{`{"alg":"HS256","crit":[]}`, ErrMalformed},
+		{`{"alg":"HS256","crit":["kid"],"kid":"a"}`, ErrMalformed},
+		{`{"alg":"HS256","crit":["b64"]}`, ErrMalformed},
+	}
+	for _, c := range cases {
+		if _, err := Parse(sign(c.header), secret, HS256); err != c.err {
+			t.Fatalf("%s: expected %v, got %v", c.header, c.err, err)
+		}
+	}
+	// 调用方声明能够处理的扩展
+	p := NewParser(HS256)
+	p.Crit = []string{"exp"}
+	if _, err := p.Parse(sign(`{"alg":"HS256","crit":["exp"],"exp":1}`), secret); err != nil {
+		t.Fatalf("understood critical extension was rejected: %v", err)
+	}
+
+	token, err := SignWithHeader(Header{Alg: HS256, Crit: []string{"x-ext"}}, Claims{}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 头部缺少x-ext字段
+	if _, err := Parse(token, secret, HS256); err != ErrMalformed {
+		t.Fatalf("expected ErrMalformed, got %v", err)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	for _, token := range []string{"", "a.b", "a.b.c.d", "!!.e30.", b64Encode([]byte("{")) + ".e30."} {
+		if _, err := Parse(token, []byte("k"), HS256); err != ErrMalformed {
+			t.Fatalf("%q: expected ErrMalformed, got %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+//TestingT 断言所需的测试接口，*testing.T 与 *testing.B 均满足
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+//Entry 捕获到的一条日志
+type Entry struct {
+	Level   string
+	Tag     string
+	Message string
+	Error   string
+	Caller  string
+	Fields  map[string]interface{} //除以上字段外的其它字段
+}
+
+//CaptureLogger 内存日志，替换全局logger后捕获所有日志，用于测试
+//全局logger只有一个，使用CaptureLogger的测试不能并行执行
+type CaptureLogger struct {
+	mu      sync.Mutex
+	entries []Entry
+
+	prevLogger zerolog.Logger
+	prevLevel  zerolog.Level
+}
+
+//Capture 使用内存logger替换全局logger，并开启debug级别
+//使用完毕后需调用Restore恢复
+func Capture() *CaptureLogger {
+	c := &CaptureLogger{
+		prevLogger: logger,
+		prevLevel:  zerolog.GlobalLevel(),
+	}
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	logger = zerolog.New(c)
+	return c
+}
+
+//CaptureT 同Capture，并在测试结束时自动Restore
+func CaptureT(t interface{ Cleanup(func()) }) *CaptureLogger {
+	c := Capture()
+	t.Cleanup(c.Restore)
+	return c
+}
+
+//Restore 恢复被替换的全局logger及日志级别
+func (c *CaptureLogger) Restore() {
+	logger = c.prevLogger
+	zerolog.SetGlobalLevel(c.prevLevel)
+}
+
+//Write 实现io.Writer，解析zerolog输出的json日志
+func (c *CaptureLogger) Write(p []byte) (int, error) {
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	var fields map[string]interface{}
+	if err := d.Decode(&fields); err != nil {
+		return 0, err
+	}
+	entry := Entry{
+		Level:   popString(fields, zerolog.LevelFieldName),
+		Tag:     popString(fields, "tag"),
+		Message: popString(fields, zerolog.MessageFieldName),
+		Error:   popString(fields, zerolog.ErrorFieldName),
+		Caller:  popString(fields, zerolog.CallerFieldName),
+		Fields:  fields,
+	}
+	c.mu.Lock()
+	c.entries = append(c.entries, entry)
+	c.mu.Unlock()
+	return len(p), nil
+}
+
+//Entries 获取已捕获的全部日志
+func (c *CaptureLogger) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]Entry, len(c.entries))
+	copy(entries, c.entries)
+	return entries
+}
+
+//Reset 清空已捕获的日志
+func (c *CaptureLogger) Reset() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
+
+//Len 已捕获的日志条数
+func (c *CaptureLogger) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+//Find 按级别和tag查找日志，参数为空表示不限制
+func (c *CaptureLogger) Find(level string, tag string) []Entry {
+	var found []Entry
+	for _, e := range c.Entries() {
+		if e.match(level, tag, "", nil) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+//AssertLogged 断言存在匹配的日志
+//level、tag、msg为空表示不限制，fields中的每个字段都需要相等
+func (c *CaptureLogger) AssertLogged(t TestingT, level string, tag string, msg string, fields ...map[string]interface{}) bool {
+	t.Helper()
+	var want map[string]interface{}
+	if len(fields) > 0 {
+		want = fields[0]
+	}
+	for _, e := range c.Entries() {
+		if e.match(level, tag, msg, want) {
+			return true
+		}
+	}
+	t.Errorf("no log entry matched level=%q tag=%q message=%q fields=%v\ncaptured:\n%s",
+		level, tag, msg, want, c.dump())
+	return false
+}
+
+//AssertNotLogged 断言不存在指定级别和tag的日志，参数为空表示不限制
+func (c *CaptureLogger) AssertNotLogged(t TestingT, level string, tag string) bool {
+	t.Helper()
+	if found := c.Find(level, tag); len(found) > 0 {
+		t.Errorf("unexpected log entry level=%q tag=%q: %d entries matched\ncaptured:\n%s",
+			level, tag, len(found), c.dump())
+		return false
+	}
+	return true
+}
+
+//AssertCount 断言已捕获的日志条数
+func (c *CaptureLogger) AssertCount(t TestingT, n int) bool {
+	t.Helper()
+	if l := c.Len(); l != n {
+		t.Errorf("expected %d log entries, got %d\ncaptured:\n%s", n, l, c.dump())
+		return false
+	}
+	return true
+}
+
+func (e Entry) match(level string, tag string, msg string, fields map[string]interface{}) bool {
+	if level != "" && !strings.EqualFold(e.Level, level) {
+		return false
+	}
+	if tag != "" && e.Tag != tag {
+		return false
+	}
+	if msg != "" && e.Message != msg {
+		return false
+	}
+	for k, v := range fields {
+		actual, ok := e.Fields[k]
+		if !ok || fmt.Sprint(actual) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *CaptureLogger) dump() string {
+	var sb strings.Builder
+	for _, e := range c.Entries() {
+		fmt.Fprintf(&sb, "  [%s] tag=%q message=%q", e.Level, e.Tag, e.Message)
+		if e.Error != "" {
+			fmt.Fprintf(&sb, " error=%q", e.Error)
+		}
+		if len(e.Fields) > 0 {
+			fmt.Fprintf(&sb, " fields=%v", e.Fields)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func popString(fields map[string]interface{}, key string) string {
+	v, ok := fields[key]
+	if !ok {
+		return ""
+	}
+	delete(fields, key)
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
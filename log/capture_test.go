@@ -0,0 +1,78 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// 记录断言失败信息，用于验证断言本身
+type recordT struct {
+	failures []string
+}
+
+func (r *recordT) Helper() {}
+
+func (r *recordT) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestCapture(t *testing.T) {
+	c := CaptureT(t)
+	Info("svc", "hello")
+	Debugf("svc", "n=%d", 3)
+	Errorf("db", errors.New("boom"), 1, "query %s", "x")
+	logger.Info().Str("tag", "svc").Int("port", 8080).Msg("listen")
+
+	c.AssertLogged(t, "INFO", "svc", "hello")
+	c.AssertLogged(t, "debug", "svc", "n=3")
+	c.AssertLogged(t, "error", "db", "query x")
+	c.AssertLogged(t, "", "", "listen", map[string]interface{}{"port": 8080})
+	c.AssertNotLogged(t, "warn", "")
+	c.AssertCount(t, 4)
+	if e := c.Find("error", "db"); len(e) != 1 || e[0].Error != "boom" || e[0].Caller == "" {
+		t.Fatalf("unexpected entries %+v", e)
+	}
+	c.Reset()
+	c.AssertCount(t, 0)
+}
+
+func TestCaptureAssertFailures(t *testing.T) {
+	c := CaptureT(t)
+	Warn("svc", "slow")
+	r := &recordT{}
+	if c.AssertLogged(r, "error", "svc", "") {
+		t.Fatal("AssertLogged should fail for a missing level")
+	}
+	if c.AssertNotLogged(r, "warn", "svc") {
+		t.Fatal("AssertNotLogged should fail for a present entry")
+	}
+	if c.AssertCount(r, 2) {
+		t.Fatal("AssertCount should fail for a wrong count")
+	}
+	if len(r.failures) != 3 || !strings.Contains(r.failures[0], `message="slow"`) {
+		t.Fatalf("failures should dump captured entries: %q", r.failures)
+	}
+}
+
+func TestCaptureRestore(t *testing.T) {
+	prevLevel := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	defer zerolog.SetGlobalLevel(prevLevel)
+
+	c := Capture()
+	if zerolog.GlobalLevel() != zerolog.DebugLevel {
+		t.Fatal("Capture should enable debug level")
+	}
+	c.Restore()
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Fatal("Restore should reset the global level")
+	}
+	Info("svc", "after restore")
+	if c.Len() != 0 {
+		t.Fatal("entries logged after Restore should not be captured")
+	}
+}
@@ -114,12 +114,41 @@ func Warnf(tag string, format string, a ...interface{}) {
 
 //Error error level print
 //caller should start from 1
+//err为包装过的error时，会同时打印err链及其携带的堆栈
 func Error(tag string, err error, caller int, msg string) {
-	logger.Error().Err(err).Caller(caller).Str("tag", tag).Msg(msg)
+	withError(logger.Error(), err, StackTrace(err)).Caller(caller).Str("tag", tag).Msg(msg)
 }
 
 //ErrorF error level print format
 //caller should start from 1
+//err为包装过的error时，会同时打印err链及其携带的堆栈
 func Errorf(tag string, err error, caller int, format string, a ...interface{}) {
-	logger.Error().Err(err).Caller(caller).Str("tag", tag).Msgf(format, a...)
+	withError(logger.Error(), err, StackTrace(err)).Caller(caller).Str("tag", tag).Msgf(format, a...)
+}
+
+//ErrorStack error level print with stack trace
+//自动记录调用位置，优先使用err链上携带的堆栈，否则采集当前调用堆栈
+func ErrorStack(tag string, err error, msg string) {
+	withError(logger.Error(), err, errorStack(err)).Caller(1).Str("tag", tag).Msg(msg)
+}
+
+//ErrorStackf error level print format with stack trace
+//自动记录调用位置，优先使用err链上携带的堆栈，否则采集当前调用堆栈
+func ErrorStackf(tag string, err error, format string, a ...interface{}) {
+	withError(logger.Error(), err, errorStack(err)).Caller(1).Str("tag", tag).Msgf(format, a...)
+}
+
+func withError(e *zerolog.Event, err error, stack string) *zerolog.Event {
+	if err == nil {
+		return e
+	}
+	return e.Str(zerolog.ErrorFieldName, renderError(err, stack))
+}
+
+func errorStack(err error) string {
+	if stack := StackTrace(err); stack != "" {
+		return stack
+	}
+	// 跳过runtime.Callers、captureStack、errorStack、ErrorStack(f)
+	return formatStack(captureStack(4))
 }
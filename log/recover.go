@@ -0,0 +1,61 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+// 捕获panic并记录后是否继续抛出，默认继续抛出
+var rePanic int32 = 1
+
+//SetRePanic 设置Recover捕获panic并记录日志后是否重新抛出
+func SetRePanic(enable bool) {
+	if enable {
+		atomic.StoreInt32(&rePanic, 1)
+	} else {
+		atomic.StoreInt32(&rePanic, 0)
+	}
+}
+
+//Recover 捕获panic，以error级别记录panic信息及堆栈
+//必须直接defer调用：defer log.Recover(tag)
+//记录后按SetRePanic的配置重新抛出或吞掉panic
+func Recover(tag string) {
+	if r := recover(); r != nil {
+		logPanic(tag, r)
+		if atomic.LoadInt32(&rePanic) == 1 {
+			panic(r)
+		}
+	}
+}
+
+//SafeGo 启动goroutine执行fn，fn中的panic会被记录
+//无论SetRePanic如何配置都不会重新抛出，避免导致进程退出
+func SafeGo(tag string, fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(tag, r)
+			}
+		}()
+		fn()
+	}()
+}
+
+func logPanic(tag string, r interface{}) {
+	// 丢弃runtime.gopanic及之前的栈帧，从panic发生处开始
+	pcs := captureStack(3)
+	for i := range pcs {
+		if fn := runtime.FuncForPC(pcs[i] - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+			pcs = pcs[i+1:]
+			break
+		}
+	}
+	stack := formatStack(pcs)
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+	withError(logger.Error(), err, stack).Str("tag", tag).Msg("panic recovered")
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// 堆栈最大采集深度
+const maxStackDepth = 64
+
+//stackError 携带创建时调用堆栈的error
+type stackError struct {
+	err   error
+	msg   string
+	stack []uintptr
+}
+
+func (e *stackError) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+//StackTrace 返回error创建时的调用堆栈
+func (e *stackError) StackTrace() string {
+	return formatStack(e.stack)
+}
+
+//WithStack 为err附加当前调用堆栈，err为nil时返回nil
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{err: err, stack: captureStack(3)}
+}
+
+//Wrap 为err附加说明信息及当前调用堆栈，err为nil时返回nil
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{err: err, msg: msg, stack: captureStack(3)}
+}
+
+//Wrapf 为err附加格式化说明信息及当前调用堆栈，err为nil时返回nil
+func Wrapf(err error, format string, a ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{err: err, msg: fmt.Sprintf(format, a...), stack: captureStack(3)}
+}
+
+//StackTrace 获取err链上最内层携带的调用堆栈，没有则返回空字符串
+func StackTrace(err error) string {
+	var stack string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if se, ok := e.(*stackError); ok {
+			stack = se.StackTrace()
+		}
+	}
+	return stack
+}
+
+//ErrorChain 按errors.Unwrap展开err链，返回每一层的类型与信息
+func ErrorChain(err error) []string {
+	var chain []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if _, ok := e.(*stackError); ok {
+			continue
+		}
+		chain = append(chain, fmt.Sprintf("%T: %s", e, e.Error()))
+	}
+	return chain
+}
+
+// 渲染error字段：错误信息、err链以及调用堆栈
+func renderError(err error, stack string) string {
+	var sb strings.Builder
+	sb.WriteString(err.Error())
+	chain := ErrorChain(err)
+	if len(chain) > 1 {
+		for _, c := range chain {
+			sb.WriteString("\n  caused by ")
+			sb.WriteString(c)
+		}
+	}
+	if stack != "" {
+		sb.WriteString("\nstack:\n")
+		sb.WriteString(stack)
+	}
+	return sb.String()
+}
+
+// 采集调用堆栈，skip含义与runtime.Callers一致
+func captureStack(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip, pcs)
+	return pcs[:n]
+}
+
+// 格式化调用堆栈，每帧两行：函数名、文件:行号
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			fmt.Fprintf(&sb, "  %s\n    %s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
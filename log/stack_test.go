@@ -0,0 +1,138 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func wrapDiskFull() error {
+	return Wrap(errors.New("disk full"), "write cache")
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil, "x") != nil || WithStack(nil) != nil || Wrapf(nil, "%d", 1) != nil {
+		t.Fatal("wrapping nil should return nil")
+	}
+	base := errors.New("disk full")
+	err := fmt.Errorf("save: %w", Wrapf(base, "write %s", "cache"))
+	if err.Error() != "save: write cache: disk full" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Fatal("errors.Is should see the wrapped error")
+	}
+	if stack := StackTrace(err); !strings.Contains(stack, "log.TestWrap") {
+		t.Fatalf("stack should contain the caller, got:\n%s", stack)
+	}
+	if StackTrace(base) != "" {
+		t.Fatal("plain error should have no stack")
+	}
+}
+
+func TestErrorChain(t *testing.T) {
+	err := fmt.Errorf("save: %w", wrapDiskFull())
+	chain := ErrorChain(err)
+	if len(chain) != 2 {
+		t.Fatalf("expected 2 layers, got %q", chain)
+	}
+	if !strings.HasPrefix(chain[0], "*fmt.wrapError: save:") || chain[1] != "*errors.errorString: disk full" {
+		t.Fatalf("unexpected chain %q", chain)
+	}
+	// 最内层的堆栈优先
+	outer := WithStack(err)
+	if stack := StackTrace(outer); !strings.Contains(stack, "log.wrapDiskFull") {
+		t.Fatalf("expected innermost stack, got:\n%s", stack)
+	}
+}
+
+func TestErrorLogsChainAndStack(t *testing.T) {
+	c := CaptureT(t)
+	Error("db", fmt.Errorf("save: %w", wrapDiskFull()), 1, "failed")
+	entries := c.Find("error", "db")
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	msg := entries[0].Error
+	for _, want := range []string{"save: write cache: disk full", "caused by *errors.errorString: disk full", "stack:", "log.wrapDiskFull"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error field missing %q:\n%s", want, msg)
+		}
+	}
+
+	c.Reset()
+	ErrorStack("db", errors.New("boom"), "stacked")
+	entries = c.Find("error", "db")
+	if len(entries) != 1 || !strings.Contains(entries[0].Error, "log.TestErrorLogsChainAndStack") {
+		t.Fatalf("ErrorStack should capture the calling stack: %+v", entries)
+	}
+	if !strings.Contains(entries[0].Caller, "stack_test.go") {
+		t.Fatalf("unexpected caller %q", entries[0].Caller)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	c := CaptureT(t)
+	SetRePanic(false)
+	defer SetRePanic(true)
+	func() {
+		defer Recover("job")
+		var m map[string]int
+		m["a"] = 1
+	}()
+	entries := c.Find("error", "job")
+	if len(entries) != 1 || entries[0].Message != "panic recovered" {
+		t.Fatalf("panic should be logged: %+v", entries)
+	}
+	if !strings.Contains(entries[0].Error, "assignment to entry in nil map") || !strings.Contains(entries[0].Error, "log.TestRecover") {
+		t.Fatalf("unexpected error field:\n%s", entries[0].Error)
+	}
+	if strings.Contains(entries[0].Error, "runtime.gopanic") {
+		t.Fatal("stack should start at the panic site")
+	}
+
+	SetRePanic(true)
+	defer func() {
+		if r := recover(); r != "again" {
+			t.Fatalf("expected re-panic, got %v", r)
+		}
+	}()
+	defer Recover("job")
+	panic("again")
+}
+
+func TestRecoverErrorFieldName(t *testing.T) {
+	c := CaptureT(t)
+	SetRePanic(false)
+	defer SetRePanic(true)
+	defer func(name string) { zerolog.ErrorFieldName = name }(zerolog.ErrorFieldName)
+	zerolog.ErrorFieldName = "err"
+	func() {
+		defer Recover("job")
+		panic("custom field")
+	}()
+	entries := c.Find("error", "job")
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Error, "custom field") {
+		t.Fatalf("error should be logged under zerolog.ErrorFieldName: %+v", entries)
+	}
+	if _, ok := entries[0].Fields["error"]; ok {
+		t.Fatalf("unexpected literal error field: %+v", entries[0].Fields)
+	}
+}
+
+func TestSafeGo(t *testing.T) {
+	c := CaptureT(t)
+	SafeGo("worker", func() { panic(errors.New("worker failed")) })
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	c.AssertLogged(t, "error", "worker", "panic recovered")
+	if e := c.Find("error", "worker"); len(e) != 1 || !strings.HasPrefix(e[0].Error, "worker failed") {
+		t.Fatalf("unexpected entries %+v", e)
+	}
+}
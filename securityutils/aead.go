@@ -0,0 +1,217 @@
+package securityutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// AEAD密文格式（版本1）：
+// | version(1) | algorithm(1) | nonce | ciphertext | tag |
+// version与algorithm作为附加数据的一部分参与认证，篡改后解密失败
+const (
+	AeadVersion1 byte = 1
+
+	aeadHeaderSize = 2
+)
+
+//AeadAlgorithm 认证加密算法
+type AeadAlgorithm byte
+
+const (
+	AeadAesGcm            AeadAlgorithm = 1 //AES-GCM，key必须为16/24/32位长度
+	AeadChaCha20Poly1305  AeadAlgorithm = 2 //ChaCha20-Poly1305，key必须为32位长度
+	AeadXChaCha20Poly1305 AeadAlgorithm = 3 //XChaCha20-Poly1305，key必须为32位长度，nonce为24位
+	AeadSm4Gcm            AeadAlgorithm = 4 //SM4-GCM，key必须为16位长度
+)
+
+func (alg AeadAlgorithm) String() string {
+	switch alg {
+	case AeadAesGcm:
+		return "AES-GCM"
+	case AeadChaCha20Poly1305:
+		return "ChaCha20-Poly1305"
+	case AeadXChaCha20Poly1305:
+		return "XChaCha20-Poly1305"
+	case AeadSm4Gcm:
+		return "SM4-GCM"
+	}
+	return "unknown"
+}
+
+//NewAead 根据算法创建cipher.AEAD
+func NewAead(alg AeadAlgorithm, key []byte) (cipher.AEAD, error) {
+	switch alg {
+	case AeadAesGcm:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case AeadChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	case AeadXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	case AeadSm4Gcm:
+		block, err := NewSm4Cipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	}
+	return nil, errors.New("unsupported aead algorithm")
+}
+
+//RandomBytes 生成n位密码学安全的随机字节，可用于key、iv、nonce
+func RandomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+//AeadEncrypt 认证加密，自动生成随机nonce并输出自描述密文
+//additionalData 附加认证数据，为非必需参数，解密时必须一致
+func AeadEncrypt(alg AeadAlgorithm, src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	aead, err := NewAead(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	dst := make([]byte, aeadHeaderSize+nonceSize, aeadHeaderSize+nonceSize+len(src)+aead.Overhead())
+	dst[0] = AeadVersion1
+	dst[1] = byte(alg)
+	nonce := dst[aeadHeaderSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	ad := aeadAdditionalData(dst[:aeadHeaderSize], additionalData)
+	return aead.Seal(dst, nonce, src, ad), nil
+}
+
+//AeadDecrypt 认证解密，算法与nonce从密文头部读取
+//additionalData 附加认证数据，为非必需参数，必须与加密时一致
+func AeadDecrypt(src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	alg, err := AeadAlgorithmOf(src)
+	if err != nil {
+		return nil, err
+	}
+	return aeadDecrypt(alg, src, key, additionalData)
+}
+
+//AeadAlgorithmOf 读取自描述密文使用的算法
+func AeadAlgorithmOf(src []byte) (AeadAlgorithm, error) {
+	if len(src) < aeadHeaderSize {
+		return 0, errors.New("src is too short, less than header size")
+	}
+	if src[0] != AeadVersion1 {
+		return 0, errors.New("unsupported aead envelope version")
+	}
+	return AeadAlgorithm(src[1]), nil
+}
+
+func aeadDecrypt(alg AeadAlgorithm, src []byte, key []byte, additionalData [][]byte) ([]byte, error) {
+	algOf, err := AeadAlgorithmOf(src)
+	if err != nil {
+		return nil, err
+	}
+	if algOf != alg {
+		return nil, errors.New("aead algorithm mismatch, expect " + alg.String() + " but got " + algOf.String())
+	}
+	aead, err := NewAead(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(src) < aeadHeaderSize+nonceSize+aead.Overhead() {
+		return nil, errors.New("src is too short, less than nonce and tag size")
+	}
+	nonce := src[aeadHeaderSize : aeadHeaderSize+nonceSize]
+	ciphertext := src[aeadHeaderSize+nonceSize:]
+	ad := aeadAdditionalData(src[:aeadHeaderSize], additionalData)
+	return aead.Open(nil, nonce, ciphertext, ad)
+}
+
+// 密文头部与用户附加数据一起参与认证
+func aeadAdditionalData(header []byte, additionalData [][]byte) []byte {
+	ad := make([]byte, 0, aeadHeaderSize+64)
+	ad = append(ad, header...)
+	if len(additionalData) > 0 {
+		ad = append(ad, additionalData[0]...)
+	}
+	return ad
+}
+
+// =================== AES-GCM ======================
+// AES加密, 使用GCM模式，注意key必须为16/24/32位长度，additionalData附加认证数据为非必需参数
+func AesEncryptGCM(src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	return AeadEncrypt(AeadAesGcm, src, key, additionalData...)
+}
+
+// AES解密, 使用GCM模式，注意key必须为16/24/32位长度，additionalData附加认证数据为非必需参数
+func AesDecryptGCM(src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	return aeadDecrypt(AeadAesGcm, src, key, additionalData)
+}
+
+// AES加密, 使用GCM模式，返回base64编码的密文
+func AesEncryptGCMToString(src []byte, key []byte, additionalData ...[]byte) (string, error) {
+	dst, err := AesEncryptGCM(src, key, additionalData...)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(dst), nil
+}
+
+// AES解密, 使用GCM模式，src为base64编码的密文
+func AesDecryptGCMString(src string, key []byte, additionalData ...[]byte) ([]byte, error) {
+	srcByte, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return nil, err
+	}
+	return AesDecryptGCM(srcByte, key, additionalData...)
+}
+
+// =================== ChaCha20-Poly1305 ======================
+// ChaCha20-Poly1305加密，注意key必须为32位长度，additionalData附加认证数据为非必需参数
+func ChaCha20Poly1305Encrypt(src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	return AeadEncrypt(AeadChaCha20Poly1305, src, key, additionalData...)
+}
+
+// ChaCha20-Poly1305解密，注意key必须为32位长度，additionalData附加认证数据为非必需参数
+func ChaCha20Poly1305Decrypt(src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	return aeadDecrypt(AeadChaCha20Poly1305, src, key, additionalData)
+}
+
+// ChaCha20-Poly1305加密，返回base64编码的密文
+func ChaCha20Poly1305EncryptToString(src []byte, key []byte, additionalData ...[]byte) (string, error) {
+	dst, err := ChaCha20Poly1305Encrypt(src, key, additionalData...)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(dst), nil
+}
+
+// ChaCha20-Poly1305解密，src为base64编码的密文
+func ChaCha20Poly1305DecryptString(src string, key []byte, additionalData ...[]byte) ([]byte, error) {
+	srcByte, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return nil, err
+	}
+	return ChaCha20Poly1305Decrypt(srcByte, key, additionalData...)
+}
+
+// XChaCha20-Poly1305加密，注意key必须为32位长度，nonce更长，适合大量随机nonce的场景
+func XChaCha20Poly1305Encrypt(src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	return AeadEncrypt(AeadXChaCha20Poly1305, src, key, additionalData...)
+}
+
+// XChaCha20-Poly1305解密，注意key必须为32位长度
+func XChaCha20Poly1305Decrypt(src []byte, key []byte, additionalData ...[]byte) ([]byte, error) {
+	return aeadDecrypt(AeadXChaCha20Poly1305, src, key, additionalData)
+}
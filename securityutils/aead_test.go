@@ -0,0 +1,127 @@
+package securityutils
+
+import (
+	"bytes"
+	"testing"
+)
+
+var aeadAlgorithms = []AeadAlgorithm{AeadAesGcm, AeadChaCha20Poly1305, AeadXChaCha20Poly1305, AeadSm4Gcm}
+
+func aeadTestKey(t *testing.T, alg AeadAlgorithm) []byte {
+	t.Helper()
+	n := 32
+	if alg == AeadSm4Gcm {
+		n = 16
+	}
+	key, err := RandomBytes(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAeadRoundTrip(t *testing.T) {
+	for _, alg := range aeadAlgorithms {
+		key := aeadTestKey(t, alg)
+		for _, plain := range [][]byte{{}, []byte("hello"), bytes.Repeat([]byte{0xAB}, 4096)} {
+			ct, err := AeadEncrypt(alg, plain, key, []byte("ad"))
+			if err != nil {
+				t.Fatalf("%s: %v", alg, err)
+			}
+			if got, err := AeadAlgorithmOf(ct); err != nil || got != alg {
+				t.Fatalf("%s: header algorithm %s, %v", alg, got, err)
+			}
+			pt, err := AeadDecrypt(ct, key, []byte("ad"))
+			if err != nil || !bytes.Equal(pt, plain) {
+				t.Fatalf("%s: round trip failed: %v", alg, err)
+			}
+		}
+		a, _ := AeadEncrypt(alg, []byte("same"), key)
+		b, _ := AeadEncrypt(alg, []byte("same"), key)
+		if bytes.Equal(a, b) {
+			t.Fatalf("%s: nonce should be random", alg)
+		}
+	}
+}
+
+func TestAeadTamper(t *testing.T) {
+	for _, alg := range aeadAlgorithms {
+		key := aeadTestKey(t, alg)
+		ct, err := AeadEncrypt(alg, []byte("attack at dawn"), key, []byte("ad"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 任意一位被修改都必须解密失败，包括版本与算法头
+		for i := range ct {
+			bad := append([]byte{}, ct...)
+			bad[i] ^= 0x01
+			if _, err := AeadDecrypt(bad, key, []byte("ad")); err == nil {
+				t.Fatalf("%s: tampered byte %d was accepted", alg, i)
+			}
+		}
+		for _, cut := range []int{0, 1, 2, len(ct) - 1} {
+			if _, err := AeadDecrypt(ct[:cut], key, []byte("ad")); err == nil {
+				t.Fatalf("%s: truncated to %d bytes was accepted", alg, cut)
+			}
+		}
+		if _, err := AeadDecrypt(ct, key); err == nil {
+			t.Fatalf("%s: missing additional data was accepted", alg)
+		}
+		if _, err := AeadDecrypt(ct, key, []byte("other")); err == nil {
+			t.Fatalf("%s: wrong additional data was accepted", alg)
+		}
+		if _, err := AeadDecrypt(ct, aeadTestKey(t, alg), []byte("ad")); err == nil {
+			t.Fatalf("%s: wrong key was accepted", alg)
+		}
+	}
+}
+
+func TestAeadAlgorithmMismatch(t *testing.T) {
+	key := aeadTestKey(t, AeadChaCha20Poly1305)
+	ct, err := ChaCha20Poly1305Encrypt([]byte("x"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AesDecryptGCM(ct, key); err == nil {
+		t.Fatal("AesDecryptGCM should reject ChaCha20-Poly1305 ciphertext")
+	}
+	if _, err := XChaCha20Poly1305Decrypt(ct, key); err == nil {
+		t.Fatal("XChaCha20Poly1305Decrypt should reject ChaCha20-Poly1305 ciphertext")
+	}
+	if pt, err := ChaCha20Poly1305Decrypt(ct, key); err != nil || string(pt) != "x" {
+		t.Fatalf("ChaCha20Poly1305Decrypt: %v", err)
+	}
+}
+
+func TestAeadKeySize(t *testing.T) {
+	for _, c := range []struct {
+		alg AeadAlgorithm
+		n   int
+	}{{AeadAesGcm, 15}, {AeadChaCha20Poly1305, 16}, {AeadXChaCha20Poly1305, 31}, {AeadSm4Gcm, 32}, {AeadAlgorithm(99), 32}} {
+		if _, err := AeadEncrypt(c.alg, []byte("x"), make([]byte, c.n)); err == nil {
+			t.Fatalf("%s: %d byte key should be rejected", c.alg, c.n)
+		}
+	}
+}
+
+func TestAeadString(t *testing.T) {
+	key := aeadTestKey(t, AeadAesGcm)[:16]
+	s, err := AesEncryptGCMToString([]byte("x"), key, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := AesDecryptGCMString(s, key, []byte("ad")); err != nil || string(pt) != "x" {
+		t.Fatalf("AesDecryptGCMString: %v", err)
+	}
+	if _, err := AesDecryptGCMString("!"+s, key, []byte("ad")); err == nil {
+		t.Fatal("invalid base64 should be rejected")
+	}
+	key = aeadTestKey(t, AeadChaCha20Poly1305)
+	s, err = ChaCha20Poly1305EncryptToString([]byte("y"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := ChaCha20Poly1305DecryptString(s, key); err != nil || string(pt) != "y" {
+		t.Fatalf("ChaCha20Poly1305DecryptString: %v", err)
+	}
+}
@@ -97,6 +97,7 @@ func (x *ecbDecrypter) CryptBlocks(dst, src []byte) {
 
 // =================== CBC模式 ======================
 // AES加密, 使用CBC模式，注意key必须为16/24/32位长度，iv初始化向量为非必需参数（长度为16位）
+// 未传iv时使用key的前16位作为iv，仅为兼容保留，新代码请使用AesEncryptCBCSafe
 func AesEncryptCBC(src []byte, key []byte, iv ...[]byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -163,7 +164,10 @@ func AesEncryptCFB(src []byte, key []byte) ([]byte, error) {
 
 // AES解密, 使用CFB模式
 func AesDecryptCFB(src []byte, key []byte) (dst []byte, err error) {
-	block, _ := aes.NewCipher(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
 	if len(src) < aes.BlockSize {
 		return nil, errors.New("src is too short, less than block size")
 	}
@@ -182,7 +186,7 @@ func PKCS5Padding(src []byte, blockSize int) []byte {
 	return append(src, padtext...)
 }
 
-// 去除PKCS5补位
+// 去除PKCS5补位，不校验补位内容，输入为空时会panic，新代码请使用PKCS5UnPaddingSafe
 func PKCS5UnPadding(src []byte) []byte {
 	length := len(src)
 	padtext := int(src[length-1])
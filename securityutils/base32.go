@@ -0,0 +1,51 @@
+package securityutils
+
+import (
+	"encoding/base32"
+	"strings"
+)
+
+// Crockford Base32字母表，去除了易混淆的 I、L、O、U
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordEncoding = base32.NewEncoding(crockfordAlphabet).WithPadding(base32.NoPadding)
+
+var crockfordReplacer = strings.NewReplacer("-", "", "I", "1", "L", "1", "O", "0")
+
+//Base32加密（RFC 4648）
+func Base32EncodeToString(src []byte) string {
+	return base32.StdEncoding.EncodeToString(src)
+}
+
+//Base32解密（RFC 4648），不区分大小写，兼容有无填充两种格式
+func Base32DecodeString(src string) ([]byte, error) {
+	src = strings.TrimRight(strings.ToUpper(src), "=")
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(src)
+}
+
+//Base32加密（RFC 4648），无填充
+func Base32RawEncodeToString(src []byte) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(src)
+}
+
+//Base32 Hex加密（RFC 4648 扩展十六进制字母表），编码结果保持字节序
+func Base32HexEncodeToString(src []byte) string {
+	return base32.HexEncoding.EncodeToString(src)
+}
+
+//Base32 Hex解密，不区分大小写，兼容有无填充两种格式
+func Base32HexDecodeString(src string) ([]byte, error) {
+	src = strings.TrimRight(strings.ToUpper(src), "=")
+	return base32.HexEncoding.WithPadding(base32.NoPadding).DecodeString(src)
+}
+
+//Crockford Base32加密，无填充
+func CrockfordEncodeToString(src []byte) string {
+	return crockfordEncoding.EncodeToString(src)
+}
+
+//Crockford Base32解密，不区分大小写，忽略连字符，I、L按1处理，O按0处理
+func CrockfordDecodeString(src string) ([]byte, error) {
+	src = crockfordReplacer.Replace(strings.ToUpper(src))
+	return crockfordEncoding.DecodeString(src)
+}
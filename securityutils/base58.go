@@ -0,0 +1,112 @@
+package securityutils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// Base58使用比特币字母表，去除了易混淆的 0、O、I、l
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Index = func() [256]int8 {
+	var index [256]int8
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base58Alphabet); i++ {
+		index[base58Alphabet[i]] = int8(i)
+	}
+	return index
+}()
+
+//Base58加密
+func Base58Encode(src []byte) string {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+	// log(256)/log(58) ≈ 1.37
+	buf := make([]byte, (len(src)-zeros)*138/100+1)
+	size := 0
+	for _, b := range src[zeros:] {
+		carry := int(b)
+		for i := 0; i < size || carry != 0; i++ {
+			carry += 256 * int(buf[i])
+			buf[i] = byte(carry % 58)
+			carry /= 58
+			if i >= size {
+				size = i + 1
+			}
+		}
+	}
+	dst := make([]byte, zeros+size)
+	for i := 0; i < zeros; i++ {
+		dst[i] = base58Alphabet[0]
+	}
+	for i := 0; i < size; i++ {
+		dst[zeros+i] = base58Alphabet[buf[size-1-i]]
+	}
+	return string(dst)
+}
+
+//Base58解密
+func Base58Decode(src string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	// log(58)/log(256) ≈ 0.733
+	buf := make([]byte, (len(src)-zeros)*733/1000+1)
+	size := 0
+	for i := zeros; i < len(src); i++ {
+		carry := int(base58Index[src[i]])
+		if carry < 0 {
+			return nil, errors.New("invalid base58 character")
+		}
+		for j := 0; j < size || carry != 0; j++ {
+			carry += 58 * int(buf[j])
+			buf[j] = byte(carry)
+			carry >>= 8
+			if j >= size {
+				size = j + 1
+			}
+		}
+	}
+	dst := make([]byte, zeros+size)
+	for i := 0; i < size; i++ {
+		dst[zeros+i] = buf[size-1-i]
+	}
+	return dst, nil
+}
+
+//Base58Check加密，version为版本前缀，末尾追加4位双重SHA-256校验码
+func Base58CheckEncode(src []byte, version byte) string {
+	payload := make([]byte, 0, 1+len(src)+4)
+	payload = append(payload, version)
+	payload = append(payload, src...)
+	payload = append(payload, base58Checksum(payload)...)
+	return Base58Encode(payload)
+}
+
+//Base58Check解密，校验码不匹配时返回error
+func Base58CheckDecode(src string) (version byte, dst []byte, err error) {
+	payload, err := Base58Decode(src)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(payload) < 5 {
+		return 0, nil, errors.New("invalid base58check format")
+	}
+	body, checksum := payload[:len(payload)-4], payload[len(payload)-4:]
+	if !bytes.Equal(checksum, base58Checksum(body)) {
+		return 0, nil, errors.New("base58check checksum error")
+	}
+	return body[0], body[1:], nil
+}
+
+func base58Checksum(src []byte) []byte {
+	first := sha256.Sum256(src)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
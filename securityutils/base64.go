@@ -3,6 +3,7 @@ package securityutils
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 )
 
 //Base64加密
@@ -35,3 +36,48 @@ func Base64DecodeString(src string) []byte {
 	}
 	return dst
 }
+
+//Base64解密，解码失败返回error
+func Base64DecodeErr(src []byte) ([]byte, error) {
+	dst := make([]byte, base64.StdEncoding.DecodedLen(len(src)))
+	n, err := base64.StdEncoding.Decode(dst, src)
+	if err != nil {
+		return nil, err
+	}
+	return dst[:n], nil
+}
+
+//Base64解密，解码失败返回error
+func Base64DecodeStringErr(src string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(src)
+}
+
+//Base64加密，无填充
+func Base64RawEncodeToString(src []byte) string {
+	return base64.RawStdEncoding.EncodeToString(src)
+}
+
+//Base64解密，兼容有无填充两种格式
+func Base64RawDecodeString(src string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(src, "="))
+}
+
+//Base64 URL安全加密，使用 - 与 _ 替代 + 与 /
+func Base64URLEncodeToString(src []byte) string {
+	return base64.URLEncoding.EncodeToString(src)
+}
+
+//Base64 URL安全解密，兼容有无填充两种格式
+func Base64URLDecodeString(src string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(src, "="))
+}
+
+//Base64 URL安全加密，无填充，常用于JWT、URL参数
+func Base64RawURLEncodeToString(src []byte) string {
+	return base64.RawURLEncoding.EncodeToString(src)
+}
+
+//Base64 URL安全解密，兼容有无填充两种格式
+func Base64RawURLDecodeString(src string) ([]byte, error) {
+	return Base64URLDecodeString(src)
+}
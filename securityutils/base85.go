@@ -0,0 +1,29 @@
+package securityutils
+
+import (
+	"encoding/ascii85"
+	"errors"
+	"strings"
+)
+
+//Base85加密（Adobe Ascii85，不含 <~ ~> 定界符）
+func Base85EncodeToString(src []byte) string {
+	dst := make([]byte, ascii85.MaxEncodedLen(len(src)))
+	n := ascii85.Encode(dst, src)
+	return string(dst[:n])
+}
+
+//Base85解密，兼容带 <~ ~> 定界符的格式，忽略空白字符
+func Base85DecodeString(src string) ([]byte, error) {
+	src = strings.TrimSpace(src)
+	if strings.HasPrefix(src, "<~") && strings.HasSuffix(src, "~>") {
+		src = src[2 : len(src)-2]
+	}
+	// 'z'代表4个0字节，解码结果最多为输入的4倍
+	dst := make([]byte, 4*len(src))
+	n, _, err := ascii85.Decode(dst, []byte(src), true)
+	if err != nil {
+		return nil, errors.New("invalid base85 data: " + err.Error())
+	}
+	return dst[:n], nil
+}
@@ -0,0 +1,110 @@
+package securityutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// 密钥协商得到的共享秘密不应直接作为密钥使用，需经HKDF派生
+const ecdhKeyInfo = "gocommon ecdh"
+
+// =================== ECDH ======================
+//EcdhSharedSecret Ecdh密钥协商，返回共享秘密（x坐标），双方曲线必须一致
+//基于crypto/ecdh实现，常量时间计算并校验对方公钥，支持P-256、P-384、P-521曲线
+func EcdhSharedSecret(privateKey *ecdsa.PrivateKey, peerPublicKey *ecdsa.PublicKey) ([]byte, error) {
+	if privateKey == nil || peerPublicKey == nil {
+		return nil, errors.New("key can not be nil")
+	}
+	if peerPublicKey.Curve != privateKey.Curve {
+		return nil, errors.New("ecdh curve mismatch")
+	}
+	priv, err := privateKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := peerPublicKey.ECDH()
+	if err != nil {
+		return nil, errors.New("invalid peer public key: " + err.Error())
+	}
+	return priv.ECDH(pub)
+}
+
+// Ecdh协商并派生密钥，可直接传给AesEncryptGCM等加解密函数
+// privateKey、peerPublicKey为PEM格式，keyLen为密钥长度（AES为16/24/32）
+// info 为非必需参数，用于区分不同用途的密钥，双方必须一致
+func EcdhDeriveKey(privateKey string, peerPublicKey string, keyLen int, info ...[]byte) ([]byte, error) {
+	return ecdhDeriveKey([]byte(privateKey), []byte(peerPublicKey), keyLen, info)
+}
+
+// Ecdh协商并派生密钥，privateKeyPath、peerPublicKeyPath为PEM文件路径
+func EcdhDeriveKeyPath(privateKeyPath string, peerPublicKeyPath string, keyLen int, info ...[]byte) ([]byte, error) {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	peerPublicKey, err := ioutil.ReadFile(peerPublicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return ecdhDeriveKey(privateKey, peerPublicKey, keyLen, info)
+}
+
+func ecdhDeriveKey(privateKey []byte, peerPublicKey []byte, keyLen int, info [][]byte) ([]byte, error) {
+	privKey, err := ParseEcdsaPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := ParseEcdsaPublicKey(peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := EcdhSharedSecret(privKey, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return HkdfSha256(secret, nil, keyInfo(info), keyLen)
+}
+
+// =================== X25519 ======================
+//GenerateX25519Key 生成X25519密钥对，均为32位
+func GenerateX25519Key() (privateKey []byte, publicKey []byte, err error) {
+	privateKey = make([]byte, curve25519.ScalarSize)
+	if _, err = io.ReadFull(rand.Reader, privateKey); err != nil {
+		return nil, nil, err
+	}
+	publicKey, err = curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateKey, publicKey, nil
+}
+
+//X25519SharedSecret X25519密钥协商，返回32位共享秘密
+func X25519SharedSecret(privateKey []byte, peerPublicKey []byte) ([]byte, error) {
+	if len(privateKey) != curve25519.ScalarSize || len(peerPublicKey) != curve25519.PointSize {
+		return nil, errors.New("x25519 key size error")
+	}
+	return curve25519.X25519(privateKey, peerPublicKey)
+}
+
+//X25519DeriveKey X25519协商并派生密钥，可直接传给AesEncryptGCM、ChaCha20Poly1305Encrypt等函数
+//info 为非必需参数，用于区分不同用途的密钥，双方必须一致
+func X25519DeriveKey(privateKey []byte, peerPublicKey []byte, keyLen int, info ...[]byte) ([]byte, error) {
+	secret, err := X25519SharedSecret(privateKey, peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return HkdfSha256(secret, nil, keyInfo(info), keyLen)
+}
+
+func keyInfo(info [][]byte) []byte {
+	if len(info) > 0 {
+		return info[0]
+	}
+	return []byte(ecdhKeyInfo)
+}
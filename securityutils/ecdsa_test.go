@@ -0,0 +1,151 @@
+package securityutils
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+)
+
+func TestEcdsaSign(t *testing.T) {
+	for _, f := range []EcKeyFormat{EcKeyPKCS8, EcKeySEC1} {
+		priv, pub, err := GenerateEcdsaKeyPair(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sign, err := EcdsaSign("message", priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := EcdsaVerify("message", sign, pub); err != nil {
+			t.Fatal(err)
+		}
+		if err := EcdsaVerify("messagE", sign, pub); err == nil {
+			t.Fatal("modified message was accepted")
+		}
+	}
+	for _, curve := range []elliptic.Curve{elliptic.P384(), elliptic.P521()} {
+		key, err := GenerateEcdsaKey(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sign, err := EcdsaSignByte([]byte("m"), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := EcdsaVerifyByte([]byte("m"), sign, &key.PublicKey); err != nil {
+			t.Fatalf("%s: %v", curve.Params().Name, err)
+		}
+	}
+	_, edPub, _ := GenerateEd25519KeyPair()
+	if _, err := ParseEcdsaPublicKey([]byte(edPub)); err == nil {
+		t.Fatal("Ed25519 key parsed as ECDSA key")
+	}
+}
+
+func TestEd25519(t *testing.T) {
+	// RFC 8032 §7.1 TEST 1
+	seed := HexDecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	key := ed25519.NewKeyFromSeed(seed)
+	if HexEncodeToString(key.Public().(ed25519.PublicKey)) != "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a" {
+		t.Fatal("public key mismatch")
+	}
+	sign, err := Ed25519SignByte(nil, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HexEncodeToString(sign) != "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b" {
+		t.Fatalf("signature mismatch: %x", sign)
+	}
+
+	priv, pub, err := GenerateEd25519KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Ed25519Sign("message", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Ed25519Verify("message", s, pub); err != nil {
+		t.Fatal(err)
+	}
+	if err := Ed25519Verify("messagE", s, pub); err == nil {
+		t.Fatal("modified message was accepted")
+	}
+}
+
+func TestEcdh(t *testing.T) {
+	privA, pubA, _ := GenerateEcdsaKeyPair()
+	privB, pubB, _ := GenerateEcdsaKeyPair(EcKeySEC1)
+	k1, err := EcdhDeriveKey(privA, pubB, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := EcdhDeriveKey(privB, pubA, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(k1, k2) {
+		t.Fatal("both sides should derive the same key")
+	}
+	if k3, _ := EcdhDeriveKey(privA, pubB, 32, []byte("other")); bytes.Equal(k1, k3) {
+		t.Fatal("different info should derive different keys")
+	}
+
+	a, _ := GenerateEcdsaKey(elliptic.P384())
+	b, _ := GenerateEcdsaKey(elliptic.P384())
+	s1, err := EcdhSharedSecret(a, &b.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, _ := EcdhSharedSecret(b, &a.PublicKey)
+	if len(s1) != 48 || !bytes.Equal(s1, s2) {
+		t.Fatal("P-384 shared secrets differ")
+	}
+
+	p256, _ := GenerateEcdsaKey()
+	if _, err := EcdhSharedSecret(p256, &a.PublicKey); err == nil {
+		t.Fatal("curve mismatch was accepted")
+	}
+	// 不在曲线上的点必须被拒绝（无效曲线攻击）
+	bad := &ecdsa.PublicKey{Curve: elliptic.P256(), X: big.NewInt(1), Y: big.NewInt(1)}
+	if _, err := EcdhSharedSecret(p256, bad); err == nil {
+		t.Fatal("point not on curve was accepted")
+	}
+	if _, err := EcdhSharedSecret(nil, bad); err == nil {
+		t.Fatal("nil key was accepted")
+	}
+}
+
+func TestX25519(t *testing.T) {
+	// RFC 7748 §6.1
+	alice := HexDecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	bobPub := HexDecodeString("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+	secret, err := X25519SharedSecret(alice, bobPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HexEncodeToString(secret) != "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742" {
+		t.Fatalf("shared secret mismatch: %x", secret)
+	}
+
+	a, ap, _ := GenerateX25519Key()
+	b, bp, _ := GenerateX25519Key()
+	k1, err := X25519DeriveKey(a, bp, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, _ := X25519DeriveKey(b, ap, 32)
+	if !bytes.Equal(k1, k2) {
+		t.Fatal("both sides should derive the same key")
+	}
+	if _, err := X25519SharedSecret(a[:31], bp); err == nil {
+		t.Fatal("short key was accepted")
+	}
+	// 低阶点产生全零共享秘密，必须被拒绝
+	if _, err := X25519SharedSecret(a, make([]byte, 32)); err == nil {
+		t.Fatal("low order point was accepted")
+	}
+}
@@ -0,0 +1,154 @@
+package securityutils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+)
+
+//GenerateEd25519Key 生成Ed25519密钥对
+func GenerateEd25519Key() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+//GenerateEd25519KeyPair 生成PEM格式的Ed25519密钥对(PKCS#8/PKIX)，可直接用于Ed25519Sign、Ed25519Verify等函数
+func GenerateEd25519KeyPair() (privateKey string, publicKey string, err error) {
+	pubKey, privKey, err := GenerateEd25519Key()
+	if err != nil {
+		return "", "", err
+	}
+	priv, err := Ed25519PrivateKeyToPEM(privKey)
+	if err != nil {
+		return "", "", err
+	}
+	pub, err := Ed25519PublicKeyToPEM(pubKey)
+	if err != nil {
+		return "", "", err
+	}
+	return string(priv), string(pub), nil
+}
+
+//Ed25519PrivateKeyToDER 导出DER格式(PKCS#8)私钥
+func Ed25519PrivateKeyToDER(key ed25519.PrivateKey) ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(key)
+}
+
+//Ed25519PrivateKeyToPEM 导出PEM格式(PKCS#8)私钥
+func Ed25519PrivateKeyToPEM(key ed25519.PrivateKey) ([]byte, error) {
+	der, err := Ed25519PrivateKeyToDER(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+//Ed25519PublicKeyToDER 导出DER格式(PKIX)公钥
+func Ed25519PublicKeyToDER(key ed25519.PublicKey) ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(key)
+}
+
+//Ed25519PublicKeyToPEM 导出PEM格式(PKIX)公钥
+func Ed25519PublicKeyToPEM(key ed25519.PublicKey) ([]byte, error) {
+	der, err := Ed25519PublicKeyToDER(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+//ParseEd25519PrivateKey 解析Ed25519私钥，支持PEM与DER格式的PKCS#8私钥
+func ParseEd25519PrivateKey(privateKey []byte) (ed25519.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(pemOrDER(privateKey))
+	if err != nil {
+		return nil, errors.New("private key error")
+	}
+	privKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not ed25519 key")
+	}
+	return privKey, nil
+}
+
+//ParseEd25519PublicKey 解析Ed25519公钥，支持PEM与DER格式的PKIX公钥以及X.509证书
+func ParseEd25519PublicKey(publicKey []byte) (ed25519.PublicKey, error) {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not ed25519 key")
+	}
+	return pubKey, nil
+}
+
+// Ed25519签名，返回base64编码的签名
+func Ed25519Sign(src string, privateKey string) (string, error) {
+	return ed25519Sign(src, []byte(privateKey))
+}
+
+// Ed25519签名，返回base64编码的签名
+func Ed25519SignPath(src string, privateKeyPath string) (string, error) {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return ed25519Sign(src, privateKey)
+}
+
+func ed25519Sign(src string, privateKey []byte) (string, error) {
+	key, err := ParseEd25519PrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(src))), nil
+}
+
+// Ed25519验签，sign为base64编码的签名，验签失败返回error
+func Ed25519Verify(src string, sign string, publicKey string) error {
+	return ed25519Verify(src, sign, []byte(publicKey))
+}
+
+// Ed25519验签，sign为base64编码的签名，验签失败返回error
+func Ed25519VerifyPath(src string, sign string, publicKeyPath string) error {
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	return ed25519Verify(src, sign, publicKey)
+}
+
+func ed25519Verify(src string, sign string, publicKey []byte) error {
+	key, err := ParseEd25519PublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	signByte, err := base64.StdEncoding.DecodeString(sign)
+	if err != nil {
+		return err
+	}
+	return Ed25519VerifyByte([]byte(src), signByte, key)
+}
+
+// Ed25519签名
+func Ed25519SignByte(src []byte, key ed25519.PrivateKey) ([]byte, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("ed25519 private key size error")
+	}
+	return ed25519.Sign(key, src), nil
+}
+
+// Ed25519验签，验签失败返回error
+func Ed25519VerifyByte(src []byte, sign []byte, key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return errors.New("ed25519 public key size error")
+	}
+	if !ed25519.Verify(key, src, sign) {
+		return errors.New("ed25519 verification error")
+	}
+	return nil
+}
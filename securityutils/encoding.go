@@ -0,0 +1,90 @@
+package securityutils
+
+import (
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+//EncodingType 流式编码格式
+type EncodingType string
+
+const (
+	EncodingHex          EncodingType = "hex"
+	EncodingBase32       EncodingType = "base32"
+	EncodingBase64       EncodingType = "base64"
+	EncodingBase64URL    EncodingType = "base64url"
+	EncodingBase64RawURL EncodingType = "base64rawurl"
+	EncodingBase85       EncodingType = "base85"
+)
+
+//NewEncodeWriter 创建流式编码器，写入的数据编码后写入w，结束时必须Close以输出剩余数据
+//Base58等非分组编码无法流式处理，不在支持范围内
+func NewEncodeWriter(enc EncodingType, w io.Writer) (io.WriteCloser, error) {
+	switch enc {
+	case EncodingHex:
+		return nopWriteCloser{hex.NewEncoder(w)}, nil
+	case EncodingBase32:
+		return base32.NewEncoder(base32.StdEncoding, w), nil
+	case EncodingBase64:
+		return base64.NewEncoder(base64.StdEncoding, w), nil
+	case EncodingBase64URL:
+		return base64.NewEncoder(base64.URLEncoding, w), nil
+	case EncodingBase64RawURL:
+		return base64.NewEncoder(base64.RawURLEncoding, w), nil
+	case EncodingBase85:
+		return ascii85.NewEncoder(w), nil
+	}
+	return nil, errors.New("unsupported encoding " + string(enc))
+}
+
+//NewDecodeReader 创建流式解码器，从r读取编码数据并返回解码后的数据，base32/base64忽略换行符
+func NewDecodeReader(enc EncodingType, r io.Reader) (io.Reader, error) {
+	switch enc {
+	case EncodingHex:
+		return hex.NewDecoder(r), nil
+	case EncodingBase32:
+		return base32.NewDecoder(base32.StdEncoding, r), nil
+	case EncodingBase64:
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	case EncodingBase64URL:
+		return base64.NewDecoder(base64.URLEncoding, r), nil
+	case EncodingBase64RawURL:
+		return base64.NewDecoder(base64.RawURLEncoding, r), nil
+	case EncodingBase85:
+		return ascii85.NewDecoder(r), nil
+	}
+	return nil, errors.New("unsupported encoding " + string(enc))
+}
+
+//EncodeStream 将src编码后写入dst，返回读取的字节数
+func EncodeStream(dst io.Writer, src io.Reader, enc EncodingType) (int64, error) {
+	w, err := NewEncodeWriter(enc, dst)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, src)
+	if err != nil {
+		w.Close()
+		return n, err
+	}
+	return n, w.Close()
+}
+
+//DecodeStream 将src解码后写入dst，返回写入的字节数
+func DecodeStream(dst io.Writer, src io.Reader, enc EncodingType) (int64, error) {
+	r, err := NewDecodeReader(enc, src)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(dst, r)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,148 @@
+package securityutils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBaseEncodings(t *testing.T) {
+	// RFC 4648 §10
+	src := []byte("foobar")
+	if got := Base32EncodeToString(src); got != "MZXW6YTBOI======" {
+		t.Fatalf("base32: %s", got)
+	}
+	if got := Base32RawEncodeToString(src); got != "MZXW6YTBOI" {
+		t.Fatalf("base32 raw: %s", got)
+	}
+	if got := Base32HexEncodeToString(src); got != "CPNMUOJ1E8======" {
+		t.Fatalf("base32hex: %s", got)
+	}
+	for _, s := range []string{"MZXW6YTBOI======", "MZXW6YTBOI", "mzxw6ytboi"} {
+		if dst, err := Base32DecodeString(s); err != nil || !bytes.Equal(dst, src) {
+			t.Fatalf("base32 decode %q: %v", s, err)
+		}
+	}
+	if dst, err := Base32HexDecodeString("cpnmuoj1e8"); err != nil || !bytes.Equal(dst, src) {
+		t.Fatalf("base32hex decode: %v", err)
+	}
+	if _, err := Base32DecodeString("MZXW6YTBO1"); err == nil {
+		t.Fatal("invalid base32 was accepted")
+	}
+
+	if Base64URLEncodeToString([]byte{0xfb, 0xff}) != "-_8=" || Base64RawURLEncodeToString([]byte{0xfb, 0xff}) != "-_8" {
+		t.Fatal("base64url mismatch")
+	}
+	for _, s := range []string{"-_8=", "-_8"} {
+		if dst, err := Base64RawURLDecodeString(s); err != nil || !bytes.Equal(dst, []byte{0xfb, 0xff}) {
+			t.Fatalf("base64url decode %q: %v", s, err)
+		}
+	}
+	if Base64RawEncodeToString(src[:4]) != "Zm9vYg" {
+		t.Fatal("base64 raw mismatch")
+	}
+	if dst, err := Base64RawDecodeString("Zm9vYg=="); err != nil || string(dst) != "foob" {
+		t.Fatalf("base64 raw decode: %v", err)
+	}
+	if _, err := Base64DecodeStringErr("Zm9v!"); err == nil {
+		t.Fatal("invalid base64 was accepted")
+	}
+	if _, err := HexDecodeStringErr("0g"); err == nil {
+		t.Fatal("invalid hex was accepted")
+	}
+}
+
+func TestCrockford(t *testing.T) {
+	src := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0xff}
+	enc := CrockfordEncodeToString(src)
+	if strings.ContainsAny(enc, "ILOU=") {
+		t.Fatalf("unexpected characters in %s", enc)
+	}
+	// 小写、连字符以及易混淆字符按规范处理
+	alias := strings.NewReplacer("0", "o", "1", "l").Replace(strings.ToLower(enc))
+	for _, s := range []string{enc, strings.ToLower(enc), enc[:4] + "-" + enc[4:], alias} {
+		if dst, err := CrockfordDecodeString(s); err != nil || !bytes.Equal(dst, src) {
+			t.Fatalf("decode %q: %v", s, err)
+		}
+	}
+	if _, err := CrockfordDecodeString("U0"); err == nil {
+		t.Fatal("U is not part of the alphabet")
+	}
+}
+
+func TestBase58(t *testing.T) {
+	cases := []struct{ src, enc string }{
+		{"", ""},
+		{"Hello World!", "2NEpo7TZRRrLZSi2U"},
+		{"\x00\x00\x01", "112"},
+		{"\x00", "1"},
+	}
+	for _, c := range cases {
+		if got := Base58Encode([]byte(c.src)); got != c.enc {
+			t.Fatalf("Base58Encode(%q) = %s", c.src, got)
+		}
+		if dst, err := Base58Decode(c.enc); err != nil || string(dst) != c.src {
+			t.Fatalf("Base58Decode(%q): %q %v", c.enc, dst, err)
+		}
+	}
+	if _, err := Base58Decode("0OIl"); err == nil {
+		t.Fatal("invalid base58 was accepted")
+	}
+
+	// 比特币地址：版本0 + HASH160
+	hash160 := HexDecodeString("010966776006953d5567439e5e39f86a0d273bee")
+	addr := Base58CheckEncode(hash160, 0)
+	if addr != "16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM" {
+		t.Fatalf("Base58CheckEncode = %s", addr)
+	}
+	version, dst, err := Base58CheckDecode(addr)
+	if err != nil || version != 0 || !bytes.Equal(dst, hash160) {
+		t.Fatalf("Base58CheckDecode: %v", err)
+	}
+	bad := []byte(addr)
+	bad[5] = 'x'
+	if _, _, err := Base58CheckDecode(string(bad)); err == nil {
+		t.Fatal("checksum mismatch was accepted")
+	}
+}
+
+func TestBase85(t *testing.T) {
+	if got := Base85EncodeToString([]byte("Man ")); got != "9jqo^" {
+		t.Fatalf("Base85EncodeToString = %s", got)
+	}
+	for _, s := range []string{"9jqo^", "<~9jqo^~>", "9j qo\n^"} {
+		if dst, err := Base85DecodeString(s); err != nil || string(dst) != "Man " {
+			t.Fatalf("Base85DecodeString(%q): %q %v", s, dst, err)
+		}
+	}
+	if _, err := Base85DecodeString("9jqo{"); err == nil {
+		t.Fatal("invalid base85 was accepted")
+	}
+}
+
+func TestEncodeStream(t *testing.T) {
+	src := bytes.Repeat([]byte("stream encoding \x00\xff"), 5000)
+	for _, enc := range []EncodingType{EncodingHex, EncodingBase32, EncodingBase64, EncodingBase64URL, EncodingBase64RawURL, EncodingBase85} {
+		var encoded bytes.Buffer
+		n, err := EncodeStream(&encoded, bytes.NewReader(src), enc)
+		if err != nil || n != int64(len(src)) {
+			t.Fatalf("%s: encode %d %v", enc, n, err)
+		}
+		var decoded bytes.Buffer
+		if _, err := DecodeStream(&decoded, &encoded, enc); err != nil || !bytes.Equal(decoded.Bytes(), src) {
+			t.Fatalf("%s: decode %v", enc, err)
+		}
+	}
+	// 流式结果与一次性编码一致
+	var buf bytes.Buffer
+	EncodeStream(&buf, bytes.NewReader(src), EncodingBase64)
+	if buf.String() != Base64EncodeToString(src) {
+		t.Fatal("stream base64 differs from Base64EncodeToString")
+	}
+	if _, err := NewEncodeWriter("base58", &buf); err == nil {
+		t.Fatal("base58 stream should be unsupported")
+	}
+	if _, err := DecodeStream(&buf, strings.NewReader("zz"), EncodingHex); err == nil {
+		t.Fatal("invalid hex stream was accepted")
+	}
+}
@@ -0,0 +1,214 @@
+package securityutils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/blake3"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+//HashAlgorithm 摘要算法名称，不区分大小写
+type HashAlgorithm string
+
+const (
+	HashMd5        HashAlgorithm = "md5"
+	HashSha1       HashAlgorithm = "sha1"
+	HashSha256     HashAlgorithm = "sha256"
+	HashSha384     HashAlgorithm = "sha384"
+	HashSha512     HashAlgorithm = "sha512"
+	HashSha3_256   HashAlgorithm = "sha3-256"
+	HashSha3_512   HashAlgorithm = "sha3-512"
+	HashBlake2b256 HashAlgorithm = "blake2b-256"
+	HashBlake2b512 HashAlgorithm = "blake2b-512"
+	HashBlake3     HashAlgorithm = "blake3"
+	HashSm3        HashAlgorithm = "sm3"
+	HashCrc32      HashAlgorithm = "crc32"  //IEEE多项式，非密码学摘要
+	HashCrc32c     HashAlgorithm = "crc32c" //Castagnoli多项式，非密码学摘要
+	HashXxHash64   HashAlgorithm = "xxhash64"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+//NewHash 根据算法名称创建hash.Hash
+func NewHash(alg HashAlgorithm) (hash.Hash, error) {
+	switch HashAlgorithm(strings.ToLower(string(alg))) {
+	case HashMd5:
+		return md5.New(), nil
+	case HashSha1:
+		return sha1.New(), nil
+	case HashSha256:
+		return sha256.New(), nil
+	case HashSha384:
+		return sha512.New384(), nil
+	case HashSha512:
+		return sha512.New(), nil
+	case HashSha3_256:
+		return sha3.New256(), nil
+	case HashSha3_512:
+		return sha3.New512(), nil
+	case HashBlake2b256:
+		return blake2b.New256(nil)
+	case HashBlake2b512:
+		return blake2b.New512(nil)
+	case HashBlake3:
+		return blake3.New(), nil
+	case HashSm3:
+		return NewSm3(), nil
+	case HashCrc32:
+		return crc32.NewIEEE(), nil
+	case HashCrc32c:
+		return crc32.New(crc32cTable), nil
+	case HashXxHash64:
+		return xxhash.New(), nil
+	}
+	return nil, errors.New("unsupported hash algorithm " + string(alg))
+}
+
+//HashByte 计算src的摘要
+func HashByte(alg HashAlgorithm, src []byte) ([]byte, error) {
+	hash, err := NewHash(alg)
+	if err != nil {
+		return nil, err
+	}
+	hash.Write(src)
+	return hash.Sum(nil), nil
+}
+
+//Hash 计算src的摘要，返回十六进制字符串
+func Hash(alg HashAlgorithm, src string) (string, error) {
+	sum, err := HashByte(alg, []byte(src))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+//HashReaderByte 流式读取r直到EOF并计算摘要
+func HashReaderByte(alg HashAlgorithm, r io.Reader) ([]byte, error) {
+	hash, err := NewHash(alg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(hash, r); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+//HashReader 流式读取r直到EOF并计算摘要，返回十六进制字符串
+func HashReader(alg HashAlgorithm, r io.Reader) (string, error) {
+	sum, err := HashReaderByte(alg, r)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+//HashFileByte 流式计算文件摘要，不会将整个文件读入内存
+func HashFileByte(alg HashAlgorithm, filePath string) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return HashReaderByte(alg, f)
+}
+
+//HashFile 流式计算文件摘要，返回十六进制字符串
+func HashFile(alg HashAlgorithm, filePath string) (string, error) {
+	sum, err := HashFileByte(alg, filePath)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// =================== 多摘要 ======================
+//MultiHasher 一次读取同时计算多种摘要，实现io.Writer
+type MultiHasher struct {
+	algs   []HashAlgorithm
+	hashes []hash.Hash
+	w      io.Writer
+}
+
+//NewMultiHasher 创建多摘要计算器，algs不能为空
+func NewMultiHasher(algs ...HashAlgorithm) (*MultiHasher, error) {
+	if len(algs) == 0 {
+		return nil, errors.New("hash algorithms can not be empty")
+	}
+	m := &MultiHasher{algs: algs, hashes: make([]hash.Hash, len(algs))}
+	writers := make([]io.Writer, len(algs))
+	for i, alg := range algs {
+		hash, err := NewHash(alg)
+		if err != nil {
+			return nil, err
+		}
+		m.hashes[i] = hash
+		writers[i] = hash
+	}
+	m.w = io.MultiWriter(writers...)
+	return m, nil
+}
+
+func (m *MultiHasher) Write(p []byte) (int, error) {
+	return m.w.Write(p)
+}
+
+//Reset 重置所有摘要状态
+func (m *MultiHasher) Reset() {
+	for _, hash := range m.hashes {
+		hash.Reset()
+	}
+}
+
+//Sum 返回各算法的摘要，key为创建时传入的算法名称
+func (m *MultiHasher) Sum() map[HashAlgorithm][]byte {
+	sums := make(map[HashAlgorithm][]byte, len(m.algs))
+	for i, alg := range m.algs {
+		sums[alg] = m.hashes[i].Sum(nil)
+	}
+	return sums
+}
+
+//SumHex 返回各算法的十六进制摘要，key为创建时传入的算法名称
+func (m *MultiHasher) SumHex() map[HashAlgorithm]string {
+	sums := make(map[HashAlgorithm]string, len(m.algs))
+	for i, alg := range m.algs {
+		sums[alg] = hex.EncodeToString(m.hashes[i].Sum(nil))
+	}
+	return sums
+}
+
+//MultiHashReader 读取一次r同时计算多种摘要，返回十六进制字符串
+func MultiHashReader(r io.Reader, algs ...HashAlgorithm) (map[HashAlgorithm]string, error) {
+	m, err := NewMultiHasher(algs...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(m, r); err != nil {
+		return nil, err
+	}
+	return m.SumHex(), nil
+}
+
+//MultiHashFile 读取一次文件同时计算多种摘要，返回十六进制字符串
+func MultiHashFile(filePath string, algs ...HashAlgorithm) (map[HashAlgorithm]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return MultiHashReader(f, algs...)
+}
@@ -0,0 +1,105 @@
+package securityutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var hashVectors = []struct {
+	alg  HashAlgorithm
+	src  string
+	want string
+}{
+	{HashMd5, "abc", "900150983cd24fb0d6963f7d28e17f72"},
+	{HashSha1, "abc", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+	{HashSha256, "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	{HashSha384, "abc", "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7"},
+	{HashSha512, "abc", "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+	{HashSha3_256, "abc", "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+	{HashSha3_512, "abc", "b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0"},
+	{HashBlake2b256, "abc", "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319"},
+	{HashBlake2b512, "abc", "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+	{HashBlake3, "", "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+	{HashSm3, "abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+	{HashCrc32, "123456789", "cbf43926"},
+	{HashCrc32c, "123456789", "e3069283"},
+	{HashXxHash64, "", "ef46db3751d8e999"},
+}
+
+func TestHashVectors(t *testing.T) {
+	for _, v := range hashVectors {
+		got, err := Hash(v.alg, v.src)
+		if err != nil {
+			t.Fatalf("%s: %v", v.alg, err)
+		}
+		if got != v.want {
+			t.Fatalf("%s(%q) = %s", v.alg, v.src, got)
+		}
+		// 算法名称不区分大小写
+		if upper, _ := Hash(HashAlgorithm(strings.ToUpper(string(v.alg))), v.src); upper != v.want {
+			t.Fatalf("%s: upper case name mismatch", v.alg)
+		}
+	}
+	if Sha512("abc") != hashVectors[4].want {
+		t.Fatal("Sha512 mismatch")
+	}
+	if _, err := NewHash("md4"); err == nil {
+		t.Fatal("unsupported algorithm was accepted")
+	}
+}
+
+func TestHashReaderAndFile(t *testing.T) {
+	src := strings.Repeat("streaming hasher ", 10000)
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range hashVectors {
+		want, _ := Hash(v.alg, src)
+		if got, err := HashReader(v.alg, strings.NewReader(src)); err != nil || got != want {
+			t.Fatalf("%s: HashReader mismatch: %v", v.alg, err)
+		}
+		if got, err := HashFile(v.alg, path); err != nil || got != want {
+			t.Fatalf("%s: HashFile mismatch: %v", v.alg, err)
+		}
+	}
+	if _, err := HashFile(HashSha256, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("missing file should return error")
+	}
+}
+
+func TestMultiHasher(t *testing.T) {
+	algs := []HashAlgorithm{HashMd5, HashSha256, HashBlake3, HashCrc32}
+	m, err := NewMultiHasher(algs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Write([]byte("12345"))
+	m.Write([]byte("6789"))
+	sums := m.SumHex()
+	for _, alg := range algs {
+		want, _ := Hash(alg, "123456789")
+		if sums[alg] != want {
+			t.Fatalf("%s: multi hash mismatch", alg)
+		}
+	}
+	m.Reset()
+	if m.SumHex()[HashCrc32] != "00000000" {
+		t.Fatal("Reset should clear all hashes")
+	}
+
+	path := filepath.Join(t.TempDir(), "data")
+	os.WriteFile(path, []byte("123456789"), 0644)
+	fileSums, err := MultiHashFile(path, algs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileSums) != len(algs) || fileSums[HashSha256] != sums[HashSha256] {
+		t.Fatalf("unexpected file sums %v", fileSums)
+	}
+	if _, err := NewMultiHasher(HashSha256, "md4"); err == nil {
+		t.Fatal("unsupported algorithm was accepted")
+	}
+}
@@ -34,3 +34,18 @@ func HexDecodeString(src string) []byte {
 	}
 	return dst
 }
+
+//Hex解密，解码失败返回error
+func HexDecodeErr(src []byte) ([]byte, error) {
+	dst := make([]byte, hex.DecodedLen(len(src)))
+	n, err := hex.Decode(dst, src)
+	if err != nil {
+		return nil, err
+	}
+	return dst[:n], nil
+}
+
+//Hex解密，解码失败返回error
+func HexDecodeStringErr(src string) ([]byte, error) {
+	return hex.DecodeString(src)
+}
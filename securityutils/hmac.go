@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"fmt"
 )
 
@@ -49,3 +50,24 @@ func HmacSha256Byte(src []byte, key []byte) []byte {
 	hash.Write(src)
 	return hash.Sum(nil)
 }
+
+//hmac-sha512单向秘钥key加密 128位
+func HmacSha512(src string, key string) string {
+	hash := hmac.New(sha512.New, []byte(key))
+	hash.Write([]byte(src))
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+//hmac-sha512单向秘钥key加密
+func HmacSha512Byte(src []byte, key []byte) []byte {
+	hash := hmac.New(sha512.New, key)
+	hash.Write(src)
+	return hash.Sum(nil)
+}
+
+//hmac-sha384单向秘钥key加密
+func HmacSha384Byte(src []byte, key []byte) []byte {
+	hash := hmac.New(sha512.New384, key)
+	hash.Write(src)
+	return hash.Sum(nil)
+}
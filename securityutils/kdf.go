@@ -0,0 +1,70 @@
+package securityutils
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+//DefaultPbkdf2Iterations PBKDF2默认迭代次数（OWASP 2023对PBKDF2-HMAC-SHA256的建议值）
+const DefaultPbkdf2Iterations = 600000
+
+//HkdfSha256 HKDF-SHA256密钥派生，适用于由高熵密钥派生子密钥
+//salt、info为非必需参数，可传nil；length为派生出的密钥长度
+func HkdfSha256(secret []byte, salt []byte, info []byte, length int) ([]byte, error) {
+	return hkdfDerive(sha256.New, secret, salt, info, length)
+}
+
+//HkdfSha512 HKDF-SHA512密钥派生
+func HkdfSha512(secret []byte, salt []byte, info []byte, length int) ([]byte, error) {
+	return hkdfDerive(sha512.New, secret, salt, info, length)
+}
+
+func hkdfDerive(h func() hash.Hash, secret []byte, salt []byte, info []byte, length int) ([]byte, error) {
+	if length <= 0 {
+		return nil, errors.New("key length must be positive")
+	}
+	dst := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(h, secret, salt, info), dst); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+//Pbkdf2Sha1 PBKDF2-HMAC-SHA1密钥派生，仅用于兼容WinZip AES等既有格式，新场景请使用Pbkdf2Sha256
+func Pbkdf2Sha1(password []byte, salt []byte, iterations int, keyLen int) []byte {
+	return pbkdf2.Key(password, salt, iterations, keyLen, sha1.New)
+}
+
+//Pbkdf2Sha256 PBKDF2-HMAC-SHA256密钥派生，适用于由口令派生密钥
+func Pbkdf2Sha256(password []byte, salt []byte, iterations int, keyLen int) []byte {
+	return pbkdf2.Key(password, salt, iterations, keyLen, sha256.New)
+}
+
+//Pbkdf2Sha512 PBKDF2-HMAC-SHA512密钥派生
+func Pbkdf2Sha512(password []byte, salt []byte, iterations int, keyLen int) []byte {
+	return pbkdf2.Key(password, salt, iterations, keyLen, sha512.New)
+}
+
+//DeriveKey 由口令派生指定长度的密钥，可直接传给Aes*、Des*等加解密函数
+//AES密钥长度为16/24/32，DES为8，3DES为24；salt应随机生成并与密文一起保存
+//iterations 迭代次数为非必需参数，默认DefaultPbkdf2Iterations
+func DeriveKey(password string, salt []byte, keyLen int, iterations ...int) ([]byte, error) {
+	if len(salt) == 0 {
+		return nil, errors.New("salt can not be empty")
+	}
+	if keyLen <= 0 {
+		return nil, errors.New("key length must be positive")
+	}
+	iter := DefaultPbkdf2Iterations
+	if len(iterations) > 0 && iterations[0] > 0 {
+		iter = iterations[0]
+	}
+	return Pbkdf2Sha256([]byte(password), salt, iter, keyLen), nil
+}
@@ -0,0 +1,308 @@
+package securityutils
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/youngchan1988/gocommon/syncutils"
+)
+
+// 信封加密密文格式（版本1）：
+// | version(1) | keyVersion(4, 大端) | AES-256-GCM密文(AeadEncrypt格式) |
+// version与keyVersion作为附加数据参与认证，篡改后解密失败
+const (
+	EnvelopeVersion1 byte = 1
+
+	envelopeHeaderSize = 5
+	// MasterKeySize 主密钥与数据密钥长度
+	MasterKeySize = 32
+)
+
+var (
+	ErrKeyVersionNotFound = errors.New("keyring: key version not found")
+	ErrWrongMasterKey     = errors.New("keyring: master key is wrong or key data is corrupted")
+)
+
+//Keyring 数据密钥环：数据密钥(DEK)按版本保存，持久化时使用主密钥(KEK)包裹
+//加密始终使用当前版本，解密按密文中记录的版本选择密钥，轮换后旧数据仍可解密
+type Keyring struct {
+	mu        *syncutils.RWMutex
+	masterKey []byte
+	keys      map[uint32]*dataKey
+	current   uint32
+}
+
+type dataKey struct {
+	version uint32
+	key     []byte
+	created time.Time
+}
+
+//KeyInfo 数据密钥信息
+type KeyInfo struct {
+	Version uint32
+	Created time.Time
+	Current bool
+}
+
+type keyringJSON struct {
+	Current uint32        `json:"current"`
+	Keys    []wrappedJSON `json:"keys"`
+}
+
+type wrappedJSON struct {
+	Version uint32    `json:"version"`
+	Created time.Time `json:"created"`
+	Key     []byte    `json:"key"` //主密钥包裹后的数据密钥
+}
+
+//NewKeyring 创建空密钥环，masterKey必须为32位长度，需调用Rotate生成第一个数据密钥
+func NewKeyring(masterKey []byte) (*Keyring, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, errors.New("keyring: master key must be " + strconv.Itoa(MasterKeySize) + " bytes")
+	}
+	return &Keyring{
+		mu:        syncutils.New(),
+		masterKey: append([]byte(nil), masterKey...),
+		keys:      make(map[uint32]*dataKey),
+	}, nil
+}
+
+//LoadKeyring 从Export导出的数据恢复密钥环，主密钥错误时返回ErrWrongMasterKey
+func LoadKeyring(data []byte, masterKey []byte) (*Keyring, error) {
+	k, err := NewKeyring(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	var kj keyringJSON
+	if err := json.Unmarshal(data, &kj); err != nil {
+		return nil, err
+	}
+	for _, w := range kj.Keys {
+		key, err := AesDecryptGCM(w.Key, k.masterKey, keyVersionAD(w.Version))
+		if err != nil {
+			return nil, ErrWrongMasterKey
+		}
+		k.keys[w.Version] = &dataKey{version: w.Version, key: key, created: w.Created}
+	}
+	if _, ok := k.keys[kj.Current]; !ok && len(k.keys) > 0 {
+		return nil, errors.New("keyring: current key version is missing")
+	}
+	k.current = kj.Current
+	return k, nil
+}
+
+//Export 导出密钥环，数据密钥均经主密钥包裹，可直接持久化
+func (k *Keyring) Export() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.export(k.masterKey)
+}
+
+func (k *Keyring) export(masterKey []byte) ([]byte, error) {
+	kj := keyringJSON{Current: k.current, Keys: make([]wrappedJSON, 0, len(k.keys))}
+	for _, v := range k.sortedVersions() {
+		dk := k.keys[v]
+		wrapped, err := AesEncryptGCM(dk.key, masterKey, keyVersionAD(v))
+		if err != nil {
+			return nil, err
+		}
+		kj.Keys = append(kj.Keys, wrappedJSON{Version: v, Created: dk.created, Key: wrapped})
+	}
+	return json.MarshalIndent(kj, "", "  ")
+}
+
+//Rotate 生成新版本的数据密钥并设为当前密钥，返回新版本号
+func (k *Keyring) Rotate() (uint32, error) {
+	key, err := RandomBytes(MasterKeySize)
+	if err != nil {
+		return 0, err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	version := k.current + 1
+	for _, ok := k.keys[version]; ok; _, ok = k.keys[version] {
+		version++
+	}
+	k.keys[version] = &dataKey{version: version, key: key, created: time.Now()}
+	k.current = version
+	return version, nil
+}
+
+//SetCurrent 将已有版本设为当前加密密钥，可用于回滚轮换
+func (k *Keyring) SetCurrent(version uint32) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[version]; !ok {
+		return ErrKeyVersionNotFound
+	}
+	k.current = version
+	return nil
+}
+
+//Remove 删除旧版本数据密钥，删除后该版本加密的数据无法解密，不允许删除当前密钥
+func (k *Keyring) Remove(version uint32) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if version == k.current {
+		return errors.New("keyring: can not remove current key")
+	}
+	if _, ok := k.keys[version]; !ok {
+		return ErrKeyVersionNotFound
+	}
+	delete(k.keys, version)
+	return nil
+}
+
+//Current 当前加密使用的密钥版本，0表示尚未生成密钥
+func (k *Keyring) Current() uint32 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+//Keys 所有数据密钥信息，按版本升序
+func (k *Keyring) Keys() []KeyInfo {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	infos := make([]KeyInfo, 0, len(k.keys))
+	for _, v := range k.sortedVersions() {
+		infos = append(infos, KeyInfo{Version: v, Created: k.keys[v].created, Current: v == k.current})
+	}
+	return infos
+}
+
+// 复制密钥环，dataKey创建后不再修改，可在副本间共享
+func (k *Keyring) clone() *Keyring {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	c := &Keyring{mu: syncutils.New(), masterKey: k.masterKey, keys: make(map[uint32]*dataKey, len(k.keys)), current: k.current}
+	for v, dk := range k.keys {
+		c.keys[v] = dk
+	}
+	return c
+}
+
+// 用c中的数据密钥替换当前密钥，主密钥不变
+func (k *Keyring) replace(c *Keyring) {
+	k.mu.Lock()
+	k.keys, k.current = c.keys, c.current
+	k.mu.Unlock()
+}
+
+//ChangeMasterKey 更换主密钥，数据密钥不变，已加密的数据无需重新加密，更换后需重新Export持久化
+func (k *Keyring) ChangeMasterKey(masterKey []byte) error {
+	if len(masterKey) != MasterKeySize {
+		return errors.New("keyring: master key must be " + strconv.Itoa(MasterKeySize) + " bytes")
+	}
+	k.mu.Lock()
+	k.masterKey = append([]byte(nil), masterKey...)
+	k.mu.Unlock()
+	return nil
+}
+
+//Encrypt 使用当前数据密钥加密，密文中记录密钥版本，additionalData为非必需参数，解密时必须一致
+func (k *Keyring) Encrypt(src []byte, additionalData ...[]byte) ([]byte, error) {
+	k.mu.RLock()
+	dk, ok := k.keys[k.current]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, errors.New("keyring: no data key, call Rotate first")
+	}
+	header := envelopeHeader(dk.version)
+	body, err := AesEncryptGCM(src, dk.key, envelopeAD(header, additionalData))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+//Decrypt 按密文中记录的版本选择数据密钥解密
+func (k *Keyring) Decrypt(src []byte, additionalData ...[]byte) ([]byte, error) {
+	version, err := EnvelopeKeyVersion(src)
+	if err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	dk, ok := k.keys[version]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, ErrKeyVersionNotFound
+	}
+	return AesDecryptGCM(src[envelopeHeaderSize:], dk.key, envelopeAD(src[:envelopeHeaderSize], additionalData))
+}
+
+//ReEncrypt 使用当前数据密钥重新加密，密钥轮换后用于迁移旧数据
+func (k *Keyring) ReEncrypt(src []byte, additionalData ...[]byte) ([]byte, error) {
+	dst, err := k.Decrypt(src, additionalData...)
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(dst, additionalData...)
+}
+
+//EncryptString 加密字符串，返回base64编码的密文
+func (k *Keyring) EncryptString(src string, additionalData ...[]byte) (string, error) {
+	dst, err := k.Encrypt([]byte(src), additionalData...)
+	if err != nil {
+		return "", err
+	}
+	return Base64EncodeToString(dst), nil
+}
+
+//DecryptString 解密base64编码的密文
+func (k *Keyring) DecryptString(src string, additionalData ...[]byte) (string, error) {
+	srcByte, err := Base64DecodeStringErr(src)
+	if err != nil {
+		return "", err
+	}
+	dst, err := k.Decrypt(srcByte, additionalData...)
+	if err != nil {
+		return "", err
+	}
+	return string(dst), nil
+}
+
+//EnvelopeKeyVersion 读取信封密文使用的数据密钥版本
+func EnvelopeKeyVersion(src []byte) (uint32, error) {
+	if len(src) < envelopeHeaderSize {
+		return 0, errors.New("src is too short, less than header size")
+	}
+	if src[0] != EnvelopeVersion1 {
+		return 0, errors.New("unsupported envelope version")
+	}
+	return binary.BigEndian.Uint32(src[1:envelopeHeaderSize]), nil
+}
+
+func (k *Keyring) sortedVersions() []uint32 {
+	versions := make([]uint32, 0, len(k.keys))
+	for v := range k.keys {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+func envelopeHeader(version uint32) []byte {
+	header := make([]byte, envelopeHeaderSize, envelopeHeaderSize+64)
+	header[0] = EnvelopeVersion1
+	binary.BigEndian.PutUint32(header[1:], version)
+	return header
+}
+
+func envelopeAD(header []byte, additionalData [][]byte) []byte {
+	ad := append([]byte(nil), header...)
+	if len(additionalData) > 0 {
+		ad = append(ad, additionalData[0]...)
+	}
+	return ad
+}
+
+// 包裹数据密钥时绑定版本号，防止不同版本的包裹密钥被互换
+func keyVersionAD(version uint32) []byte {
+	return []byte("gocommon keyring v" + strconv.FormatUint(uint64(version), 10))
+}
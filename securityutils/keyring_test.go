@@ -0,0 +1,120 @@
+package securityutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestKeyringRotate(t *testing.T) {
+	master := bytes.Repeat([]byte{1}, MasterKeySize)
+	k, err := NewKeyring(master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Encrypt([]byte("x")); err == nil {
+		t.Fatal("encrypt without data key should fail")
+	}
+	v1, _ := k.Rotate()
+	old, err := k.Encrypt([]byte("old data"), []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, _ := k.Rotate()
+	if v2 != v1+1 || k.Current() != v2 {
+		t.Fatalf("unexpected versions %d %d", v1, v2)
+	}
+	// 轮换后旧数据仍可解密，新数据使用新版本
+	if pt, err := k.Decrypt(old, []byte("ad")); err != nil || string(pt) != "old data" {
+		t.Fatalf("decrypt old data: %v", err)
+	}
+	if _, err := k.Decrypt(old, []byte("other")); err == nil {
+		t.Fatal("additional data mismatch was accepted")
+	}
+	migrated, err := k.ReEncrypt(old, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := EnvelopeKeyVersion(migrated); v != v2 {
+		t.Fatalf("ReEncrypt used version %d", v)
+	}
+	// 篡改头部中的版本号
+	forged := append([]byte{}, migrated...)
+	forged[4] = byte(v1)
+	if _, err := k.Decrypt(forged, []byte("ad")); err == nil {
+		t.Fatal("forged key version was accepted")
+	}
+
+	if err := k.Remove(v2); err == nil {
+		t.Fatal("current key was removed")
+	}
+	if err := k.Remove(v1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Decrypt(old, []byte("ad")); err != ErrKeyVersionNotFound {
+		t.Fatalf("expected ErrKeyVersionNotFound, got %v", err)
+	}
+	if err := k.SetCurrent(v1); err != ErrKeyVersionNotFound {
+		t.Fatalf("expected ErrKeyVersionNotFound, got %v", err)
+	}
+	if keys := k.Keys(); len(keys) != 1 || keys[0].Version != v2 || !keys[0].Current {
+		t.Fatalf("unexpected keys %+v", keys)
+	}
+	s, err := k.EncryptString("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := k.DecryptString(s); err != nil || pt != "hello" {
+		t.Fatalf("DecryptString: %v", err)
+	}
+}
+
+func TestKeyringExport(t *testing.T) {
+	master := bytes.Repeat([]byte{1}, MasterKeySize)
+	k, _ := NewKeyring(master)
+	k.Rotate()
+	k.Rotate()
+	ct, _ := k.Encrypt([]byte("data"))
+	data, err := k.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 导出数据中不包含明文数据密钥
+	for _, dk := range k.keys {
+		if bytes.Contains(data, []byte(Base64EncodeToString(dk.key))) {
+			t.Fatal("exported keyring contains plaintext data key")
+		}
+	}
+	loaded, err := LoadKeyring(data, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := loaded.Decrypt(ct); err != nil || string(pt) != "data" || loaded.Current() != k.Current() {
+		t.Fatalf("loaded keyring: %v", err)
+	}
+	if _, err := LoadKeyring(data, bytes.Repeat([]byte{2}, MasterKeySize)); err != ErrWrongMasterKey {
+		t.Fatalf("expected ErrWrongMasterKey, got %v", err)
+	}
+	// 交换两个版本的包裹密钥
+	var kj keyringJSON
+	json.Unmarshal(data, &kj)
+	kj.Keys[0].Key, kj.Keys[1].Key = kj.Keys[1].Key, kj.Keys[0].Key
+	swapped, _ := json.Marshal(kj)
+	if _, err := LoadKeyring(swapped, master); err != ErrWrongMasterKey {
+		t.Fatalf("swapped wrapped keys: %v", err)
+	}
+
+	newMaster := bytes.Repeat([]byte{3}, MasterKeySize)
+	if err := k.ChangeMasterKey(newMaster); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = k.Export()
+	if loaded, err := LoadKeyring(data, newMaster); err != nil {
+		t.Fatal(err)
+	} else if pt, _ := loaded.Decrypt(ct); string(pt) != "data" {
+		t.Fatal("data key changed with master key")
+	}
+	if _, err := NewKeyring(master[:16]); err == nil {
+		t.Fatal("16 byte master key was accepted")
+	}
+}
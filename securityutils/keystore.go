@@ -0,0 +1,229 @@
+package securityutils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// 密钥库文件格式（JSON）：
+// 主密钥由口令经PBKDF2-SHA256派生，不落盘；文件中只保存盐、迭代次数以及被主密钥包裹的数据密钥
+const keystoreVersion1 = 1
+
+//Keystore 基于文件的密钥库，由口令保护，内部为Keyring
+//不直接暴露Keyring：主密钥只能通过ChangePassphrase更换，保证盐与主密钥一致
+type Keystore struct {
+	mu         sync.Mutex //保护salt，串行化密钥修改与文件写入
+	keyring    *Keyring
+	path       string
+	salt       []byte
+	iterations int
+}
+
+type keystoreJSON struct {
+	Version    int             `json:"version"`
+	Kdf        string          `json:"kdf"`
+	Iterations int             `json:"iterations"`
+	Salt       []byte          `json:"salt"`
+	Keyring    json.RawMessage `json:"keyring"`
+}
+
+//OpenKeystore 打开密钥库文件，文件不存在时创建并生成第一个数据密钥
+//iterations 为非必需参数，仅创建时生效，默认DefaultPbkdf2Iterations
+//口令错误时返回ErrWrongMasterKey
+func OpenKeystore(path string, passphrase string, iterations ...int) (*Keystore, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return createKeystore(path, passphrase, iterations)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var kj keystoreJSON
+	if err := json.Unmarshal(data, &kj); err != nil {
+		return nil, errors.New("keystore: file format error")
+	}
+	if kj.Version != keystoreVersion1 || kj.Kdf != string(PasswordPbkdf2Sha256) {
+		return nil, errors.New("keystore: unsupported version or kdf")
+	}
+	// 迭代次数与盐由创建时写入，缺失说明文件已损坏，不能回退到默认值
+	if kj.Iterations <= 0 || len(kj.Salt) == 0 {
+		return nil, errors.New("keystore: file format error")
+	}
+	masterKey, err := DeriveKey(passphrase, kj.Salt, MasterKeySize, kj.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := LoadKeyring(kj.Keyring, masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Keystore{keyring: keyring, path: path, salt: kj.Salt, iterations: kj.Iterations}, nil
+}
+
+func createKeystore(path string, passphrase string, iterations []int) (*Keystore, error) {
+	iter := DefaultPbkdf2Iterations
+	if len(iterations) > 0 && iterations[0] > 0 {
+		iter = iterations[0]
+	}
+	salt, err := RandomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := DeriveKey(passphrase, salt, MasterKeySize, iter)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := NewKeyring(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := keyring.Rotate(); err != nil {
+		return nil, err
+	}
+	ks := &Keystore{keyring: keyring, path: path, salt: salt, iterations: iter}
+	if err := ks.Save(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+//Path 密钥库文件路径
+func (ks *Keystore) Path() string {
+	return ks.path
+}
+
+//Save 保存密钥库，先写临时文件再重命名，文件权限为0600
+func (ks *Keystore) Save() error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	return ks.save(ks.keyring, nil, ks.salt)
+}
+
+// 保存keyring，masterKey为nil时使用keyring的主密钥
+func (ks *Keystore) save(keyring *Keyring, masterKey []byte, salt []byte) error {
+	keyring.mu.RLock()
+	if masterKey == nil {
+		masterKey = keyring.masterKey
+	}
+	exported, err := keyring.export(masterKey)
+	keyring.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keystoreJSON{
+		Version:    keystoreVersion1,
+		Kdf:        string(PasswordPbkdf2Sha256),
+		Iterations: ks.iterations,
+		Salt:       salt,
+		Keyring:    exported,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileFrom(ks.path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// 在密钥环副本上修改并保存，文件保存成功后才替换内存中的密钥环，
+// 保存失败时密钥库保持不变，不会用未落盘的数据密钥加密
+func (ks *Keystore) update(modify func(k *Keyring) error) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	next := ks.keyring.clone()
+	if err := modify(next); err != nil {
+		return err
+	}
+	if err := ks.save(next, nil, ks.salt); err != nil {
+		return err
+	}
+	ks.keyring.replace(next)
+	return nil
+}
+
+//Rotate 生成新版本数据密钥并保存，保存失败时不切换当前密钥
+func (ks *Keystore) Rotate() (uint32, error) {
+	var version uint32
+	err := ks.update(func(k *Keyring) (err error) {
+		version, err = k.Rotate()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+//Remove 删除旧版本数据密钥并保存，保存失败时不删除
+func (ks *Keystore) Remove(version uint32) error {
+	return ks.update(func(k *Keyring) error {
+		return k.Remove(version)
+	})
+}
+
+//SetCurrent 设置当前加密密钥并保存，保存失败时不切换
+func (ks *Keystore) SetCurrent(version uint32) error {
+	return ks.update(func(k *Keyring) error {
+		return k.SetCurrent(version)
+	})
+}
+
+//ChangePassphrase 更换口令，重新生成盐并用新主密钥包裹数据密钥，已加密的数据无需重新加密
+//文件保存成功后才切换内存中的主密钥，保存失败时密钥库保持原口令
+func (ks *Keystore) ChangePassphrase(passphrase string) error {
+	salt, err := RandomBytes(16)
+	if err != nil {
+		return err
+	}
+	masterKey, err := DeriveKey(passphrase, salt, MasterKeySize, ks.iterations)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := ks.save(ks.keyring, masterKey, salt); err != nil {
+		return err
+	}
+	ks.salt = salt
+	return ks.keyring.ChangeMasterKey(masterKey)
+}
+
+//Current 当前加密使用的密钥版本
+func (ks *Keystore) Current() uint32 {
+	return ks.keyring.Current()
+}
+
+//Keys 所有数据密钥信息，按版本升序
+func (ks *Keystore) Keys() []KeyInfo {
+	return ks.keyring.Keys()
+}
+
+//Encrypt 使用当前数据密钥加密，见Keyring.Encrypt
+func (ks *Keystore) Encrypt(src []byte, additionalData ...[]byte) ([]byte, error) {
+	return ks.keyring.Encrypt(src, additionalData...)
+}
+
+//Decrypt 按密文中记录的版本选择数据密钥解密，见Keyring.Decrypt
+func (ks *Keystore) Decrypt(src []byte, additionalData ...[]byte) ([]byte, error) {
+	return ks.keyring.Decrypt(src, additionalData...)
+}
+
+//ReEncrypt 使用当前数据密钥重新加密，见Keyring.ReEncrypt
+func (ks *Keystore) ReEncrypt(src []byte, additionalData ...[]byte) ([]byte, error) {
+	return ks.keyring.ReEncrypt(src, additionalData...)
+}
+
+//EncryptString 加密字符串，返回base64编码的密文
+func (ks *Keystore) EncryptString(src string, additionalData ...[]byte) (string, error) {
+	return ks.keyring.EncryptString(src, additionalData...)
+}
+
+//DecryptString 解密base64编码的密文
+func (ks *Keystore) DecryptString(src string, additionalData ...[]byte) (string, error) {
+	return ks.keyring.DecryptString(src, additionalData...)
+}
@@ -0,0 +1,146 @@
+package securityutils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "keystore.json")
+	ks, err := OpenKeystore(path, "passphrase", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("keystore file: %v", err)
+	}
+	ct, err := ks.EncryptString("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := ks.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenKeystore(path, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Current() != v2 || len(reopened.Keys()) != 2 {
+		t.Fatalf("rotation was not saved: %+v", reopened.Keys())
+	}
+	if pt, err := reopened.DecryptString(ct); err != nil || pt != "secret" {
+		t.Fatalf("DecryptString: %v", err)
+	}
+	if _, err := OpenKeystore(path, "wrong"); err != ErrWrongMasterKey {
+		t.Fatalf("expected ErrWrongMasterKey, got %v", err)
+	}
+
+	// Keystore不暴露ChangeMasterKey，只能通过ChangePassphrase更换
+	if _, ok := interface{}(ks).(interface{ ChangeMasterKey([]byte) error }); ok {
+		t.Fatal("Keystore must not expose ChangeMasterKey")
+	}
+	if err := ks.ChangePassphrase("new passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenKeystore(path, "passphrase"); err != ErrWrongMasterKey {
+		t.Fatalf("old passphrase: %v", err)
+	}
+	reopened, err = OpenKeystore(path, "new passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := reopened.DecryptString(ct); err != nil || pt != "secret" {
+		t.Fatalf("decrypt after passphrase change: %v", err)
+	}
+	// 内存中的密钥库与文件保持一致，之后的保存仍可用新口令打开
+	if err := ks.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	if reopened, err = OpenKeystore(path, "new passphrase"); err != nil || len(reopened.Keys()) != 1 {
+		t.Fatalf("save after passphrase change: %v", err)
+	}
+}
+
+func TestKeystoreCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	if _, err := OpenKeystore(path, "p", 1000); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	var kj map[string]interface{}
+	json.Unmarshal(data, &kj)
+	for _, iterations := range []int{0, -1} {
+		kj["iterations"] = iterations
+		corrupt, _ := json.Marshal(kj)
+		os.WriteFile(path, corrupt, 0600)
+		if _, err := OpenKeystore(path, "p"); err == nil || err == ErrWrongMasterKey {
+			t.Fatalf("iterations %d: expected format error, got %v", iterations, err)
+		}
+	}
+	kj["iterations"] = 1000
+	delete(kj, "salt")
+	corrupt, _ := json.Marshal(kj)
+	os.WriteFile(path, corrupt, 0600)
+	if _, err := OpenKeystore(path, "p"); err == nil || err == ErrWrongMasterKey {
+		t.Fatalf("missing salt: expected format error, got %v", err)
+	}
+	os.WriteFile(path, []byte("{"), 0600)
+	if _, err := OpenKeystore(path, "p"); err == nil {
+		t.Fatal("invalid json was accepted")
+	}
+}
+
+func TestKeystoreSaveFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	path := filepath.Join(dir, "keystore.json")
+	ks, err := OpenKeystore(path, "p", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := ks.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 用同名文件替换目录，使保存失败
+	moved := dir + ".moved"
+	if err := os.Rename(dir, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Rotate(); err == nil {
+		t.Fatal("Rotate should fail when the keystore can not be saved")
+	}
+	if err := ks.SetCurrent(1); err == nil {
+		t.Fatal("SetCurrent should fail when the keystore can not be saved")
+	}
+	if err := ks.Remove(1); err == nil {
+		t.Fatal("Remove should fail when the keystore can not be saved")
+	}
+	if ks.Current() != v2 || len(ks.Keys()) != 2 {
+		t.Fatalf("keystore changed after failed save: current %d, keys %+v", ks.Current(), ks.Keys())
+	}
+	ct, err := ks.EncryptString("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(moved, dir); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenKeystore(path, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := reopened.DecryptString(ct); err != nil || pt != "secret" {
+		t.Fatalf("data encrypted after a failed save can not be decrypted after reopening: %v", err)
+	}
+}
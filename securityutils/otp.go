@@ -0,0 +1,254 @@
+package securityutils
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//OtpAlgorithm 一次性密码使用的HMAC算法
+type OtpAlgorithm string
+
+const (
+	OtpSha1   OtpAlgorithm = "SHA1" //大多数验证器App只支持SHA1
+	OtpSha256 OtpAlgorithm = "SHA256"
+	OtpSha512 OtpAlgorithm = "SHA512"
+)
+
+//OtpOptions 一次性密码参数，零值字段使用默认值
+type OtpOptions struct {
+	Algorithm OtpAlgorithm //默认OtpSha1
+	Digits    int          //密码位数，6~8，默认6
+	Period    int          //TOTP时间步长（秒），默认30
+	Skew      int          //TOTP校验时前后允许的时间步数，默认0（仅当前步长），建议1
+}
+
+const (
+	// DefaultOtpSecretSize 默认密钥长度，RFC 4226建议至少160位
+	DefaultOtpSecretSize = 20
+
+	defaultOtpDigits = 6
+	defaultOtpPeriod = 30
+)
+
+//GenerateOtpSecret 生成base32编码（无填充）的随机密钥，size为非必需参数，默认20位
+func GenerateOtpSecret(size ...int) (string, error) {
+	n := DefaultOtpSecretSize
+	if len(size) > 0 && size[0] > 0 {
+		n = size[0]
+	}
+	secret, err := RandomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return Base32RawEncodeToString(secret), nil
+}
+
+// =================== HOTP ======================
+//HOTP 根据计数器生成一次性密码（RFC 4226），secret为base32编码的密钥
+func HOTP(secret string, counter uint64, opts ...OtpOptions) (string, error) {
+	key, err := otpKey(secret)
+	if err != nil {
+		return "", err
+	}
+	o, err := otpOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, counter, o), nil
+}
+
+//ValidateHOTP 校验HOTP，在[counter, counter+window]范围内查找匹配的计数器
+//校验通过时返回匹配的计数器，调用方应将服务端计数器更新为其加1，防止重放
+func ValidateHOTP(code string, secret string, counter uint64, window int, opts ...OtpOptions) (uint64, bool, error) {
+	key, err := otpKey(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	o, err := otpOptions(opts)
+	if err != nil {
+		return 0, false, err
+	}
+	if window < 0 {
+		window = 0
+	}
+	for i := 0; i <= window; i++ {
+		if otpEqual(code, hotp(key, counter+uint64(i), o)) {
+			return counter + uint64(i), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// =================== TOTP ======================
+//TOTP 根据时间生成一次性密码（RFC 6238），secret为base32编码的密钥
+func TOTP(secret string, t time.Time, opts ...OtpOptions) (string, error) {
+	key, err := otpKey(secret)
+	if err != nil {
+		return "", err
+	}
+	o, err := otpOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, totpStep(t, o.Period), o), nil
+}
+
+//TOTPNow 根据当前时间生成一次性密码
+func TOTPNow(secret string, opts ...OtpOptions) (string, error) {
+	return TOTP(secret, time.Now(), opts...)
+}
+
+//ValidateTOTP 校验TOTP，允许前后Skew个时间步长的时钟偏差
+func ValidateTOTP(code string, secret string, t time.Time, opts ...OtpOptions) (bool, error) {
+	_, ok, err := ValidateTOTPStep(code, secret, t, opts...)
+	return ok, err
+}
+
+//ValidateTOTPStep 校验TOTP并返回匹配的时间步
+//调用方可记录最后一次使用的时间步，拒绝小于等于该值的密码，防止同一密码被重复使用
+func ValidateTOTPStep(code string, secret string, t time.Time, opts ...OtpOptions) (uint64, bool, error) {
+	key, err := otpKey(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	o, err := otpOptions(opts)
+	if err != nil {
+		return 0, false, err
+	}
+	step := totpStep(t, o.Period)
+	// 先比较当前时间步，再依次向前后扩展
+	for i := 0; i <= o.Skew; i++ {
+		if otpEqual(code, hotp(key, step+uint64(i), o)) {
+			return step + uint64(i), true, nil
+		}
+		if i > 0 && step >= uint64(i) && otpEqual(code, hotp(key, step-uint64(i), o)) {
+			return step - uint64(i), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// =================== otpauth URI ======================
+//TotpURI 生成TOTP配置URI（otpauth://totp/...），可生成二维码供验证器App扫描
+func TotpURI(secret string, issuer string, account string, opts ...OtpOptions) (string, error) {
+	o, err := otpOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	query := otpURIQuery(secret, issuer, o)
+	query.Set("period", strconv.Itoa(o.Period))
+	return otpURI("totp", issuer, account, query), nil
+}
+
+//HotpURI 生成HOTP配置URI（otpauth://hotp/...），counter为初始计数器
+func HotpURI(secret string, issuer string, account string, counter uint64, opts ...OtpOptions) (string, error) {
+	o, err := otpOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	query := otpURIQuery(secret, issuer, o)
+	query.Set("counter", strconv.FormatUint(counter, 10))
+	return otpURI("hotp", issuer, account, query), nil
+}
+
+func otpURIQuery(secret string, issuer string, o OtpOptions) url.Values {
+	query := url.Values{}
+	query.Set("secret", strings.ToUpper(strings.TrimRight(secret, "=")))
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", string(o.Algorithm))
+	query.Set("digits", strconv.Itoa(o.Digits))
+	return query
+}
+
+func otpURI(typ string, issuer string, account string, query url.Values) string {
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+	u := url.URL{Scheme: "otpauth", Host: typ, Path: "/" + label, RawQuery: strings.Replace(query.Encode(), "+", "%20", -1)}
+	return u.String()
+}
+
+// RFC 4226 动态截断
+func hotp(key []byte, counter uint64, o OtpOptions) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	var sum []byte
+	switch o.Algorithm {
+	case OtpSha256:
+		sum = HmacSha256Byte(msg[:], key)
+	case OtpSha512:
+		sum = HmacSha512Byte(msg[:], key)
+	default:
+		sum = HmacSha1Byte(msg[:], key)
+	}
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < o.Digits; i++ {
+		mod *= 10
+	}
+	code := strconv.FormatUint(uint64(value%mod), 10)
+	return strings.Repeat("0", o.Digits-len(code)) + code
+}
+
+func totpStep(t time.Time, period int) uint64 {
+	unix := t.Unix()
+	if unix < 0 {
+		return 0
+	}
+	return uint64(unix) / uint64(period)
+}
+
+func otpEqual(code string, expect string) bool {
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(code)), []byte(expect)) == 1
+}
+
+// 兼容小写、空格分组以及带填充的base32密钥
+func otpKey(secret string) ([]byte, error) {
+	secret = strings.Replace(secret, " ", "", -1)
+	key, err := Base32DecodeString(secret)
+	if err != nil {
+		return nil, errors.New("otp secret must be base32 encoded")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("otp secret can not be empty")
+	}
+	return key, nil
+}
+
+func otpOptions(opts []OtpOptions) (OtpOptions, error) {
+	var o OtpOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Algorithm == "" {
+		o.Algorithm = OtpSha1
+	}
+	o.Algorithm = OtpAlgorithm(strings.ToUpper(string(o.Algorithm)))
+	switch o.Algorithm {
+	case OtpSha1, OtpSha256, OtpSha512:
+	default:
+		return o, errors.New("unsupported otp algorithm " + string(o.Algorithm))
+	}
+	if o.Digits == 0 {
+		o.Digits = defaultOtpDigits
+	}
+	if o.Digits < 6 || o.Digits > 8 {
+		return o, errors.New("otp digits must be between 6 and 8")
+	}
+	if o.Period <= 0 {
+		o.Period = defaultOtpPeriod
+	}
+	if o.Skew < 0 {
+		o.Skew = 0
+	}
+	return o, nil
+}
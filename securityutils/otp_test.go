@@ -0,0 +1,148 @@
+package securityutils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 附录D
+	secret := Base32EncodeToString([]byte("12345678901234567890"))
+	codes := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for i, want := range codes {
+		code, err := HOTP(secret, uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != want {
+			t.Fatalf("counter %d: got %s, want %s", i, code, want)
+		}
+	}
+	counter, ok, err := ValidateHOTP("969429", secret, 1, 5)
+	if err != nil || !ok || counter != 3 {
+		t.Fatalf("ValidateHOTP: %d %v %v", counter, ok, err)
+	}
+	if _, ok, _ := ValidateHOTP("969429", secret, 4, 5); ok {
+		t.Fatal("counter before the window was accepted")
+	}
+	if _, ok, _ := ValidateHOTP("520489", secret, 0, 5); ok {
+		t.Fatal("counter after the window was accepted")
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 附录B
+	seeds := map[OtpAlgorithm]string{
+		OtpSha1:   "12345678901234567890",
+		OtpSha256: "12345678901234567890123456789012",
+		OtpSha512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	cases := []struct {
+		unix  int64
+		codes map[OtpAlgorithm]string
+	}{
+		{59, map[OtpAlgorithm]string{OtpSha1: "94287082", OtpSha256: "46119246", OtpSha512: "90693936"}},
+		{1111111109, map[OtpAlgorithm]string{OtpSha1: "07081804", OtpSha256: "68084774", OtpSha512: "25091201"}},
+		{1111111111, map[OtpAlgorithm]string{OtpSha1: "14050471", OtpSha256: "67062674", OtpSha512: "99943326"}},
+		{1234567890, map[OtpAlgorithm]string{OtpSha1: "89005924", OtpSha256: "91819424", OtpSha512: "93441116"}},
+		{2000000000, map[OtpAlgorithm]string{OtpSha1: "69279037", OtpSha256: "90698825", OtpSha512: "38618901"}},
+		{20000000000, map[OtpAlgorithm]string{OtpSha1: "65353130", OtpSha256: "77737706", OtpSha512: "47863826"}},
+	}
+	for _, c := range cases {
+		for alg, want := range c.codes {
+			secret := Base32RawEncodeToString([]byte(seeds[alg]))
+			opts := OtpOptions{Algorithm: alg, Digits: 8}
+			code, err := TOTP(secret, time.Unix(c.unix, 0), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != want {
+				t.Fatalf("%s at %d: got %s, want %s", alg, c.unix, code, want)
+			}
+			if ok, err := ValidateTOTP(want, secret, time.Unix(c.unix, 0), opts); err != nil || !ok {
+				t.Fatalf("%s at %d: validate failed %v", alg, c.unix, err)
+			}
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret, err := GenerateOtpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	prev, _ := TOTP(secret, now.Add(-30*time.Second))
+	next, _ := TOTP(secret, now.Add(30*time.Second))
+	old, _ := TOTP(secret, now.Add(-60*time.Second))
+	if ok, _ := ValidateTOTP(prev, secret, now); ok {
+		t.Fatal("previous step was accepted without skew")
+	}
+	opts := OtpOptions{Skew: 1}
+	step, ok, err := ValidateTOTPStep(prev, secret, now, opts)
+	if err != nil || !ok || step != uint64(now.Unix()/30-1) {
+		t.Fatalf("previous step: %d %v %v", step, ok, err)
+	}
+	if ok, _ := ValidateTOTP(next, secret, now, opts); !ok {
+		t.Fatal("next step was rejected with skew 1")
+	}
+	if ok, _ := ValidateTOTP(old, secret, now, opts); ok {
+		t.Fatal("step outside skew was accepted")
+	}
+	// 兼容空格分组与小写密钥，以及输入两端的空白
+	code, _ := TOTP(secret, now)
+	grouped := strings.ToLower(secret[:4] + " " + secret[4:])
+	if ok, _ := ValidateTOTP(" "+code+" ", grouped, now); !ok {
+		t.Fatal("grouped lower case secret was rejected")
+	}
+}
+
+func TestOtpOptions(t *testing.T) {
+	secret, _ := GenerateOtpSecret(10)
+	if len(secret) != 16 {
+		t.Fatalf("unexpected secret %q", secret)
+	}
+	if _, err := HOTP(secret, 0, OtpOptions{Digits: 5}); err == nil {
+		t.Fatal("5 digits was accepted")
+	}
+	if _, err := HOTP(secret, 0, OtpOptions{Algorithm: "MD5"}); err == nil {
+		t.Fatal("MD5 was accepted")
+	}
+	if _, err := HOTP("not base32!", 0); err == nil {
+		t.Fatal("invalid secret was accepted")
+	}
+	if _, err := HOTP("", 0); err == nil {
+		t.Fatal("empty secret was accepted")
+	}
+	if code, _ := HOTP(secret, 0, OtpOptions{Algorithm: "sha256", Digits: 7}); len(code) != 7 {
+		t.Fatalf("unexpected code %q", code)
+	}
+}
+
+func TestOtpURI(t *testing.T) {
+	uri, err := TotpURI("jbswy3dpehpk3pxp", "Example Co", "alice@example.com", OtpOptions{Period: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Example Co:alice@example.com" {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+	q := u.Query()
+	if q.Get("secret") != "JBSWY3DPEHPK3PXP" || q.Get("issuer") != "Example Co" || q.Get("period") != "60" ||
+		q.Get("algorithm") != "SHA1" || q.Get("digits") != "6" {
+		t.Fatalf("unexpected query %v", q)
+	}
+	if strings.Contains(uri, "+") {
+		t.Fatalf("spaces must be encoded as %%20: %s", uri)
+	}
+	hotpURI, err := HotpURI("JBSWY3DPEHPK3PXP", "", "bob", 5)
+	if err != nil || !strings.HasPrefix(hotpURI, "otpauth://hotp/bob?") || !strings.Contains(hotpURI, "counter=5") {
+		t.Fatalf("unexpected hotp uri %s: %v", hotpURI, err)
+	}
+}
@@ -0,0 +1,283 @@
+package securityutils
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// 口令哈希编码格式：
+// argon2id：$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+// scrypt：  $scrypt$ln=15,r=8,p=1$<salt>$<hash>
+// pbkdf2：  $pbkdf2-sha256$i=600000$<salt>$<hash>
+// bcrypt：  $2a$10$<salt+hash>（bcrypt自有的Modular Crypt格式）
+// 以上salt与hash均为不带填充的标准base64编码（PHC字符串格式）
+
+//PasswordAlgorithm 口令哈希算法
+type PasswordAlgorithm string
+
+const (
+	PasswordArgon2id     PasswordAlgorithm = "argon2id"
+	PasswordBcrypt       PasswordAlgorithm = "bcrypt"
+	PasswordScrypt       PasswordAlgorithm = "scrypt"
+	PasswordPbkdf2Sha256 PasswordAlgorithm = "pbkdf2-sha256"
+)
+
+//PasswordParams 口令哈希参数
+type PasswordParams struct {
+	Algorithm PasswordAlgorithm
+
+	Argon2Time    uint32 //迭代次数
+	Argon2Memory  uint32 //内存，单位KiB
+	Argon2Threads uint8  //并行度
+
+	BcryptCost int
+
+	ScryptLogN int //N = 2^ScryptLogN
+	ScryptR    int
+	ScryptP    int
+
+	Pbkdf2Iterations int
+
+	SaltLen int //bcrypt不使用
+	KeyLen  int //bcrypt不使用
+}
+
+//DefaultPasswordParams 默认口令哈希参数，使用argon2id
+func DefaultPasswordParams() *PasswordParams {
+	return &PasswordParams{
+		Algorithm:        PasswordArgon2id,
+		Argon2Time:       3,
+		Argon2Memory:     64 * 1024,
+		Argon2Threads:    4,
+		BcryptCost:       12,
+		ScryptLogN:       15,
+		ScryptR:          8,
+		ScryptP:          1,
+		Pbkdf2Iterations: DefaultPbkdf2Iterations,
+		SaltLen:          16,
+		KeyLen:           32,
+	}
+}
+
+//HashPassword 计算口令哈希，返回可直接存储的编码字符串
+//params 为非必需参数，默认DefaultPasswordParams
+func HashPassword(password string, params ...*PasswordParams) (string, error) {
+	p := passwordParams(params)
+	if p.Algorithm == PasswordBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+	if p.SaltLen <= 0 || p.KeyLen <= 0 {
+		return "", errors.New("salt length and key length must be positive")
+	}
+	salt, err := RandomBytes(p.SaltLen)
+	if err != nil {
+		return "", err
+	}
+	ph := &phcHash{salt: salt}
+	switch p.Algorithm {
+	case PasswordArgon2id:
+		ph.id = string(PasswordArgon2id)
+		ph.version = argon2.Version
+		ph.params = []phcParam{{"m", int(p.Argon2Memory)}, {"t", int(p.Argon2Time)}, {"p", int(p.Argon2Threads)}}
+	case PasswordScrypt:
+		ph.id = string(PasswordScrypt)
+		ph.params = []phcParam{{"ln", p.ScryptLogN}, {"r", p.ScryptR}, {"p", p.ScryptP}}
+	case PasswordPbkdf2Sha256:
+		ph.id = string(PasswordPbkdf2Sha256)
+		ph.params = []phcParam{{"i", p.Pbkdf2Iterations}}
+	default:
+		return "", errors.New("unsupported password algorithm")
+	}
+	ph.hash, err = ph.derive(password, p.KeyLen)
+	if err != nil {
+		return "", err
+	}
+	return ph.String(), nil
+}
+
+//VerifyPassword 校验口令与已存储的编码哈希是否匹配，使用常量时间比较
+func VerifyPassword(password string, encoded string) (bool, error) {
+	if isBcryptHash(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	ph, err := parsePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+	hash, err := ph.derive(password, len(ph.hash))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(hash, ph.hash) == 1, nil
+}
+
+//PasswordNeedsRehash 判断已存储的哈希是否需要用当前参数重新计算
+//算法或参数与params不一致时返回true，应在口令校验通过后重新HashPassword并保存
+//params 为非必需参数，默认DefaultPasswordParams
+func PasswordNeedsRehash(encoded string, params ...*PasswordParams) (bool, error) {
+	p := passwordParams(params)
+	if isBcryptHash(encoded) {
+		if p.Algorithm != PasswordBcrypt {
+			return true, nil
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, err
+		}
+		return cost != p.BcryptCost, nil
+	}
+	ph, err := parsePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+	if ph.id != string(p.Algorithm) || len(ph.salt) != p.SaltLen || len(ph.hash) != p.KeyLen {
+		return true, nil
+	}
+	switch p.Algorithm {
+	case PasswordArgon2id:
+		return ph.version != argon2.Version ||
+			ph.param("m") != int(p.Argon2Memory) ||
+			ph.param("t") != int(p.Argon2Time) ||
+			ph.param("p") != int(p.Argon2Threads), nil
+	case PasswordScrypt:
+		return ph.param("ln") != p.ScryptLogN || ph.param("r") != p.ScryptR || ph.param("p") != p.ScryptP, nil
+	case PasswordPbkdf2Sha256:
+		return ph.param("i") != p.Pbkdf2Iterations, nil
+	}
+	return true, nil
+}
+
+func passwordParams(params []*PasswordParams) *PasswordParams {
+	if len(params) > 0 && params[0] != nil {
+		return params[0]
+	}
+	return DefaultPasswordParams()
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// ------------------- PHC字符串格式 ----------------------
+type phcParam struct {
+	name  string
+	value int
+}
+
+type phcHash struct {
+	id      string
+	version int //0表示没有v=字段
+	params  []phcParam
+	salt    []byte
+	hash    []byte
+}
+
+func (ph *phcHash) param(name string) int {
+	for _, p := range ph.params {
+		if p.name == name {
+			return p.value
+		}
+	}
+	return -1
+}
+
+func (ph *phcHash) String() string {
+	var sb strings.Builder
+	sb.WriteString("$" + ph.id)
+	if ph.version > 0 {
+		sb.WriteString("$v=" + strconv.Itoa(ph.version))
+	}
+	for i, p := range ph.params {
+		if i == 0 {
+			sb.WriteString("$")
+		} else {
+			sb.WriteString(",")
+		}
+		sb.WriteString(p.name + "=" + strconv.Itoa(p.value))
+	}
+	sb.WriteString("$" + base64.RawStdEncoding.EncodeToString(ph.salt))
+	sb.WriteString("$" + base64.RawStdEncoding.EncodeToString(ph.hash))
+	return sb.String()
+}
+
+func (ph *phcHash) derive(password string, keyLen int) ([]byte, error) {
+	switch ph.id {
+	case string(PasswordArgon2id):
+		if ph.version != argon2.Version {
+			return nil, fmt.Errorf("unsupported argon2 version %d", ph.version)
+		}
+		m, t, p := ph.param("m"), ph.param("t"), ph.param("p")
+		if m <= 0 || t <= 0 || p <= 0 || p > 255 {
+			return nil, errors.New("invalid argon2id params")
+		}
+		return argon2.IDKey([]byte(password), ph.salt, uint32(t), uint32(m), uint8(p), uint32(keyLen)), nil
+	case string(PasswordScrypt):
+		ln, r, p := ph.param("ln"), ph.param("r"), ph.param("p")
+		if ln <= 0 || ln >= 63 || r <= 0 || p <= 0 {
+			return nil, errors.New("invalid scrypt params")
+		}
+		return scrypt.Key([]byte(password), ph.salt, 1<<uint(ln), r, p, keyLen)
+	case string(PasswordPbkdf2Sha256):
+		i := ph.param("i")
+		if i <= 0 {
+			return nil, errors.New("invalid pbkdf2 params")
+		}
+		return Pbkdf2Sha256([]byte(password), ph.salt, i, keyLen), nil
+	}
+	return nil, errors.New("unsupported password algorithm: " + ph.id)
+}
+
+func parsePHC(encoded string) (*phcHash, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) < 4 || fields[0] != "" {
+		return nil, errors.New("invalid password hash format")
+	}
+	ph := &phcHash{id: fields[1]}
+	fields = fields[2:]
+	if strings.HasPrefix(fields[0], "v=") {
+		v, err := strconv.Atoi(strings.TrimPrefix(fields[0], "v="))
+		if err != nil {
+			return nil, errors.New("invalid password hash version")
+		}
+		ph.version = v
+		fields = fields[1:]
+	}
+	if len(fields) != 3 {
+		return nil, errors.New("invalid password hash format")
+	}
+	for _, kv := range strings.Split(fields[0], ",") {
+		i := strings.IndexByte(kv, '=')
+		if i <= 0 {
+			return nil, errors.New("invalid password hash params")
+		}
+		v, err := strconv.Atoi(kv[i+1:])
+		if err != nil {
+			return nil, errors.New("invalid password hash params")
+		}
+		ph.params = append(ph.params, phcParam{kv[:i], v})
+	}
+	var err error
+	if ph.salt, err = base64.RawStdEncoding.DecodeString(fields[1]); err != nil {
+		return nil, errors.New("invalid password hash salt")
+	}
+	if ph.hash, err = base64.RawStdEncoding.DecodeString(fields[2]); err != nil || len(ph.hash) == 0 {
+		return nil, errors.New("invalid password hash")
+	}
+	return ph, nil
+}
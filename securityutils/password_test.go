@@ -0,0 +1,120 @@
+package securityutils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// 降低代价参数，避免测试耗时过长
+func fastPasswordParams(alg PasswordAlgorithm) *PasswordParams {
+	p := DefaultPasswordParams()
+	p.Algorithm = alg
+	p.BcryptCost = 4
+	p.Pbkdf2Iterations = 1000
+	p.ScryptLogN = 10
+	p.Argon2Memory = 1024
+	p.Argon2Time = 1
+	return p
+}
+
+func TestHashPassword(t *testing.T) {
+	prefixes := map[PasswordAlgorithm]string{
+		PasswordArgon2id:     "$argon2id$v=19$m=1024,t=1,p=4$",
+		PasswordBcrypt:       "$2a$04$",
+		PasswordScrypt:       "$scrypt$ln=10,r=8,p=1$",
+		PasswordPbkdf2Sha256: "$pbkdf2-sha256$i=1000$",
+	}
+	for alg, prefix := range prefixes {
+		p := fastPasswordParams(alg)
+		h, err := HashPassword("s3cret", p)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if !strings.HasPrefix(h, prefix) {
+			t.Fatalf("%s: unexpected encoding %q", alg, h)
+		}
+		if ok, err := VerifyPassword("s3cret", h); !ok || err != nil {
+			t.Fatalf("%s: correct password rejected: %v", alg, err)
+		}
+		if ok, err := VerifyPassword("s3cret ", h); ok || err != nil {
+			t.Fatalf("%s: wrong password accepted: %v", alg, err)
+		}
+		if h2, _ := HashPassword("s3cret", p); h2 == h {
+			t.Fatalf("%s: salt should be random", alg)
+		}
+		if re, err := PasswordNeedsRehash(h, p); re || err != nil {
+			t.Fatalf("%s: same params should not need rehash: %v", alg, err)
+		}
+		if re, err := PasswordNeedsRehash(h); !re || err != nil {
+			t.Fatalf("%s: default params should need rehash: %v", alg, err)
+		}
+	}
+}
+
+func TestVerifyPasswordVectors(t *testing.T) {
+	// RFC 7914 §12 scrypt测试向量，按PHC格式编码
+	key := HexDecodeString("fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640")
+	encoded := "$scrypt$ln=10,r=8,p=16$" + base64.RawStdEncoding.EncodeToString([]byte("NaCl")) + "$" + base64.RawStdEncoding.EncodeToString(key)
+	if ok, err := VerifyPassword("password", encoded); !ok || err != nil {
+		t.Fatalf("scrypt vector rejected: %v", err)
+	}
+	// PBKDF2-HMAC-SHA256(password, salt, 1)
+	key = HexDecodeString("120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b")
+	encoded = "$pbkdf2-sha256$i=1$" + base64.RawStdEncoding.EncodeToString([]byte("salt")) + "$" + base64.RawStdEncoding.EncodeToString(key)
+	if ok, err := VerifyPassword("password", encoded); !ok || err != nil {
+		t.Fatalf("pbkdf2 vector rejected: %v", err)
+	}
+}
+
+func TestVerifyPasswordMalformed(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"plain",
+		"$argon2id$v=19$m=1024,t=1,p=4$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=4$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=0,t=1,p=4$c2FsdA$aGFzaA",
+		"$scrypt$ln=99,r=8,p=1$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=abc$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1$!!$aGFzaA",
+		"$md5$i=1$c2FsdA$aGFzaA",
+	} {
+		if ok, err := VerifyPassword("x", encoded); ok || err == nil {
+			t.Fatalf("%q: malformed hash should return an error", encoded)
+		}
+	}
+}
+
+func TestKdfVectors(t *testing.T) {
+	// RFC 5869 A.1
+	okm, err := HkdfSha256(HexDecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b"), HexDecodeString("000102030405060708090a0b0c"), HexDecodeString("f0f1f2f3f4f5f6f7f8f9"), 42)
+	if err != nil || HexEncodeToString(okm) != "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865" {
+		t.Fatalf("HKDF-SHA256 vector mismatch: %x %v", okm, err)
+	}
+	if _, err := HkdfSha512([]byte("k"), nil, nil, 0); err == nil {
+		t.Fatal("zero length should be rejected")
+	}
+	// RFC 6070
+	if k := Pbkdf2Sha1([]byte("password"), []byte("salt"), 2, 20); HexEncodeToString(k) != "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957" {
+		t.Fatalf("PBKDF2-SHA1 vector mismatch: %x", k)
+	}
+	if k := Pbkdf2Sha256([]byte("password"), []byte("salt"), 1, 32); HexEncodeToString(k) != "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b" {
+		t.Fatalf("PBKDF2-SHA256 vector mismatch: %x", k)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	key, err := DeriveKey("pw", []byte("salt1234"), 16, 1000)
+	if err != nil || len(key) != 16 {
+		t.Fatalf("DeriveKey: %v", err)
+	}
+	if k2, _ := DeriveKey("pw", []byte("salt1234"), 16, 1000); HexEncodeToString(k2) != HexEncodeToString(key) {
+		t.Fatal("DeriveKey should be deterministic")
+	}
+	if _, err := DeriveKey("pw", nil, 16); err == nil {
+		t.Fatal("empty salt should be rejected")
+	}
+	if _, err := DeriveKey("pw", []byte("salt"), 0); err == nil {
+		t.Fatal("zero key length should be rejected")
+	}
+}
@@ -3,9 +3,7 @@ package securityutils
 import (
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/x509"
 	"encoding/base64"
-	"encoding/pem"
 	"errors"
 	"io/ioutil"
 )
@@ -23,12 +21,12 @@ import (
 ```
 */
 
-// Rsa加密，密钥格式 -----BEGIN PUBLIC KEY-----
+// Rsa加密，密钥格式 -----BEGIN PUBLIC KEY----- 或 -----BEGIN RSA PUBLIC KEY-----
 func RsaEncrypt(src string, publicKey string) (string, error) {
 	return rsaEncrypt(src, []byte(publicKey))
 }
 
-// Rsa加密，密钥格式 -----BEGIN PUBLIC KEY-----
+// Rsa加密，密钥格式 -----BEGIN PUBLIC KEY----- 或 -----BEGIN RSA PUBLIC KEY-----
 func RsaEncryptPath(src string, publicKeyPath string) (string, error) {
 	publicKey, err := ioutil.ReadFile(publicKeyPath)
 	if err != nil {
@@ -43,17 +41,11 @@ func rsaEncrypt(src string, publicKey []byte) (string, error) {
 	}
 	srcByte := []byte(src)
 
-	// 解密pem格式的公钥
-	block, _ := pem.Decode(publicKey)
-	if block == nil {
-		return "", errors.New("public key error")
-	}
-	// 解析公钥
-	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	// 解析pem格式的公钥，支持PKIX与PKCS#1
+	pubKey, err := ParseRsaPublicKey(publicKey)
 	if err != nil {
 		return "", err
 	}
-	pubKey := key.(*rsa.PublicKey)
 
 	// rsa加密
 	dstByte, err := rsa.EncryptPKCS1v15(rand.Reader, pubKey, srcByte)
@@ -87,17 +79,11 @@ func rsaDecrypt(src string, privateKey []byte) (string, error) {
 	// 对rsa加密结果进行base64解密
 	srcByte, _ := base64.StdEncoding.DecodeString(src)
 
-	// 解密pem格式的私钥
-	block, _ := pem.Decode(privateKey)
-	if block == nil {
-		return "", errors.New("private key error")
-	}
-	// 解析私钥
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	// 解析pem格式的私钥，支持PKCS#8与PKCS#1
+	privKey, err := ParseRsaPrivateKey(privateKey)
 	if err != nil {
 		return "", err
 	}
-	privKey := key.(*rsa.PrivateKey)
 
 	// rsa解密
 	dstByte, err := rsa.DecryptPKCS1v15(rand.Reader, privKey, srcByte)
@@ -129,13 +115,8 @@ func rsaDecryptPKCS1(src string, privateKey []byte) (string, error) {
 	// 对rsa加密结果进行base64解密
 	srcByte, _ := base64.StdEncoding.DecodeString(src)
 
-	// 解密pem格式的私钥
-	block, _ := pem.Decode(privateKey)
-	if block == nil {
-		return "", errors.New("private key error")
-	}
-	// 解析私钥
-	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	// 解析pem格式的私钥，支持PKCS#1与PKCS#8
+	key, err := ParseRsaPrivateKey(privateKey)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,210 @@
+package securityutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+)
+
+//RsaPadding Rsa加密填充方式
+type RsaPadding int
+
+const (
+	RsaPaddingPKCS1v15 RsaPadding = iota //PKCS#1 v1.5，与RsaEncrypt一致
+	RsaPaddingOAEP                       //OAEP，哈希与MGF1均为SHA-256
+)
+
+// =================== OAEP ======================
+// Rsa加密，使用OAEP(SHA-256)填充，返回base64编码的密文，label为非必需参数
+func RsaEncryptOAEP(src string, publicKey string, label ...[]byte) (string, error) {
+	return rsaEncryptOAEP(src, []byte(publicKey), label)
+}
+
+// Rsa加密，使用OAEP(SHA-256)填充，返回base64编码的密文，label为非必需参数
+func RsaEncryptOAEPPath(src string, publicKeyPath string, label ...[]byte) (string, error) {
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return rsaEncryptOAEP(src, publicKey, label)
+}
+
+func rsaEncryptOAEP(src string, publicKey []byte, label [][]byte) (string, error) {
+	if len(src) == 0 {
+		return "", errors.New("src can not be empty")
+	}
+	key, err := ParseRsaPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	dstByte, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, []byte(src), oaepLabel(label))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(dstByte), nil
+}
+
+// Rsa解密，使用OAEP(SHA-256)填充，src为base64编码的密文，label为非必需参数
+func RsaDecryptOAEP(src string, privateKey string, label ...[]byte) (string, error) {
+	return rsaDecryptOAEP(src, []byte(privateKey), label)
+}
+
+// Rsa解密，使用OAEP(SHA-256)填充，src为base64编码的密文，label为非必需参数
+func RsaDecryptOAEPPath(src string, privateKeyPath string, label ...[]byte) (string, error) {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return rsaDecryptOAEP(src, privateKey, label)
+}
+
+func rsaDecryptOAEP(src string, privateKey []byte, label [][]byte) (string, error) {
+	if len(src) == 0 {
+		return "", errors.New("src can not be empty")
+	}
+	srcByte, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return "", err
+	}
+	key, err := ParseRsaPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	dstByte, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, srcByte, oaepLabel(label))
+	if err != nil {
+		return "", err
+	}
+	return string(dstByte), nil
+}
+
+func oaepLabel(label [][]byte) []byte {
+	if len(label) > 0 {
+		return label[0]
+	}
+	return nil
+}
+
+// =================== 分段加解密 ======================
+// Rsa分段加密，明文超过单次加密上限时按块加密后拼接，返回base64编码的密文
+// padding 为非必需参数，默认RsaPaddingPKCS1v15
+func RsaEncryptSegment(src string, publicKey string, padding ...RsaPadding) (string, error) {
+	return rsaEncryptSegment(src, []byte(publicKey), padding)
+}
+
+// Rsa分段加密，明文超过单次加密上限时按块加密后拼接，返回base64编码的密文
+func RsaEncryptSegmentPath(src string, publicKeyPath string, padding ...RsaPadding) (string, error) {
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return rsaEncryptSegment(src, publicKey, padding)
+}
+
+func rsaEncryptSegment(src string, publicKey []byte, padding []RsaPadding) (string, error) {
+	if len(src) == 0 {
+		return "", errors.New("src can not be empty")
+	}
+	key, err := ParseRsaPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	dstByte, err := RsaEncryptSegmentByte([]byte(src), key, padding...)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(dstByte), nil
+}
+
+// Rsa分段解密，src为base64编码的密文
+// padding 为非必需参数，默认RsaPaddingPKCS1v15
+func RsaDecryptSegment(src string, privateKey string, padding ...RsaPadding) (string, error) {
+	return rsaDecryptSegment(src, []byte(privateKey), padding)
+}
+
+// Rsa分段解密，src为base64编码的密文
+func RsaDecryptSegmentPath(src string, privateKeyPath string, padding ...RsaPadding) (string, error) {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return rsaDecryptSegment(src, privateKey, padding)
+}
+
+func rsaDecryptSegment(src string, privateKey []byte, padding []RsaPadding) (string, error) {
+	if len(src) == 0 {
+		return "", errors.New("src can not be empty")
+	}
+	srcByte, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return "", err
+	}
+	key, err := ParseRsaPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	dstByte, err := RsaDecryptSegmentByte(srcByte, key, padding...)
+	if err != nil {
+		return "", err
+	}
+	return string(dstByte), nil
+}
+
+// Rsa分段加密，每块明文长度不超过模长减去填充开销
+func RsaEncryptSegmentByte(src []byte, key *rsa.PublicKey, padding ...RsaPadding) ([]byte, error) {
+	oaep := len(padding) > 0 && padding[0] == RsaPaddingOAEP
+	k := key.Size()
+	maxLen := k - 11
+	if oaep {
+		maxLen = k - 2*sha256.Size - 2
+	}
+	if maxLen <= 0 {
+		return nil, errors.New("rsa key size is too small")
+	}
+	dst := make([]byte, 0, (len(src)/maxLen+1)*k)
+	for len(src) > 0 {
+		n := len(src)
+		if n > maxLen {
+			n = maxLen
+		}
+		var block []byte
+		var err error
+		if oaep {
+			block, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, key, src[:n], nil)
+		} else {
+			block, err = rsa.EncryptPKCS1v15(rand.Reader, key, src[:n])
+		}
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, block...)
+		src = src[n:]
+	}
+	return dst, nil
+}
+
+// Rsa分段解密，密文长度必须为模长的整数倍
+func RsaDecryptSegmentByte(src []byte, key *rsa.PrivateKey, padding ...RsaPadding) ([]byte, error) {
+	oaep := len(padding) > 0 && padding[0] == RsaPaddingOAEP
+	k := key.Size()
+	if len(src) == 0 || len(src)%k != 0 {
+		return nil, errors.New("src is not a multiple of the key size")
+	}
+	dst := make([]byte, 0, len(src))
+	for i := 0; i < len(src); i += k {
+		var block []byte
+		var err error
+		if oaep {
+			block, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, key, src[i:i+k], nil)
+		} else {
+			block, err = rsa.DecryptPKCS1v15(rand.Reader, key, src[i:i+k])
+		}
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, block...)
+	}
+	return dst, nil
+}
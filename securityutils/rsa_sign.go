@@ -0,0 +1,121 @@
+package securityutils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+)
+
+//RsaSignAlgorithm Rsa签名算法
+type RsaSignAlgorithm int
+
+const (
+	RsaPKCS1v15Sha256 RsaSignAlgorithm = iota //RS256
+	RsaPKCS1v15Sha512                         //RS512
+	RsaPSSSha256                              //PS256
+	RsaPSSSha512                              //PS512
+)
+
+func (alg RsaSignAlgorithm) hash() crypto.Hash {
+	if alg == RsaPKCS1v15Sha512 || alg == RsaPSSSha512 {
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
+
+func (alg RsaSignAlgorithm) isPSS() bool {
+	return alg == RsaPSSSha256 || alg == RsaPSSSha512
+}
+
+func digest(h crypto.Hash, src []byte) []byte {
+	if h == crypto.SHA512 {
+		sum := sha512.Sum512(src)
+		return sum[:]
+	}
+	sum := sha256.Sum256(src)
+	return sum[:]
+}
+
+// Rsa签名，返回base64编码的签名，私钥支持PKCS#1与PKCS#8
+func RsaSign(src string, privateKey string, alg RsaSignAlgorithm) (string, error) {
+	return rsaSign(src, []byte(privateKey), alg)
+}
+
+// Rsa签名，返回base64编码的签名，私钥支持PKCS#1与PKCS#8
+func RsaSignPath(src string, privateKeyPath string, alg RsaSignAlgorithm) (string, error) {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return rsaSign(src, privateKey, alg)
+}
+
+func rsaSign(src string, privateKey []byte, alg RsaSignAlgorithm) (string, error) {
+	key, err := ParseRsaPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	sign, err := RsaSignByte([]byte(src), key, alg)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sign), nil
+}
+
+// Rsa验签，sign为base64编码的签名，验签失败返回error，公钥支持PKIX与PKCS#1
+func RsaVerify(src string, sign string, publicKey string, alg RsaSignAlgorithm) error {
+	return rsaVerify(src, sign, []byte(publicKey), alg)
+}
+
+// Rsa验签，sign为base64编码的签名，验签失败返回error，公钥支持PKIX与PKCS#1
+func RsaVerifyPath(src string, sign string, publicKeyPath string, alg RsaSignAlgorithm) error {
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	return rsaVerify(src, sign, publicKey, alg)
+}
+
+func rsaVerify(src string, sign string, publicKey []byte, alg RsaSignAlgorithm) error {
+	key, err := ParseRsaPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	signByte, err := base64.StdEncoding.DecodeString(sign)
+	if err != nil {
+		return err
+	}
+	return RsaVerifyByte([]byte(src), signByte, key, alg)
+}
+
+// Rsa签名
+func RsaSignByte(src []byte, key *rsa.PrivateKey, alg RsaSignAlgorithm) ([]byte, error) {
+	if key == nil {
+		return nil, errors.New("private key can not be nil")
+	}
+	h := alg.hash()
+	hashed := digest(h, src)
+	if alg.isPSS() {
+		return rsa.SignPSS(rand.Reader, key, h, hashed, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	}
+	return rsa.SignPKCS1v15(rand.Reader, key, h, hashed)
+}
+
+// Rsa验签，验签失败返回error
+func RsaVerifyByte(src []byte, sign []byte, key *rsa.PublicKey, alg RsaSignAlgorithm) error {
+	if key == nil {
+		return errors.New("public key can not be nil")
+	}
+	h := alg.hash()
+	hashed := digest(h, src)
+	if alg.isPSS() {
+		// 兼容签名方使用不同的盐长度
+		return rsa.VerifyPSS(key, h, hashed, sign, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	}
+	return rsa.VerifyPKCS1v15(key, h, hashed, sign)
+}
@@ -0,0 +1,175 @@
+package securityutils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+)
+
+// 安全模式的CBC加解密，用于替代默认iv取自key的旧函数：
+// 密文格式 | iv | ciphertext | [hmac-sha256] |
+// iv每次随机生成；传入macKey时追加HMAC-SHA256（encrypt-then-MAC），解密时先校验再解密
+// macKey必须与加密key不同，长度建议不少于32位
+// 解密失败统一返回同一错误，不区分补位错误与MAC错误，避免padding oracle
+const safeMacSize = sha256.Size
+
+var errSafeDecrypt = errors.New("decryption error")
+
+// =================== AES ======================
+// AES加密, 使用CBC模式，随机iv置于密文头部，注意key必须为16/24/32位长度，macKey为非必需参数
+func AesEncryptCBCSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return encryptCBCSafe(block, src, macKey)
+}
+
+// AES解密, 使用CBC模式，src为AesEncryptCBCSafe的输出，macKey必须与加密时一致
+func AesDecryptCBCSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCBCSafe(block, src, macKey)
+}
+
+// =================== DES ======================
+// DES加密, 使用CBC模式，随机iv置于密文头部，注意key必须为8位长度，macKey为非必需参数
+func DesEncryptCBCSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := des.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return encryptCBCSafe(block, src, macKey)
+}
+
+// DES解密, 使用CBC模式，src为DesEncryptCBCSafe的输出，macKey必须与加密时一致
+func DesDecryptCBCSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := des.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCBCSafe(block, src, macKey)
+}
+
+// 3DES加密, 使用CBC模式，随机iv置于密文头部，注意key必须为24位长度，macKey为非必需参数
+func DesEncryptCBCTripleSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return encryptCBCSafe(block, src, macKey)
+}
+
+// 3DES解密, 使用CBC模式，src为DesEncryptCBCTripleSafe的输出，macKey必须与加密时一致
+func DesDecryptCBCTripleSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCBCSafe(block, src, macKey)
+}
+
+// =================== SM4 ======================
+// SM4加密, 使用CBC模式，随机iv置于密文头部，注意key必须为16位长度，macKey为非必需参数
+func Sm4EncryptCBCSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := NewSm4Cipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return encryptCBCSafe(block, src, macKey)
+}
+
+// SM4解密, 使用CBC模式，src为Sm4EncryptCBCSafe的输出，macKey必须与加密时一致
+func Sm4DecryptCBCSafe(src []byte, key []byte, macKey ...[]byte) ([]byte, error) {
+	block, err := NewSm4Cipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCBCSafe(block, src, macKey)
+}
+
+// =================== PKCS5 ======================
+// 去除PKCS5补位，以常量时间校验补位内容，补位非法时返回error
+func PKCS5UnPaddingSafe(src []byte, blockSize int) ([]byte, error) {
+	length := len(src)
+	if blockSize <= 0 || blockSize > 255 || length == 0 || length%blockSize != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	padLen := int(src[length-1])
+	good := subtle.ConstantTimeLessOrEq(1, padLen) & subtle.ConstantTimeLessOrEq(padLen, blockSize)
+	// 固定检查最后blockSize个字节，不因padLen提前退出
+	for i := 1; i <= blockSize; i++ {
+		inPad := subtle.ConstantTimeLessOrEq(i, padLen)
+		match := subtle.ConstantTimeByteEq(src[length-i], byte(padLen))
+		good &= subtle.ConstantTimeSelect(inPad, match, 1)
+	}
+	if good != 1 {
+		return nil, errors.New("invalid padding")
+	}
+	return src[:length-padLen], nil
+}
+
+func encryptCBCSafe(block cipher.Block, src []byte, macKey [][]byte) ([]byte, error) {
+	mac, err := safeMacKey(macKey)
+	if err != nil {
+		return nil, err
+	}
+	blockSize := block.BlockSize()
+	iv, err := RandomBytes(blockSize)
+	if err != nil {
+		return nil, err
+	}
+	padded := PKCS5Padding(append([]byte(nil), src...), blockSize) // 复制后补位，避免修改调用方的数组
+	dst := make([]byte, blockSize+len(padded), blockSize+len(padded)+safeMacSize)
+	copy(dst, iv)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(dst[blockSize:], padded)
+	if mac != nil {
+		dst = append(dst, HmacSha256Byte(dst, mac)...)
+	}
+	return dst, nil
+}
+
+func decryptCBCSafe(block cipher.Block, src []byte, macKey [][]byte) ([]byte, error) {
+	mac, err := safeMacKey(macKey)
+	if err != nil {
+		return nil, err
+	}
+	if mac != nil {
+		if len(src) < safeMacSize {
+			return nil, errSafeDecrypt
+		}
+		body, tag := src[:len(src)-safeMacSize], src[len(src)-safeMacSize:]
+		if !hmac.Equal(tag, HmacSha256Byte(body, mac)) {
+			return nil, errSafeDecrypt
+		}
+		src = body
+	}
+	blockSize := block.BlockSize()
+	if len(src) < 2*blockSize || len(src)%blockSize != 0 {
+		return nil, errSafeDecrypt
+	}
+	iv, ciphertext := src[:blockSize], src[blockSize:]
+	dst := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(dst, ciphertext)
+	dst, err = PKCS5UnPaddingSafe(dst, blockSize)
+	if err != nil {
+		return nil, errSafeDecrypt
+	}
+	return dst, nil
+}
+
+func safeMacKey(macKey [][]byte) ([]byte, error) {
+	if len(macKey) == 0 || macKey[0] == nil {
+		return nil, nil
+	}
+	if len(macKey[0]) == 0 {
+		return nil, errors.New("mac key can not be empty")
+	}
+	return macKey[0], nil
+}
@@ -0,0 +1,121 @@
+package securityutils
+
+import (
+	"bytes"
+	"testing"
+)
+
+type safeCipher struct {
+	name    string
+	key     []byte
+	encrypt func(src, key []byte, macKey ...[]byte) ([]byte, error)
+	decrypt func(src, key []byte, macKey ...[]byte) ([]byte, error)
+}
+
+var safeCiphers = []safeCipher{
+	{"aes", []byte("0123456789abcdef0123456789abcdef"), AesEncryptCBCSafe, AesDecryptCBCSafe},
+	{"des", []byte("01234567"), DesEncryptCBCSafe, DesDecryptCBCSafe},
+	{"3des", []byte("0123456789abcdef01234567"), DesEncryptCBCTripleSafe, DesDecryptCBCTripleSafe},
+	{"sm4", []byte("0123456789abcdef"), Sm4EncryptCBCSafe, Sm4DecryptCBCSafe},
+}
+
+func TestCBCSafeRoundTrip(t *testing.T) {
+	macKey := []byte("mac key mac key mac key mac key!")
+	for _, c := range safeCiphers {
+		for _, size := range []int{0, 1, 7, 8, 15, 16, 17, 100} {
+			src := bytes.Repeat([]byte{'x'}, size)
+			for _, mac := range [][][]byte{nil, {macKey}} {
+				ct, err := c.encrypt(src, c.key, mac...)
+				if err != nil {
+					t.Fatalf("%s: %v", c.name, err)
+				}
+				pt, err := c.decrypt(ct, c.key, mac...)
+				if err != nil || !bytes.Equal(pt, src) {
+					t.Fatalf("%s size %d mac %v: %v", c.name, size, mac != nil, err)
+				}
+			}
+		}
+		// 每次加密使用不同的iv
+		a, _ := c.encrypt([]byte("same"), c.key)
+		b, _ := c.encrypt([]byte("same"), c.key)
+		if bytes.Equal(a, b) {
+			t.Fatalf("%s: iv was reused", c.name)
+		}
+	}
+}
+
+func TestCBCSafeTamper(t *testing.T) {
+	macKey := []byte("mac key mac key mac key mac key!")
+	for _, c := range safeCiphers {
+		ct, err := c.encrypt([]byte("attack at dawn, attack at dawn"), c.key, macKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 带MAC时任意字节被篡改都必须失败，且错误不区分原因
+		for i := range ct {
+			bad := append([]byte{}, ct...)
+			bad[i] ^= 0x01
+			if _, err := c.decrypt(bad, c.key, macKey); err != errSafeDecrypt {
+				t.Fatalf("%s: tampered byte %d: %v", c.name, i, err)
+			}
+		}
+		for _, n := range []int{0, 1, len(ct) - safeMacSize, len(ct) - 1} {
+			if _, err := c.decrypt(ct[:n], c.key, macKey); err != errSafeDecrypt {
+				t.Fatalf("%s: truncated to %d: %v", c.name, n, err)
+			}
+		}
+		if _, err := c.decrypt(ct, c.key, []byte("other mac key")); err != errSafeDecrypt {
+			t.Fatalf("%s: wrong mac key: %v", c.name, err)
+		}
+		if _, err := c.encrypt([]byte("x"), c.key, []byte{}); err == nil {
+			t.Fatalf("%s: empty mac key was accepted", c.name)
+		}
+	}
+}
+
+func TestCBCSafeLegacyCompatible(t *testing.T) {
+	// 密文格式为 iv || ciphertext，去掉iv后可用旧函数解密
+	key := []byte("0123456789abcdef")
+	ct, err := AesEncryptCBCSafe([]byte("legacy"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := AesDecryptCBC(ct[16:], key, ct[:16])
+	if err != nil || string(pt) != "legacy" {
+		t.Fatalf("AesDecryptCBC: %v", err)
+	}
+	ct, err = Sm4EncryptCBCSafe([]byte("legacy"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err = Sm4DecryptCBC(ct[16:], key, ct[:16])
+	if err != nil || string(pt) != "legacy" {
+		t.Fatalf("Sm4DecryptCBC: %v", err)
+	}
+}
+
+func TestPKCS5UnPaddingSafe(t *testing.T) {
+	cases := []struct {
+		src  []byte
+		want []byte
+		ok   bool
+	}{
+		{[]byte{'a', 'b', 'c', 'd', 'e', 3, 3, 3}, []byte("abcde"), true},
+		{bytes.Repeat([]byte{8}, 8), []byte{}, true},
+		{[]byte{'a', 'b', 'c', 'd', 'e', 'f', 'g', 1}, []byte("abcdefg"), true},
+		{[]byte{'a', 'b', 'c', 'd', 'e', 2, 3, 3}, nil, false},
+		{[]byte{'a', 'b', 'c', 'd', 'e', 'f', 'g', 0}, nil, false},
+		{[]byte{'a', 'b', 'c', 'd', 'e', 'f', 'g', 9}, nil, false},
+		{[]byte{1, 2, 3}, nil, false},
+		{nil, nil, false},
+	}
+	for i, c := range cases {
+		got, err := PKCS5UnPaddingSafe(c.src, 8)
+		if (err == nil) != c.ok || !bytes.Equal(got, c.want) {
+			t.Fatalf("case %d: got %v, %v", i, got, err)
+		}
+	}
+	if padded := PKCS5Padding([]byte("abc"), 16); len(padded) != 16 || padded[15] != 13 {
+		t.Fatalf("unexpected padding %v", padded)
+	}
+}
@@ -0,0 +1,20 @@
+package securityutils
+
+import (
+	"crypto/sha512"
+	"fmt"
+)
+
+//sha512单向加密 128位
+func Sha512(src string) string {
+	hash := sha512.New()
+	hash.Write([]byte(src))
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+//sha512单向加密
+func Sha512Byte(src []byte) []byte {
+	hash := sha512.New()
+	hash.Write(src)
+	return hash.Sum(nil)
+}
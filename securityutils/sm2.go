@@ -0,0 +1,621 @@
+package securityutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"sync"
+)
+
+// SM2椭圆曲线公钥密码算法（GB/T 32918-2016），使用推荐曲线sm2p256v1
+// 密钥复用*ecdsa.PrivateKey、*ecdsa.PublicKey结构保存，Curve为Sm2P256()
+// 注意：SM2密钥不能传给crypto/ecdsa、crypto/x509的函数使用
+// 私钥d、随机数k参与的标量乘法使用sm2p256.go中的常量时间实现，验签使用Sm2P256()的通用实现
+
+//Sm2CipherMode SM2密文排列方式
+type Sm2CipherMode int
+
+const (
+	Sm2C1C3C2 Sm2CipherMode = iota //GM/T 0003-2012 标准格式
+	Sm2C1C2C3                      //旧版格式，兼容部分早期实现
+)
+
+//Sm2DefaultUID 签名默认用户身份标识
+var Sm2DefaultUID = []byte("1234567812345678")
+
+var (
+	oidPublicKeyEC = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSm2P256     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+)
+
+var (
+	sm2Once  sync.Once
+	sm2Curve *elliptic.CurveParams
+)
+
+//Sm2P256 SM2推荐曲线
+func Sm2P256() elliptic.Curve {
+	sm2Once.Do(func() {
+		sm2Curve = &elliptic.CurveParams{Name: "SM2-P-256", BitSize: 256}
+		sm2Curve.P, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF", 16)
+		sm2Curve.N, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123", 16)
+		sm2Curve.B, _ = new(big.Int).SetString("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93", 16)
+		sm2Curve.Gx, _ = new(big.Int).SetString("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7", 16)
+		sm2Curve.Gy, _ = new(big.Int).SetString("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0", 16)
+	})
+	return sm2Curve
+}
+
+// =================== 密钥 ======================
+//GenerateSm2Key 生成SM2私钥
+func GenerateSm2Key() (*ecdsa.PrivateKey, error) {
+	return generateSm2Key(rand.Reader)
+}
+
+func generateSm2Key(random io.Reader) (*ecdsa.PrivateKey, error) {
+	curve := Sm2P256()
+	// 私钥取值范围为[1, n-2]
+	nMinus1 := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	d, err := sm2RandScalar(random, nMinus1)
+	if err != nil {
+		return nil, err
+	}
+	return sm2KeyFromD(d), nil
+}
+
+func sm2KeyFromD(d *big.Int) *ecdsa.PrivateKey {
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = Sm2P256()
+	key.PublicKey.X, key.PublicKey.Y = sm2ScalarBaseMult(d)
+	return key
+}
+
+//GenerateSm2KeyPair 生成PEM格式的SM2密钥对，私钥为PKCS#8格式，公钥为PKIX格式
+func GenerateSm2KeyPair() (privateKey string, publicKey string, err error) {
+	key, err := GenerateSm2Key()
+	if err != nil {
+		return "", "", err
+	}
+	priv, err := Sm2PrivateKeyToPEM(key)
+	if err != nil {
+		return "", "", err
+	}
+	pub, err := Sm2PublicKeyToPEM(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	return string(priv), string(pub), nil
+}
+
+type sm2PKCS8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+type sm2ECPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+type sm2PKIX struct {
+	Algo      pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+//Sm2PrivateKeyToDER 导出DER格式(PKCS#8)私钥
+func Sm2PrivateKeyToDER(key *ecdsa.PrivateKey) ([]byte, error) {
+	if err := checkSm2Key(&key.PublicKey); err != nil {
+		return nil, err
+	}
+	ecKey, err := asn1.Marshal(sm2ECPrivateKey{
+		Version:    1,
+		PrivateKey: key.D.FillBytes(make([]byte, 32)),
+		PublicKey:  asn1.BitString{Bytes: sm2MarshalPoint(key.X, key.Y), BitLength: 65 * 8},
+	})
+	if err != nil {
+		return nil, err
+	}
+	algo, err := sm2AlgorithmIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2PKCS8{Algo: algo, PrivateKey: ecKey})
+}
+
+//Sm2PrivateKeyToPEM 导出PEM格式(PKCS#8)私钥
+func Sm2PrivateKeyToPEM(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := Sm2PrivateKeyToDER(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+//Sm2PublicKeyToDER 导出DER格式(PKIX)公钥
+func Sm2PublicKeyToDER(key *ecdsa.PublicKey) ([]byte, error) {
+	if err := checkSm2Key(key); err != nil {
+		return nil, err
+	}
+	algo, err := sm2AlgorithmIdentifier()
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2PKIX{
+		Algo:      algo,
+		PublicKey: asn1.BitString{Bytes: sm2MarshalPoint(key.X, key.Y), BitLength: 65 * 8},
+	})
+}
+
+//Sm2PublicKeyToPEM 导出PEM格式(PKIX)公钥
+func Sm2PublicKeyToPEM(key *ecdsa.PublicKey) ([]byte, error) {
+	der, err := Sm2PublicKeyToDER(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+//ParseSm2PrivateKey 解析SM2私钥，支持PEM与DER，PKCS#8与SEC1
+func ParseSm2PrivateKey(privateKey []byte) (*ecdsa.PrivateKey, error) {
+	der := pemOrDER(privateKey)
+	var pkcs8 sm2PKCS8
+	if rest, err := asn1.Unmarshal(der, &pkcs8); err == nil && len(rest) == 0 {
+		if !isSm2Algorithm(pkcs8.Algo) {
+			return nil, errors.New("private key is not sm2 key")
+		}
+		der = pkcs8.PrivateKey
+	}
+	var ecKey sm2ECPrivateKey
+	if _, err := asn1.Unmarshal(der, &ecKey); err != nil {
+		return nil, errors.New("private key error")
+	}
+	if len(ecKey.NamedCurveOID) > 0 && !ecKey.NamedCurveOID.Equal(oidSm2P256) {
+		return nil, errors.New("private key is not sm2 key")
+	}
+	d := new(big.Int).SetBytes(ecKey.PrivateKey)
+	nMinus1 := new(big.Int).Sub(Sm2P256().Params().N, big.NewInt(1))
+	if d.Sign() <= 0 || d.Cmp(nMinus1) >= 0 {
+		return nil, errors.New("private key error")
+	}
+	return sm2KeyFromD(d), nil
+}
+
+//ParseSm2PublicKey 解析SM2公钥，支持PEM与DER格式的PKIX公钥，以及04开头的65位未压缩公钥点
+func ParseSm2PublicKey(publicKey []byte) (*ecdsa.PublicKey, error) {
+	der := pemOrDER(publicKey)
+	point := der
+	var info sm2PKIX
+	if rest, err := asn1.Unmarshal(der, &info); err == nil && len(rest) == 0 {
+		if !isSm2Algorithm(info.Algo) {
+			return nil, errors.New("public key is not sm2 key")
+		}
+		point = info.PublicKey.RightAlign()
+	}
+	x, y, err := sm2UnmarshalPoint(point)
+	if err != nil {
+		return nil, errors.New("public key error")
+	}
+	return &ecdsa.PublicKey{Curve: Sm2P256(), X: x, Y: y}, nil
+}
+
+func sm2AlgorithmIdentifier() (pkix.AlgorithmIdentifier, error) {
+	params, err := asn1.Marshal(oidSm2P256)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyEC, Parameters: asn1.RawValue{FullBytes: params}}, nil
+}
+
+// 兼容 id-ecPublicKey + sm2p256v1 参数，以及直接使用sm2p256v1作为算法标识两种写法
+func isSm2Algorithm(algo pkix.AlgorithmIdentifier) bool {
+	if algo.Algorithm.Equal(oidSm2P256) {
+		return true
+	}
+	if !algo.Algorithm.Equal(oidPublicKeyEC) {
+		return false
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &curve); err != nil {
+		return false
+	}
+	return curve.Equal(oidSm2P256)
+}
+
+func checkSm2Key(key *ecdsa.PublicKey) error {
+	if key == nil || key.X == nil || key.Y == nil {
+		return errors.New("sm2 key can not be nil")
+	}
+	if key.Curve != Sm2P256() {
+		return errors.New("key is not on sm2 curve")
+	}
+	return nil
+}
+
+func sm2MarshalPoint(x, y *big.Int) []byte {
+	buf := make([]byte, 65)
+	buf[0] = 4
+	x.FillBytes(buf[1:33])
+	y.FillBytes(buf[33:])
+	return buf
+}
+
+func sm2UnmarshalPoint(data []byte) (*big.Int, *big.Int, error) {
+	if len(data) != 65 || data[0] != 4 {
+		return nil, nil, errors.New("sm2: invalid point encoding")
+	}
+	x := new(big.Int).SetBytes(data[1:33])
+	y := new(big.Int).SetBytes(data[33:])
+	curve := Sm2P256()
+	if x.Cmp(curve.Params().P) >= 0 || y.Cmp(curve.Params().P) >= 0 || !curve.IsOnCurve(x, y) {
+		return nil, nil, errors.New("sm2: point is not on curve")
+	}
+	return x, y, nil
+}
+
+// 在[1, max)范围内均匀选取随机数
+func sm2RandScalar(random io.Reader, max *big.Int) (*big.Int, error) {
+	buf := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(buf)
+		if k.Sign() > 0 && k.Cmp(max) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// =================== 签名 ======================
+// SM2签名（SM3摘要），返回base64编码的ASN.1签名，uid为非必需参数，默认Sm2DefaultUID
+func Sm2Sign(src string, privateKey string, uid ...[]byte) (string, error) {
+	return sm2Sign(src, []byte(privateKey), uid)
+}
+
+// SM2签名（SM3摘要），返回base64编码的ASN.1签名，uid为非必需参数，默认Sm2DefaultUID
+func Sm2SignPath(src string, privateKeyPath string, uid ...[]byte) (string, error) {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return sm2Sign(src, privateKey, uid)
+}
+
+func sm2Sign(src string, privateKey []byte, uid [][]byte) (string, error) {
+	key, err := ParseSm2PrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	sign, err := Sm2SignByte([]byte(src), key, uid...)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sign), nil
+}
+
+// SM2验签，sign为base64编码的ASN.1签名，验签失败返回error
+func Sm2Verify(src string, sign string, publicKey string, uid ...[]byte) error {
+	return sm2Verify(src, sign, []byte(publicKey), uid)
+}
+
+// SM2验签，sign为base64编码的ASN.1签名，验签失败返回error
+func Sm2VerifyPath(src string, sign string, publicKeyPath string, uid ...[]byte) error {
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	return sm2Verify(src, sign, publicKey, uid)
+}
+
+func sm2Verify(src string, sign string, publicKey []byte, uid [][]byte) error {
+	key, err := ParseSm2PublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	signByte, err := base64.StdEncoding.DecodeString(sign)
+	if err != nil {
+		return err
+	}
+	return Sm2VerifyByte([]byte(src), signByte, key, uid...)
+}
+
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+// SM2签名，返回ASN.1格式签名，uid为非必需参数，默认Sm2DefaultUID
+func Sm2SignByte(src []byte, key *ecdsa.PrivateKey, uid ...[]byte) ([]byte, error) {
+	if key == nil {
+		return nil, errors.New("private key can not be nil")
+	}
+	r, s, err := sm2SignWithRand(rand.Reader, src, key, sm2UID(uid))
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2Signature{R: r, S: s})
+}
+
+func sm2SignWithRand(random io.Reader, src []byte, key *ecdsa.PrivateKey, uid []byte) (r, s *big.Int, err error) {
+	if err := checkSm2Key(&key.PublicKey); err != nil {
+		return nil, nil, err
+	}
+	e, err := sm2Digest(src, &key.PublicKey, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	curve := Sm2P256()
+	n := curve.Params().N
+	dInv := new(big.Int).Add(key.D, big.NewInt(1))
+	dInv.ModInverse(dInv, n)
+	for {
+		k, err := sm2RandScalar(random, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		x1, _ := sm2ScalarBaseMult(k)
+		// r = (e + x1) mod n，r为0或r+k=n时重新选取k
+		r = new(big.Int).Add(e, x1)
+		r.Mod(r, n)
+		if r.Sign() == 0 || new(big.Int).Add(r, k).Cmp(n) == 0 {
+			continue
+		}
+		// s = ((1 + d)^-1 * (k - r*d)) mod n
+		s = new(big.Int).Mul(r, key.D)
+		s.Sub(k, s)
+		s.Mul(s, dInv)
+		s.Mod(s, n)
+		if s.Sign() != 0 {
+			return r, s, nil
+		}
+	}
+}
+
+// SM2验签，sign为ASN.1格式签名，验签失败返回error
+func Sm2VerifyByte(src []byte, sign []byte, key *ecdsa.PublicKey, uid ...[]byte) error {
+	if err := checkSm2Key(key); err != nil {
+		return err
+	}
+	var sig sm2Signature
+	if rest, err := asn1.Unmarshal(sign, &sig); err != nil || len(rest) != 0 || sig.R == nil || sig.S == nil {
+		return errors.New("sm2 signature format error")
+	}
+	if !sm2VerifyRS(src, sig.R, sig.S, key, sm2UID(uid)) {
+		return errors.New("sm2 verification error")
+	}
+	return nil
+}
+
+func sm2VerifyRS(src []byte, r, s *big.Int, key *ecdsa.PublicKey, uid []byte) bool {
+	curve := Sm2P256()
+	n := curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	e, err := sm2Digest(src, key, uid)
+	if err != nil {
+		return false
+	}
+	t := new(big.Int).Add(r, s)
+	t.Mod(t, n)
+	if t.Sign() == 0 {
+		return false
+	}
+	x1, y1 := curve.ScalarBaseMult(s.Bytes())
+	x2, y2 := curve.ScalarMult(key.X, key.Y, t.Bytes())
+	x, _ := curve.Add(x1, y1, x2, y2)
+	x.Add(x, e)
+	x.Mod(x, n)
+	return x.Cmp(r) == 0
+}
+
+// e = SM3(Z || M)，Z = SM3(ENTL || ID || a || b || Gx || Gy || xA || yA)
+func sm2Digest(src []byte, key *ecdsa.PublicKey, uid []byte) (*big.Int, error) {
+	if len(uid) >= 8192 {
+		return nil, errors.New("sm2 uid is too long")
+	}
+	params := Sm2P256().Params()
+	a := new(big.Int).Sub(params.P, big.NewInt(3))
+	h := NewSm3()
+	var entl [2]byte
+	binary.BigEndian.PutUint16(entl[:], uint16(len(uid)*8))
+	h.Write(entl[:])
+	h.Write(uid)
+	for _, v := range []*big.Int{a, params.B, params.Gx, params.Gy, key.X, key.Y} {
+		h.Write(v.FillBytes(make([]byte, 32)))
+	}
+	z := h.Sum(nil)
+	h.Reset()
+	h.Write(z)
+	h.Write(src)
+	return new(big.Int).SetBytes(h.Sum(nil)), nil
+}
+
+func sm2UID(uid [][]byte) []byte {
+	if len(uid) > 0 && uid[0] != nil {
+		return uid[0]
+	}
+	return Sm2DefaultUID
+}
+
+// =================== 加解密 ======================
+// SM2加密，返回base64编码的密文，mode为非必需参数，默认Sm2C1C3C2
+func Sm2Encrypt(src string, publicKey string, mode ...Sm2CipherMode) (string, error) {
+	return sm2Encrypt(src, []byte(publicKey), mode)
+}
+
+// SM2加密，返回base64编码的密文，mode为非必需参数，默认Sm2C1C3C2
+func Sm2EncryptPath(src string, publicKeyPath string, mode ...Sm2CipherMode) (string, error) {
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return sm2Encrypt(src, publicKey, mode)
+}
+
+func sm2Encrypt(src string, publicKey []byte, mode []Sm2CipherMode) (string, error) {
+	key, err := ParseSm2PublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	dst, err := Sm2EncryptByte([]byte(src), key, mode...)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(dst), nil
+}
+
+// SM2解密，src为base64编码的密文，mode为非必需参数，默认Sm2C1C3C2
+func Sm2Decrypt(src string, privateKey string, mode ...Sm2CipherMode) (string, error) {
+	return sm2Decrypt(src, []byte(privateKey), mode)
+}
+
+// SM2解密，src为base64编码的密文，mode为非必需参数，默认Sm2C1C3C2
+func Sm2DecryptPath(src string, privateKeyPath string, mode ...Sm2CipherMode) (string, error) {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	return sm2Decrypt(src, privateKey, mode)
+}
+
+func sm2Decrypt(src string, privateKey []byte, mode []Sm2CipherMode) (string, error) {
+	key, err := ParseSm2PrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	srcByte, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return "", err
+	}
+	dst, err := Sm2DecryptByte(srcByte, key, mode...)
+	if err != nil {
+		return "", err
+	}
+	return string(dst), nil
+}
+
+// SM2加密，密文为 C1(04||x||y) || C3 || C2，mode为非必需参数，默认Sm2C1C3C2
+func Sm2EncryptByte(src []byte, key *ecdsa.PublicKey, mode ...Sm2CipherMode) ([]byte, error) {
+	return sm2EncryptWithRand(rand.Reader, src, key, mode...)
+}
+
+func sm2EncryptWithRand(random io.Reader, src []byte, key *ecdsa.PublicKey, mode ...Sm2CipherMode) ([]byte, error) {
+	if err := checkSm2Key(key); err != nil {
+		return nil, err
+	}
+	if len(src) == 0 {
+		return nil, errors.New("src can not be empty")
+	}
+	curve := Sm2P256()
+	for {
+		k, err := sm2RandScalar(random, curve.Params().N)
+		if err != nil {
+			return nil, err
+		}
+		x1, y1 := sm2ScalarBaseMult(k)
+		x2, y2 := sm2ScalarMult(key.X, key.Y, k)
+		x2b, y2b := x2.FillBytes(make([]byte, 32)), y2.FillBytes(make([]byte, 32))
+		t := sm3KDF(len(src), x2b, y2b)
+		if isAllZero(t) {
+			continue
+		}
+		c2 := xorBytes(src, t)
+		c3 := sm3Sum(x2b, src, y2b)
+		c1 := sm2MarshalPoint(x1, y1)
+
+		dst := make([]byte, 0, len(c1)+len(c3)+len(c2))
+		dst = append(dst, c1...)
+		if len(mode) > 0 && mode[0] == Sm2C1C2C3 {
+			return append(append(dst, c2...), c3...), nil
+		}
+		return append(append(dst, c3...), c2...), nil
+	}
+}
+
+// SM2解密，src为 C1(04||x||y) || C3 || C2 格式的密文，mode为非必需参数，默认Sm2C1C3C2
+func Sm2DecryptByte(src []byte, key *ecdsa.PrivateKey, mode ...Sm2CipherMode) ([]byte, error) {
+	if key == nil {
+		return nil, errors.New("private key can not be nil")
+	}
+	if err := checkSm2Key(&key.PublicKey); err != nil {
+		return nil, err
+	}
+	if len(src) <= 65+Sm3Size {
+		return nil, errors.New("src is too short")
+	}
+	x1, y1, err := sm2UnmarshalPoint(src[:65])
+	if err != nil {
+		return nil, err
+	}
+	var c2, c3 []byte
+	if len(mode) > 0 && mode[0] == Sm2C1C2C3 {
+		c2, c3 = src[65:len(src)-Sm3Size], src[len(src)-Sm3Size:]
+	} else {
+		c3, c2 = src[65:65+Sm3Size], src[65+Sm3Size:]
+	}
+	x2, y2 := sm2ScalarMult(x1, y1, key.D)
+	x2b, y2b := x2.FillBytes(make([]byte, 32)), y2.FillBytes(make([]byte, 32))
+	t := sm3KDF(len(c2), x2b, y2b)
+	if isAllZero(t) {
+		return nil, errors.New("sm2 decryption error")
+	}
+	dst := xorBytes(c2, t)
+	if subtle.ConstantTimeCompare(sm3Sum(x2b, dst, y2b), c3) != 1 {
+		return nil, errors.New("sm2 decryption error")
+	}
+	return dst, nil
+}
+
+// GM/T 0003 密钥派生函数，基于SM3
+func sm3KDF(length int, z ...[]byte) []byte {
+	dst := make([]byte, 0, length+Sm3Size)
+	h := NewSm3()
+	var ct [4]byte
+	for i := uint32(1); len(dst) < length; i++ {
+		binary.BigEndian.PutUint32(ct[:], i)
+		h.Reset()
+		for _, b := range z {
+			h.Write(b)
+		}
+		h.Write(ct[:])
+		dst = h.Sum(dst)
+	}
+	return dst[:length]
+}
+
+func sm3Sum(src ...[]byte) []byte {
+	h := NewSm3()
+	for _, b := range src {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+func xorBytes(a, b []byte) []byte {
+	dst := make([]byte, len(a))
+	for i := range a {
+		dst[i] = a[i] ^ b[i]
+	}
+	return dst
+}
+
+func isAllZero(b []byte) bool {
+	var v byte
+	for _, c := range b {
+		v |= c
+	}
+	return v == 0
+}
@@ -0,0 +1,289 @@
+package securityutils
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// SM2曲线的常量时间标量乘法，用于私钥d、随机数k等秘密标量参与的运算
+// 域元素使用4个64位limb的Montgomery形式（R=2^256），点使用射影坐标与完备加法公式，
+// 标量按4位固定窗口处理并以常量时间查表，运算次数与内存访问不依赖标量的值
+// 验签只涉及公开数据，仍使用Sm2P256()的通用实现
+
+// 域元素，小端序limb，Montgomery形式
+type sm2Element [4]uint64
+
+var (
+	// p = 2^256 - 2^224 - 2^96 + 2^64 - 1
+	sm2FieldP = sm2Element{0xffffffffffffffff, 0xffffffff00000000, 0xffffffffffffffff, 0xfffffffeffffffff}
+	// 2^512 mod p，用于转换为Montgomery形式
+	sm2FieldR2 = sm2Element{0x200000003, 0x2ffffffff, 0x100000001, 0x400000002}
+)
+
+// Montgomery乘法 z = x * y * R^-1 mod p
+// p ≡ -1 (mod 2^64)，因此 -p^-1 mod 2^64 = 1，每轮的约减因子即为t[0]
+func (z *sm2Element) mul(x, y *sm2Element) *sm2Element {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		// t += x * y[i]
+		var c uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(x[j], y[i])
+			var carry uint64
+			lo, carry = bits.Add64(lo, t[j], 0)
+			hi += carry
+			lo, carry = bits.Add64(lo, c, 0)
+			hi += carry
+			t[j], c = lo, hi
+		}
+		var carry uint64
+		t[4], carry = bits.Add64(t[4], c, 0)
+		t[5] = carry
+
+		// t = (t + m*p) / 2^64
+		m := t[0]
+		hi, lo := bits.Mul64(m, sm2FieldP[0])
+		_, carry = bits.Add64(lo, t[0], 0)
+		c = hi + carry
+		for j := 1; j < 4; j++ {
+			hi, lo = bits.Mul64(m, sm2FieldP[j])
+			lo, carry = bits.Add64(lo, t[j], 0)
+			hi += carry
+			lo, carry = bits.Add64(lo, c, 0)
+			hi += carry
+			t[j-1], c = lo, hi
+		}
+		t[3], carry = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + carry
+	}
+	// 结果小于2p，减去p后未借位则取差
+	var d sm2Element
+	var borrow uint64
+	d[0], borrow = bits.Sub64(t[0], sm2FieldP[0], 0)
+	d[1], borrow = bits.Sub64(t[1], sm2FieldP[1], borrow)
+	d[2], borrow = bits.Sub64(t[2], sm2FieldP[2], borrow)
+	d[3], borrow = bits.Sub64(t[3], sm2FieldP[3], borrow)
+	_, borrow = bits.Sub64(t[4], 0, borrow)
+	z.selectFrom(&d, &sm2Element{t[0], t[1], t[2], t[3]}, borrow)
+	return z
+}
+
+func (z *sm2Element) square(x *sm2Element) *sm2Element {
+	return z.mul(x, x)
+}
+
+// z = x + y mod p
+func (z *sm2Element) add(x, y *sm2Element) *sm2Element {
+	var t, d sm2Element
+	var carry, borrow uint64
+	t[0], carry = bits.Add64(x[0], y[0], 0)
+	t[1], carry = bits.Add64(x[1], y[1], carry)
+	t[2], carry = bits.Add64(x[2], y[2], carry)
+	t[3], carry = bits.Add64(x[3], y[3], carry)
+	d[0], borrow = bits.Sub64(t[0], sm2FieldP[0], 0)
+	d[1], borrow = bits.Sub64(t[1], sm2FieldP[1], borrow)
+	d[2], borrow = bits.Sub64(t[2], sm2FieldP[2], borrow)
+	d[3], borrow = bits.Sub64(t[3], sm2FieldP[3], borrow)
+	_, borrow = bits.Sub64(carry, 0, borrow)
+	z.selectFrom(&d, &t, borrow)
+	return z
+}
+
+// z = x - y mod p
+func (z *sm2Element) sub(x, y *sm2Element) *sm2Element {
+	var t sm2Element
+	var carry, borrow uint64
+	t[0], borrow = bits.Sub64(x[0], y[0], 0)
+	t[1], borrow = bits.Sub64(x[1], y[1], borrow)
+	t[2], borrow = bits.Sub64(x[2], y[2], borrow)
+	t[3], borrow = bits.Sub64(x[3], y[3], borrow)
+	// 借位时加回p
+	mask := -borrow
+	z[0], carry = bits.Add64(t[0], sm2FieldP[0]&mask, 0)
+	z[1], carry = bits.Add64(t[1], sm2FieldP[1]&mask, carry)
+	z[2], carry = bits.Add64(t[2], sm2FieldP[2]&mask, carry)
+	z[3], _ = bits.Add64(t[3], sm2FieldP[3]&mask, carry)
+	return z
+}
+
+// cond为1时 z = b，为0时 z = a
+func (z *sm2Element) selectFrom(a, b *sm2Element, cond uint64) {
+	mask := -cond
+	for i := range z {
+		z[i] = a[i] ^ (mask & (a[i] ^ b[i]))
+	}
+}
+
+// z = x^-1 mod p，按费马小定理计算 x^(p-2)，指数是公开常量，平方-乘的顺序不依赖x
+func (z *sm2Element) invert(x *sm2Element) *sm2Element {
+	e := sm2FieldP
+	e[0] -= 2
+	r := sm2FieldOne
+	for i := 255; i >= 0; i-- {
+		r.square(&r)
+		if (e[i/64]>>(uint(i)%64))&1 == 1 {
+			r.mul(&r, x)
+		}
+	}
+	*z = r
+	return z
+}
+
+func sm2ElementFromBig(v *big.Int) *sm2Element {
+	var buf [32]byte
+	v.FillBytes(buf[:])
+	z := new(sm2Element)
+	for i := range z {
+		z[i] = binary.BigEndian.Uint64(buf[24-8*i:])
+	}
+	return z.mul(z, &sm2FieldR2)
+}
+
+func (z *sm2Element) toBig() *big.Int {
+	t := new(sm2Element).mul(z, &sm2Element{1})
+	var buf [32]byte
+	for i := range t {
+		binary.BigEndian.PutUint64(buf[24-8*i:], t[i])
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+var (
+	sm2FieldOne = *new(sm2Element).mul(&sm2Element{1}, &sm2FieldR2)
+	sm2FieldB   = *sm2ElementFromBig(Sm2P256().Params().B)
+)
+
+// 射影坐标点 (X:Y:Z)，无穷远点为 (0:1:0)
+type sm2Point struct {
+	x, y, z sm2Element
+}
+
+func (q *sm2Point) setIdentity() *sm2Point {
+	q.x = sm2Element{}
+	q.y = sm2FieldOne
+	q.z = sm2Element{}
+	return q
+}
+
+// q = p1 + p2，a = -3 的完备加法公式（Renes、Costello、Batina，https://eprint.iacr.org/2015/1060 算法4），
+// 对p1 = p2及无穷远点同样成立，没有分支
+func (q *sm2Point) add(p1, p2 *sm2Point) *sm2Point {
+	var t0, t1, t2, t3, t4, x3, y3, z3 sm2Element
+	t0.mul(&p1.x, &p2.x)
+	t1.mul(&p1.y, &p2.y)
+	t2.mul(&p1.z, &p2.z)
+	t3.add(&p1.x, &p1.y)
+	t4.add(&p2.x, &p2.y)
+	t3.mul(&t3, &t4)
+	t4.add(&t0, &t1)
+	t3.sub(&t3, &t4)
+	t4.add(&p1.y, &p1.z)
+	x3.add(&p2.y, &p2.z)
+	t4.mul(&t4, &x3)
+	x3.add(&t1, &t2)
+	t4.sub(&t4, &x3)
+	x3.add(&p1.x, &p1.z)
+	y3.add(&p2.x, &p2.z)
+	x3.mul(&x3, &y3)
+	y3.add(&t0, &t2)
+	y3.sub(&x3, &y3)
+	z3.mul(&sm2FieldB, &t2)
+	x3.sub(&y3, &z3)
+	z3.add(&x3, &x3)
+	x3.add(&x3, &z3)
+	z3.sub(&t1, &x3)
+	x3.add(&t1, &x3)
+	y3.mul(&sm2FieldB, &y3)
+	t1.add(&t2, &t2)
+	t2.add(&t1, &t2)
+	y3.sub(&y3, &t2)
+	y3.sub(&y3, &t0)
+	t1.add(&y3, &y3)
+	y3.add(&t1, &y3)
+	t1.add(&t0, &t0)
+	t0.add(&t1, &t0)
+	t0.sub(&t0, &t2)
+	t1.mul(&t4, &y3)
+	t2.mul(&t0, &y3)
+	y3.mul(&x3, &z3)
+	y3.add(&y3, &t2)
+	x3.mul(&t3, &x3)
+	x3.sub(&x3, &t1)
+	z3.mul(&t4, &z3)
+	t1.mul(&t3, &t0)
+	z3.add(&z3, &t1)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// 常量时间查表，q = table[n]
+func (q *sm2Point) lookup(table *[16]sm2Point, n byte) {
+	q.setIdentity()
+	for i := range table {
+		cond := uint64(subtle.ConstantTimeByteEq(byte(i), n))
+		q.x.selectFrom(&q.x, &table[i].x, cond)
+		q.y.selectFrom(&q.y, &table[i].y, cond)
+		q.z.selectFrom(&q.z, &table[i].z, cond)
+	}
+}
+
+// q = k * p，scalar为32字节大端序标量
+func (q *sm2Point) scalarMult(p *sm2Point, scalar *[32]byte) *sm2Point {
+	var table [16]sm2Point
+	table[0].setIdentity()
+	table[1] = *p
+	for i := 2; i < 16; i++ {
+		table[i].add(&table[i-1], p)
+	}
+	var r, t sm2Point
+	r.setIdentity()
+	for i, b := range scalar {
+		if i != 0 {
+			for j := 0; j < 4; j++ {
+				r.add(&r, &r)
+			}
+		}
+		t.lookup(&table, b>>4)
+		r.add(&r, &t)
+		for j := 0; j < 4; j++ {
+			r.add(&r, &r)
+		}
+		t.lookup(&table, b&0xf)
+		r.add(&r, &t)
+	}
+	*q = r
+	return q
+}
+
+// 转换为仿射坐标，无穷远点返回(0, 0)
+func (q *sm2Point) affine() (x, y *big.Int) {
+	var zInv, ax, ay sm2Element
+	zInv.invert(&q.z)
+	ax.mul(&q.x, &zInv)
+	ay.mul(&q.y, &zInv)
+	return ax.toBig(), ay.toBig()
+}
+
+// 标量转换为32字节，超出范围（只可能来自外部构造的私钥）时先对n取模
+func sm2ScalarBytes(k *big.Int) *[32]byte {
+	if k.Sign() < 0 || k.BitLen() > 256 {
+		k = new(big.Int).Mod(k, Sm2P256().Params().N)
+	}
+	var buf [32]byte
+	k.FillBytes(buf[:])
+	return &buf
+}
+
+// 常量时间计算 k * (x, y)，(x, y)须为曲线上的点
+func sm2ScalarMult(x, y *big.Int, k *big.Int) (*big.Int, *big.Int) {
+	p := sm2Point{x: *sm2ElementFromBig(x), y: *sm2ElementFromBig(y), z: sm2FieldOne}
+	return new(sm2Point).scalarMult(&p, sm2ScalarBytes(k)).affine()
+}
+
+// 常量时间计算 k * G
+func sm2ScalarBaseMult(k *big.Int) (*big.Int, *big.Int) {
+	params := Sm2P256().Params()
+	return sm2ScalarMult(params.Gx, params.Gy, k)
+}
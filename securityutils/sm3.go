@@ -0,0 +1,163 @@
+package securityutils
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math/bits"
+)
+
+// SM3密码杂凑算法（GB/T 32905-2016），输出256位摘要
+const (
+	Sm3Size      = 32
+	Sm3BlockSize = 64
+)
+
+var sm3IV = [8]uint32{
+	0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600,
+	0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e,
+}
+
+type sm3Digest struct {
+	h   [8]uint32
+	x   [Sm3BlockSize]byte
+	nx  int
+	len uint64
+}
+
+//NewSm3 创建SM3 hash.Hash，可用于hmac.New等需要hash构造函数的场景
+func NewSm3() hash.Hash {
+	d := new(sm3Digest)
+	d.Reset()
+	return d
+}
+
+//sm3单向加密 64位
+func Sm3(src string) string {
+	return fmt.Sprintf("%x", Sm3Byte([]byte(src)))
+}
+
+//sm3单向加密
+func Sm3Byte(src []byte) []byte {
+	hash := NewSm3()
+	hash.Write(src)
+	return hash.Sum(nil)
+}
+
+//hmac-sm3单向秘钥key加密 64位
+func HmacSm3(src string, key string) string {
+	return fmt.Sprintf("%x", HmacSm3Byte([]byte(src), []byte(key)))
+}
+
+//hmac-sm3单向秘钥key加密
+func HmacSm3Byte(src []byte, key []byte) []byte {
+	hash := hmac.New(NewSm3, key)
+	hash.Write(src)
+	return hash.Sum(nil)
+}
+
+func (d *sm3Digest) Size() int { return Sm3Size }
+
+func (d *sm3Digest) BlockSize() int { return Sm3BlockSize }
+
+func (d *sm3Digest) Reset() {
+	d.h = sm3IV
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *sm3Digest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		p = p[c:]
+		if d.nx < Sm3BlockSize {
+			return n, nil
+		}
+		sm3Block(&d.h, d.x[:])
+		d.nx = 0
+	}
+	for len(p) >= Sm3BlockSize {
+		sm3Block(&d.h, p[:Sm3BlockSize])
+		p = p[Sm3BlockSize:]
+	}
+	d.nx = copy(d.x[:], p)
+	return n, nil
+}
+
+func (d *sm3Digest) Sum(in []byte) []byte {
+	// 复制一份，保证Sum之后仍可继续Write
+	d0 := *d
+	length := d0.len << 3
+	var pad [Sm3BlockSize + 8]byte
+	pad[0] = 0x80
+	padLen := 56 - int(d0.len%Sm3BlockSize)
+	if padLen <= 0 {
+		padLen += Sm3BlockSize
+	}
+	binary.BigEndian.PutUint64(pad[padLen:], length)
+	d0.Write(pad[:padLen+8])
+
+	var out [Sm3Size]byte
+	for i, v := range d0.h {
+		binary.BigEndian.PutUint32(out[i*4:], v)
+	}
+	return append(in, out[:]...)
+}
+
+func sm3P0(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17)
+}
+
+func sm3P1(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23)
+}
+
+// 压缩函数，处理一个64字节分组
+func sm3Block(h *[8]uint32, p []byte) {
+	var w [68]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(p[i*4:])
+	}
+	for j := 16; j < 68; j++ {
+		w[j] = sm3P1(w[j-16]^w[j-9]^bits.RotateLeft32(w[j-3], 15)) ^ bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+	}
+
+	a, b, c, d, e, f, g, hh := h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7]
+	for j := 0; j < 64; j++ {
+		var t, ff, gg uint32
+		if j < 16 {
+			t = 0x79cc4519
+			ff = a ^ b ^ c
+			gg = e ^ f ^ g
+		} else {
+			t = 0x7a879d8a
+			ff = (a & b) | (a & c) | (b & c)
+			gg = (e & f) | (^e & g)
+		}
+		a12 := bits.RotateLeft32(a, 12)
+		ss1 := bits.RotateLeft32(a12+e+bits.RotateLeft32(t, j%32), 7)
+		ss2 := ss1 ^ a12
+		tt1 := ff + d + ss2 + (w[j] ^ w[j+4])
+		tt2 := gg + hh + ss1 + w[j]
+		d = c
+		c = bits.RotateLeft32(b, 9)
+		b = a
+		a = tt1
+		hh = g
+		g = bits.RotateLeft32(f, 19)
+		f = e
+		e = sm3P0(tt2)
+	}
+	h[0] ^= a
+	h[1] ^= b
+	h[2] ^= c
+	h[3] ^= d
+	h[4] ^= e
+	h[5] ^= f
+	h[6] ^= g
+	h[7] ^= hh
+}
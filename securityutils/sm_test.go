@@ -0,0 +1,309 @@
+package securityutils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestSm3(t *testing.T) {
+	// GB/T 32905-2016 附录A
+	cases := map[string]string{
+		"abc":                      "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0",
+		strings.Repeat("abcd", 16): "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732",
+	}
+	for src, want := range cases {
+		if got := Sm3(src); got != want {
+			t.Fatalf("Sm3(%q) = %s", src, got)
+		}
+	}
+	// 分多次写入结果一致
+	h := NewSm3()
+	for _, c := range []string{"a", "b", "c"} {
+		h.Write([]byte(c))
+	}
+	if hex.EncodeToString(h.Sum(nil)) != cases["abc"] {
+		t.Fatal("incremental write mismatch")
+	}
+	if HmacSm3("m", "k1") == HmacSm3("m", "k2") {
+		t.Fatal("hmac should depend on the key")
+	}
+}
+
+func TestSm4Block(t *testing.T) {
+	// GB/T 32907-2016 附录A
+	key := HexDecodeString("0123456789abcdeffedcba9876543210")
+	block, err := NewSm4Cipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]byte, 16)
+	block.Encrypt(dst, key)
+	if hex.EncodeToString(dst) != "681edf34d206965e86b3e94f536e4246" {
+		t.Fatalf("encrypt mismatch: %x", dst)
+	}
+	block.Decrypt(dst, dst)
+	if !bytes.Equal(dst, key) {
+		t.Fatal("decrypt mismatch")
+	}
+	if _, err := NewSm4Cipher(key[:8]); err == nil {
+		t.Fatal("8 byte key was accepted")
+	}
+	// 同一密钥对明文迭代加密1000000次
+	if testing.Short() {
+		return
+	}
+	copy(dst, key)
+	for i := 0; i < 1000000; i++ {
+		block.Encrypt(dst, dst)
+	}
+	if hex.EncodeToString(dst) != "595298c7c6fd271f0402f804c33d3f66" {
+		t.Fatalf("1000000 rounds mismatch: %x", dst)
+	}
+}
+
+func TestSm4Modes(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	src := []byte("sm4 block cipher modes")
+
+	ct, err := Sm4EncryptECB(src, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := Sm4DecryptECB(ct, key); err != nil || !bytes.Equal(pt, src) {
+		t.Fatalf("ECB: %v", err)
+	}
+	ct, err = Sm4EncryptCBC(src, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := Sm4DecryptCBC(ct, key, iv); err != nil || !bytes.Equal(pt, src) {
+		t.Fatalf("CBC: %v", err)
+	}
+	if _, err := Sm4EncryptCBC(src, key); err == nil {
+		t.Fatal("missing iv was accepted")
+	}
+	if _, err := Sm4EncryptCBC(src, key, nil); err == nil {
+		t.Fatal("empty iv was accepted")
+	}
+	if _, err := Sm4DecryptCBC(ct, key); err == nil {
+		t.Fatal("missing iv was accepted")
+	}
+	if _, err := Sm4DecryptCBC(ct, key, iv[:8]); err == nil {
+		t.Fatal("short iv was accepted")
+	}
+
+	// 密钥错误时补位校验失败，返回error而不是panic
+	for i := 0; i < 32; i++ {
+		wrong := append([]byte{}, key...)
+		wrong[0] ^= byte(i + 1)
+		ecb, _ := Sm4EncryptECB(src, key)
+		if pt, err := Sm4DecryptECB(ecb, wrong); err == nil && bytes.Equal(pt, src) {
+			t.Fatal("ECB decrypted with wrong key")
+		}
+		if pt, err := Sm4DecryptCBC(ct, wrong, iv); err == nil && bytes.Equal(pt, src) {
+			t.Fatal("CBC decrypted with wrong key")
+		}
+	}
+	// 构造补位字节为0xff的密文
+	block, _ := NewSm4Cipher(key)
+	bad := bytes.Repeat([]byte{0xff}, 16)
+	block.Encrypt(bad, bad)
+	if _, err := Sm4DecryptECB(bad, key); err == nil {
+		t.Fatal("invalid padding was accepted")
+	}
+
+	safe, err := Sm4EncryptCBCSafe(src, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := Sm4DecryptCBCSafe(safe, key); err != nil || !bytes.Equal(pt, src) {
+		t.Fatalf("CBCSafe: %v", err)
+	}
+	if again, _ := Sm4EncryptCBCSafe(src, key); bytes.Equal(safe, again) {
+		t.Fatal("CBCSafe should use a random iv")
+	}
+}
+
+// 固定输出的随机源，用于复现标准示例中的随机数k
+type fixedReader []byte
+
+func (r fixedReader) Read(p []byte) (int, error) {
+	return copy(p, r), nil
+}
+
+func TestSm2Vector(t *testing.T) {
+	// GM/T 0003.5-2012 示例，推荐曲线
+	d, _ := new(big.Int).SetString("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8", 16)
+	key := sm2KeyFromD(d)
+	if hex.EncodeToString(key.X.Bytes()) != "09f9df311e5421a150dd7d161e4bc5c672179fad1833fc076bb08ff356f35020" ||
+		hex.EncodeToString(key.Y.Bytes()) != "ccea490ce26775a52dc6ea718cc1aa600aed05fbf35e084a6632f6072da9ad13" {
+		t.Fatal("public key mismatch")
+	}
+	k := fixedReader(HexDecodeString("59276E27D506861A16680F3AD9C02DCCEF3CC1FA3CDBE4CE6D54B80DEAC1BC21"))
+	r, s, err := sm2SignWithRand(k, []byte("message digest"), key, Sm2DefaultUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(r.Bytes()) != "f5a03b0648d2c4630eeac513e1bb81a15944da3827d5b74143ac7eaceee720b3" ||
+		hex.EncodeToString(s.Bytes()) != "b1b6aa29df212fd8763182bc0d421ca1bb9038fd1f7f42d4840b69c485bbc1aa" {
+		t.Fatalf("signature mismatch: r=%x s=%x", r, s)
+	}
+	if !sm2VerifyRS([]byte("message digest"), r, s, &key.PublicKey, Sm2DefaultUID) {
+		t.Fatal("standard signature was rejected")
+	}
+}
+
+func TestSm2ScalarMult(t *testing.T) {
+	// 常量时间实现与elliptic.CurveParams的通用实现结果一致
+	curve := Sm2P256()
+	n := curve.Params().N
+	scalars := []*big.Int{
+		big.NewInt(1), big.NewInt(2), big.NewInt(15), big.NewInt(16), big.NewInt(255),
+		new(big.Int).Sub(n, big.NewInt(1)), new(big.Int).Sub(n, big.NewInt(2)),
+		new(big.Int).Lsh(big.NewInt(1), 255),
+	}
+	for i := 0; i < 16; i++ {
+		k, err := sm2RandScalar(strings.NewReader(strings.Repeat(string(rune('a'+i)), 32)), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scalars = append(scalars, k)
+	}
+	px, py := curve.ScalarBaseMult(big.NewInt(7).Bytes())
+	for _, k := range scalars {
+		x, y := sm2ScalarBaseMult(k)
+		wantX, wantY := curve.ScalarBaseMult(k.Bytes())
+		if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Fatalf("ScalarBaseMult(%x): got (%x, %x), want (%x, %x)", k, x, y, wantX, wantY)
+		}
+		x, y = sm2ScalarMult(px, py, k)
+		wantX, wantY = curve.ScalarMult(px, py, k.Bytes())
+		if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Fatalf("ScalarMult(%x): got (%x, %x), want (%x, %x)", k, x, y, wantX, wantY)
+		}
+	}
+	// n*G为无穷远点
+	if x, y := sm2ScalarBaseMult(n); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatalf("n*G: got (%x, %x)", x, y)
+	}
+}
+
+func TestSm2SignVerify(t *testing.T) {
+	priv, pub, err := GenerateSm2KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign, err := Sm2Sign("message", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Sm2Verify("message", sign, pub); err != nil {
+		t.Fatal(err)
+	}
+	if err := Sm2Verify("messagE", sign, pub); err == nil {
+		t.Fatal("modified message was accepted")
+	}
+	if err := Sm2Verify("message", sign, pub, []byte("other uid")); err == nil {
+		t.Fatal("signature verified with a different uid")
+	}
+	uidSign, err := Sm2Sign("message", priv, []byte("alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Sm2Verify("message", uidSign, pub, []byte("alice@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	_, otherPub, _ := GenerateSm2KeyPair()
+	if err := Sm2Verify("message", sign, otherPub); err == nil {
+		t.Fatal("signature verified with another key")
+	}
+	// SM2密钥不能按ECDSA密钥解析
+	if _, err := ParseEcdsaPublicKey([]byte(pub)); err == nil {
+		t.Fatal("SM2 key parsed as ECDSA key")
+	}
+}
+
+func TestSm2EncryptVector(t *testing.T) {
+	// GM/T 0003.5-2012 加密示例，推荐曲线
+	d, _ := new(big.Int).SetString("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8", 16)
+	key := sm2KeyFromD(d)
+	src := []byte("encryption standard")
+	c1 := "04" +
+		"04ebfc718e8d1798620432268e77feb6415e2ede0e073c0f4f640ecd2e149a73" +
+		"e858f9d81e5430a57b36daab8f950a3c64e6ee6a63094d99283aff767e124df0"
+	c3 := "59983c18f809e262923c53aec295d30383b54e39d609d160afcb1908d0bd8766"
+	c2 := "21886ca989ca9c7d58087307ca93092d651efa"
+	cases := []struct {
+		mode Sm2CipherMode
+		want string
+	}{
+		{Sm2C1C3C2, c1 + c3 + c2},
+		{Sm2C1C2C3, c1 + c2 + c3},
+	}
+	for _, c := range cases {
+		k := fixedReader(HexDecodeString("59276E27D506861A16680F3AD9C02DCCEF3CC1FA3CDBE4CE6D54B80DEAC1BC21"))
+		ct, err := sm2EncryptWithRand(k, src, &key.PublicKey, c.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(ct); got != c.want {
+			t.Fatalf("mode %d: got %s, want %s", c.mode, got, c.want)
+		}
+		pt, err := Sm2DecryptByte(HexDecodeString(c.want), key, c.mode)
+		if err != nil || !bytes.Equal(pt, src) {
+			t.Fatalf("mode %d: decrypt standard ciphertext: %q, %v", c.mode, pt, err)
+		}
+	}
+}
+
+func TestSm2Encrypt(t *testing.T) {
+	key, err := GenerateSm2Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := []byte(strings.Repeat("encryption standard ", 5))
+	for _, mode := range []Sm2CipherMode{Sm2C1C3C2, Sm2C1C2C3} {
+		ct, err := Sm2EncryptByte(src, &key.PublicKey, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != 65+Sm3Size+len(src) {
+			t.Fatalf("mode %d: unexpected ciphertext size %d", mode, len(ct))
+		}
+		pt, err := Sm2DecryptByte(ct, key, mode)
+		if err != nil || !bytes.Equal(pt, src) {
+			t.Fatalf("mode %d: %v", mode, err)
+		}
+		for _, i := range []int{1, 65, 65 + Sm3Size, len(ct) - 1} {
+			bad := append([]byte{}, ct...)
+			bad[i] ^= 1
+			if _, err := Sm2DecryptByte(bad, key, mode); err == nil {
+				t.Fatalf("mode %d: tampered byte %d was accepted", mode, i)
+			}
+		}
+	}
+	ct, _ := Sm2EncryptByte(src, &key.PublicKey)
+	if _, err := Sm2DecryptByte(ct, key, Sm2C1C2C3); err == nil {
+		t.Fatal("cipher mode mismatch was accepted")
+	}
+	other, _ := GenerateSm2Key()
+	if _, err := Sm2DecryptByte(ct, other); err == nil {
+		t.Fatal("wrong key was accepted")
+	}
+	if _, err := Sm2DecryptByte(ct[:65+Sm3Size], key); err == nil {
+		t.Fatal("truncated ciphertext was accepted")
+	}
+
+	priv, pub, _ := GenerateSm2KeyPair()
+	s, err := Sm2Encrypt("hello", pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := Sm2Decrypt(s, priv); err != nil || pt != "hello" {
+		t.Fatalf("Sm2Decrypt: %v", err)
+	}
+}
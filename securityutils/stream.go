@@ -0,0 +1,334 @@
+package securityutils
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// 流式加密格式（版本1）：
+// | version(1) | algorithm(1) | chunkSize(4) | salt(16) | chunk... |
+// 每个分块独立使用AEAD加密认证：chunk = ciphertext | tag
+// 分块密钥由key与随机salt经HKDF-SHA256派生，每个流的密钥都不同
+// nonce由分块序号与最后一块标记组成，分块被删除、重排或截断都会导致解密失败
+const (
+	StreamVersion1 byte = 1
+
+	//DefaultStreamChunkSize 默认明文分块大小 64KB
+	DefaultStreamChunkSize = 64 * 1024
+
+	maxStreamChunkSize = 16 * 1024 * 1024
+	streamSaltSize     = 16
+	streamHeaderSize   = 2 + 4 + streamSaltSize
+	streamKeyInfo      = "gocommon stream v1"
+)
+
+//streamCipher 分块加解密的公共状态
+type streamCipher struct {
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	counter uint64
+}
+
+func newStreamCipher(header []byte, key []byte) (*streamCipher, error) {
+	if header[0] != StreamVersion1 {
+		return nil, errors.New("unsupported stream version")
+	}
+	alg := AeadAlgorithm(header[1])
+	salt := header[6:streamHeaderSize]
+	streamKey, err := HkdfSha256(key, salt, []byte(streamKeyInfo), len(key))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := NewAead(alg, streamKey)
+	if err != nil {
+		return nil, err
+	}
+	if aead.NonceSize() < 9 {
+		return nil, errors.New("nonce size is too small for stream")
+	}
+	return &streamCipher{
+		aead:   aead,
+		header: header,
+		nonce:  make([]byte, aead.NonceSize()),
+	}, nil
+}
+
+// nonce = 0... | counter(8) | last(1)
+func (s *streamCipher) nextNonce(last bool) ([]byte, error) {
+	if s.counter == ^uint64(0) {
+		return nil, errors.New("stream chunk counter overflow")
+	}
+	n := len(s.nonce)
+	binary.BigEndian.PutUint64(s.nonce[n-9:n-1], s.counter)
+	if last {
+		s.nonce[n-1] = 1
+	} else {
+		s.nonce[n-1] = 0
+	}
+	s.counter++
+	return s.nonce, nil
+}
+
+//encryptWriter 流式加密
+type encryptWriter struct {
+	w         io.Writer
+	sc        *streamCipher
+	buf       []byte
+	chunkSize int
+	out       []byte
+	closed    bool
+	err       error
+}
+
+//NewEncryptWriter 创建流式加密Writer，写入的明文按分块加密后写入w
+//必须调用Close写出最后一个分块，Close不会关闭w
+//chunkSize 明文分块大小，为非必需参数，默认64KB
+func NewEncryptWriter(w io.Writer, alg AeadAlgorithm, key []byte, chunkSize ...int) (io.WriteCloser, error) {
+	size := DefaultStreamChunkSize
+	if len(chunkSize) > 0 && chunkSize[0] > 0 {
+		size = chunkSize[0]
+	}
+	if size > maxStreamChunkSize {
+		return nil, errors.New("chunk size is too large")
+	}
+	header := make([]byte, streamHeaderSize)
+	header[0] = StreamVersion1
+	header[1] = byte(alg)
+	binary.BigEndian.PutUint32(header[2:6], uint32(size))
+	if _, err := io.ReadFull(rand.Reader, header[6:]); err != nil {
+		return nil, err
+	}
+	sc, err := newStreamCipher(header, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:         w,
+		sc:        sc,
+		buf:       make([]byte, 0, size),
+		chunkSize: size,
+		out:       make([]byte, 0, size+sc.aead.Overhead()),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.closed {
+		return 0, errors.New("write to closed encrypt writer")
+	}
+	total := 0
+	for len(p) > 0 {
+		// 分块已满且仍有数据，说明不是最后一块
+		if len(e.buf) == e.chunkSize {
+			if err := e.flush(false); err != nil {
+				return total, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):e.chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		total += n
+	}
+	return total, nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return e.err
+	}
+	e.closed = true
+	if e.err != nil {
+		return e.err
+	}
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	nonce, err := e.sc.nextNonce(last)
+	if err != nil {
+		e.err = err
+		return err
+	}
+	e.out = e.sc.aead.Seal(e.out[:0], nonce, e.buf, e.sc.header)
+	e.buf = e.buf[:0]
+	if _, err := e.w.Write(e.out); err != nil {
+		e.err = err
+		return err
+	}
+	return nil
+}
+
+//decryptReader 流式解密
+type decryptReader struct {
+	r        io.Reader
+	sc       *streamCipher
+	buf      []byte //密文分块，多读1字节用于判断是否为最后一块
+	have     int
+	plainBuf []byte
+	plain    []byte //未读取的明文
+	done     bool
+	err      error
+}
+
+//NewDecryptReader 创建流式解密Reader，从r读取NewEncryptWriter输出的密文
+//每个分块在返回前都已通过认证，流被截断时返回错误
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errors.New("src is too short, less than stream header size")
+		}
+		return nil, err
+	}
+	size := int(binary.BigEndian.Uint32(header[2:6]))
+	if size <= 0 || size > maxStreamChunkSize {
+		return nil, errors.New("invalid stream chunk size")
+	}
+	sc, err := newStreamCipher(header, key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:        r,
+		sc:       sc,
+		buf:      make([]byte, size+sc.aead.Overhead()+1),
+		plainBuf: make([]byte, 0, size),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.readChunk()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) readChunk() error {
+	n, err := io.ReadFull(d.r, d.buf[d.have:])
+	d.have += n
+	last := false
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		last = true
+	} else if err != nil {
+		return err
+	}
+	encSize := len(d.buf) - 1
+	if last {
+		encSize = d.have
+		if encSize < d.sc.aead.Overhead() {
+			return errors.New("stream is truncated")
+		}
+	}
+	nonce, err := d.sc.nextNonce(last)
+	if err != nil {
+		return err
+	}
+	plain, err := d.sc.aead.Open(d.plainBuf[:0], nonce, d.buf[:encSize], d.sc.header)
+	if err != nil {
+		return errors.New("stream chunk authentication failed or stream is truncated")
+	}
+	d.plain = plain
+	if last {
+		d.done = true
+		d.have = 0
+		return nil
+	}
+	// 保留多读的1字节作为下一块的开头
+	d.buf[0] = d.buf[encSize]
+	d.have = 1
+	return nil
+}
+
+//EncryptStream 从src读取明文，流式加密后写入dst，返回写入的明文字节数
+func EncryptStream(dst io.Writer, src io.Reader, alg AeadAlgorithm, key []byte, chunkSize ...int) (int64, error) {
+	w, err := NewEncryptWriter(dst, alg, key, chunkSize...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, src)
+	if err != nil {
+		return n, err
+	}
+	return n, w.Close()
+}
+
+//DecryptStream 从src读取密文，流式解密后写入dst，返回写入的明文字节数
+//认证失败时dst中可能已写入部分明文，调用方需丢弃
+func DecryptStream(dst io.Writer, src io.Reader, key []byte) (int64, error) {
+	r, err := NewDecryptReader(src, key)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(dst, r)
+}
+
+//EncryptFile 流式加密文件，dest所在目录不存在时自动创建
+func EncryptFile(srcPath string, destPath string, alg AeadAlgorithm, key []byte, chunkSize ...int) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeFileFrom(destPath, func(w io.Writer) error {
+		_, err := EncryptStream(w, src, alg, key, chunkSize...)
+		return err
+	})
+}
+
+//DecryptFile 流式解密文件，dest所在目录不存在时自动创建
+//解密先写入临时文件，全部分块认证通过后才替换dest，失败时不会留下部分明文
+func DecryptFile(srcPath string, destPath string, key []byte) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeFileFrom(destPath, func(w io.Writer) error {
+		_, err := DecryptStream(w, src, key)
+		return err
+	})
+}
+
+// 写入同目录下的临时文件（权限0600），同步到磁盘后重命名为destPath，失败时不会留下不完整的文件
+func writeFileFrom(destPath string, write func(w io.Writer) error) error {
+	dir := filepath.Dir(destPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(destPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), destPath)
+}
@@ -0,0 +1,178 @@
+package securityutils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testChunkSize = 100
+
+func encryptTestStream(t *testing.T, data []byte, alg AeadAlgorithm, key []byte) []byte {
+	t.Helper()
+	var ct bytes.Buffer
+	n, err := EncryptStream(&ct, bytes.NewReader(data), alg, key, testChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("EncryptStream returned %d, want %d", n, len(data))
+	}
+	return ct.Bytes()
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	for _, alg := range aeadAlgorithms {
+		key := aeadTestKey(t, alg)
+		// 覆盖空流、不满一块、恰好整块及多块的情况
+		for _, size := range []int{0, 1, testChunkSize - 1, testChunkSize, testChunkSize + 1, 3 * testChunkSize, 12345} {
+			data := make([]byte, size)
+			rand.Read(data)
+			ct := encryptTestStream(t, data, alg, key)
+			var pt bytes.Buffer
+			if _, err := DecryptStream(&pt, bytes.NewReader(ct), key); err != nil || !bytes.Equal(pt.Bytes(), data) {
+				t.Fatalf("%s size %d: round trip failed: %v", alg, size, err)
+			}
+		}
+	}
+}
+
+func TestStreamSmallWrites(t *testing.T) {
+	key := aeadTestKey(t, AeadAesGcm)
+	data := make([]byte, 1000)
+	rand.Read(data)
+	var ct bytes.Buffer
+	w, err := NewEncryptWriter(&ct, AeadAesGcm, key, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := w.Write(data[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatal("write after Close should fail")
+	}
+	r, err := NewDecryptReader(bytes.NewReader(ct.Bytes()), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(pt, data) {
+		t.Fatalf("round trip failed: %v", err)
+	}
+}
+
+func TestStreamTamper(t *testing.T) {
+	key := aeadTestKey(t, AeadChaCha20Poly1305)
+	data := make([]byte, 3*testChunkSize+10)
+	rand.Read(data)
+	ct := encryptTestStream(t, data, AeadChaCha20Poly1305, key)
+	for i := range ct {
+		bad := append([]byte{}, ct...)
+		bad[i] ^= 0x80
+		if _, err := DecryptStream(io.Discard, bytes.NewReader(bad), key); err == nil {
+			t.Fatalf("tampered byte %d was accepted", i)
+		}
+	}
+	if _, err := DecryptStream(io.Discard, bytes.NewReader(ct), aeadTestKey(t, AeadChaCha20Poly1305)); err == nil {
+		t.Fatal("wrong key was accepted")
+	}
+}
+
+func TestStreamTruncateAndReorder(t *testing.T) {
+	key := aeadTestKey(t, AeadAesGcm)
+	data := make([]byte, 3*testChunkSize+10)
+	rand.Read(data)
+	ct := encryptTestStream(t, data, AeadAesGcm, key)
+	enc := testChunkSize + 16
+	chunk := func(i int) []byte {
+		start := streamHeaderSize + i*enc
+		end := start + enc
+		if end > len(ct) {
+			end = len(ct)
+		}
+		return ct[start:end]
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	header := ct[:streamHeaderSize]
+	cases := map[string][]byte{
+		"header only":       header,
+		"short header":      ct[:streamHeaderSize-1],
+		"drop last chunk":   ct[:streamHeaderSize+3*enc],
+		"cut inside chunk":  ct[:len(ct)-1],
+		"cut at boundary":   ct[:streamHeaderSize+enc],
+		"drop middle chunk": join(header, chunk(0), chunk(2), chunk(3)),
+		"swap chunks":       join(header, chunk(1), chunk(0), chunk(2), chunk(3)),
+		"trailing data":     join(ct, []byte{0}),
+	}
+	for name, bad := range cases {
+		var pt bytes.Buffer
+		if _, err := DecryptStream(&pt, bytes.NewReader(bad), key); err == nil {
+			t.Fatalf("%s: was accepted", name)
+		}
+	}
+}
+
+func TestStreamChunkSize(t *testing.T) {
+	key := aeadTestKey(t, AeadAesGcm)
+	if _, err := NewEncryptWriter(io.Discard, AeadAesGcm, key, maxStreamChunkSize+1); err == nil {
+		t.Fatal("oversized chunk should be rejected")
+	}
+	ct := encryptTestStream(t, []byte("x"), AeadAesGcm, key)
+	bad := append([]byte{}, ct...)
+	copy(bad[2:6], []byte{0xFF, 0xFF, 0xFF, 0xFF})
+	if _, err := NewDecryptReader(bytes.NewReader(bad), key); err == nil {
+		t.Fatal("invalid chunk size in header should be rejected")
+	}
+}
+
+func TestEncryptFile(t *testing.T) {
+	dir := t.TempDir()
+	key := aeadTestKey(t, AeadAesGcm)
+	src := filepath.Join(dir, "plain.txt")
+	data := bytes.Repeat([]byte("secret "), 20000)
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	enc := filepath.Join(dir, "out", "plain.enc")
+	if err := EncryptFile(src, enc, AeadAesGcm, key); err != nil {
+		t.Fatal(err)
+	}
+	dec := filepath.Join(dir, "dec", "plain.txt")
+	if err := DecryptFile(enc, dec, key); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dec); !bytes.Equal(got, data) {
+		t.Fatal("decrypted file differs")
+	}
+	if info, err := os.Stat(dec); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("decrypted file should be 0600: %v", err)
+	}
+
+	// 认证失败时不能留下部分明文
+	ct, _ := os.ReadFile(enc)
+	ct[len(ct)-1] ^= 1
+	bad := filepath.Join(dir, "bad.enc")
+	os.WriteFile(bad, ct, 0644)
+	failed := filepath.Join(dir, "failed.txt")
+	if err := DecryptFile(bad, failed, key); err == nil {
+		t.Fatal("tampered file was accepted")
+	}
+	if _, err := os.Stat(failed); !os.IsNotExist(err) {
+		t.Fatal("failed decryption left a file behind")
+	}
+}
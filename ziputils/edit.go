@@ -0,0 +1,168 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/youngchan1988/gocommon/fileutils"
+)
+
+//ZipEditor 批量修改已有的zip：添加、替换、删除条目
+//修改在Commit时一次性完成：未修改的条目按原始压缩数据直接复制，新内容写入同目录的临时文件，成功后再替换原文件
+//Commit失败时原文件保持不变
+type ZipEditor struct {
+	path    string
+	opts    []StreamOptions
+	adds    []editAdd
+	deletes map[string]bool
+}
+
+type editAdd struct {
+	name     string
+	r        io.Reader
+	filePath string
+	modTime  time.Time
+}
+
+//NewZipEditor 创建zip编辑器，zipFile不存在时Commit会创建新文件
+//opts 为非必需参数，作用于新添加的条目（压缩方式、压缩级别、加密口令），Filter与Rename不生效
+func NewZipEditor(zipFile string, opts ...StreamOptions) *ZipEditor {
+	return &ZipEditor{path: zipFile, opts: opts, deletes: make(map[string]bool)}
+}
+
+//Add 添加条目，名称已存在时替换原条目，同一名称多次添加时以最后一次为准，r在Commit时读取
+func (e *ZipEditor) Add(name string, r io.Reader, modTime time.Time) *ZipEditor {
+	e.adds = append(e.adds, editAdd{name: streamEntryName(name), r: r, modTime: modTime})
+	return e
+}
+
+//AddFile 添加文件，name为非必需参数，默认为文件名，名称已存在时替换原条目，同一名称多次添加时以最后一次为准
+func (e *ZipEditor) AddFile(filePath string, name ...string) *ZipEditor {
+	entryName := filepath.Base(filePath)
+	if len(name) > 0 && name[0] != "" {
+		entryName = name[0]
+	}
+	e.adds = append(e.adds, editAdd{name: streamEntryName(entryName), filePath: filePath})
+	return e
+}
+
+//Delete 删除条目，名称以/结尾时删除整个目录
+func (e *ZipEditor) Delete(names ...string) *ZipEditor {
+	for _, name := range names {
+		e.deletes[streamEntryName(name)] = true
+	}
+	return e
+}
+
+//Commit 写入所有修改，要删除的条目不存在时返回ErrEntryNotFound且不修改原文件
+func (e *ZipEditor) Commit() error {
+	var files []*zip.File
+	var reader *zip.ReadCloser
+	if _, err := os.Stat(e.path); err == nil {
+		if reader, err = zip.OpenReader(e.path); err != nil {
+			return err
+		}
+		defer func() {
+			if reader != nil {
+				reader.Close()
+			}
+		}()
+		files = reader.File
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	// 同一名称多次添加时只保留最后一次
+	last := make(map[string]int, len(e.adds))
+	for i, add := range e.adds {
+		last[add.name] = i
+	}
+	deleted := make(map[string]bool, len(e.deletes))
+	var kept []*zip.File
+	for _, file := range files {
+		name := streamEntryName(file.Name)
+		if del, ok := e.deleteMatch(name); ok {
+			deleted[del] = true
+			continue
+		}
+		if _, ok := last[name]; ok {
+			continue
+		}
+		kept = append(kept, file)
+	}
+	for name := range e.deletes {
+		if !deleted[name] {
+			return ErrEntryNotFound
+		}
+	}
+
+	return fileutils.WriteFileAtomic(e.path, func(w io.Writer) error {
+		s := NewZipStream(w, e.opts...)
+		s.o.Filter, s.o.Rename = nil, nil
+		for _, file := range kept {
+			if err := s.zw.Copy(file); err != nil {
+				return err
+			}
+		}
+		for i, add := range e.adds {
+			if last[add.name] != i {
+				continue
+			}
+			if err := e.write(s, add); err != nil {
+				return err
+			}
+		}
+		if err := s.Close(); err != nil {
+			return err
+		}
+		// 替换前关闭原文件，Windows下无法重命名覆盖已打开的文件
+		if reader != nil {
+			err := reader.Close()
+			reader = nil
+			return err
+		}
+		return nil
+	}, fileutils.WriteOptions{CreateDir: true})
+}
+
+func (e *ZipEditor) deleteMatch(name string) (string, bool) {
+	if e.deletes[name] {
+		return name, true
+	}
+	for del := range e.deletes {
+		if strings.HasSuffix(del, "/") && strings.HasPrefix(name, del) {
+			return del, true
+		}
+	}
+	return "", false
+}
+
+func (e *ZipEditor) write(s *ZipStream, add editAdd) error {
+	if add.filePath == "" {
+		return s.AddReader(add.name, add.r, add.modTime)
+	}
+	info, err := os.Stat(add.filePath)
+	if err != nil {
+		return err
+	}
+	return s.addFile(add.filePath, info, add.name)
+}
+
+//AddToZip 向zip添加或替换一个条目，zipFile不存在时创建
+func AddToZip(zipFile string, name string, r io.Reader, modTime time.Time) error {
+	return NewZipEditor(zipFile).Add(name, r, modTime).Commit()
+}
+
+//AddFileToZip 向zip添加或替换一个文件，name为非必需参数，默认为文件名
+func AddFileToZip(zipFile string, filePath string, name ...string) error {
+	return NewZipEditor(zipFile).AddFile(filePath, name...).Commit()
+}
+
+//DeleteFromZip 从zip删除条目，名称以/结尾时删除整个目录
+func DeleteFromZip(zipFile string, names ...string) error {
+	return NewZipEditor(zipFile).Delete(names...).Commit()
+}
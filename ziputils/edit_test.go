@@ -0,0 +1,61 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestZipEditorDuplicateAdd(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"src/a.txt": "from file", "src/b.txt": "b"})
+	zipFile := filepath.Join(dir, "out.zip")
+	if err := AddFileToZip(zipFile, filepath.Join(dir, "src", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	err := NewZipEditor(zipFile).
+		Add("a.txt", strings.NewReader("first"), now).
+		AddFile(filepath.Join(dir, "src", "a.txt")).
+		Add("a.txt", strings.NewReader("last"), now).
+		Add("b.txt", strings.NewReader("replaced"), now).
+		Add("b.txt", strings.NewReader("replaced twice"), now).
+		Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := zipNames(t, data); !reflect.DeepEqual(names, []string{"a.txt", "b.txt"}) {
+		t.Fatalf("entries: %v", names)
+	}
+	zr, err := zip.OpenReader(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	want := map[string]string{"a.txt": "last", "b.txt": "replaced twice"}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want[f.Name] {
+			t.Fatalf("%s: got %q, want %q", f.Name, content, want[f.Name])
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+
+	"github.com/youngchan1988/gocommon/fileutils"
+	"github.com/youngchan1988/gocommon/securityutils"
+)
+
+//CompressFilesEncrypted 压缩文件并流式加密，明文压缩包不会落地
+//files 文件数组，可以是不同dir下的文件或者文件夹
+//dest 加密后的压缩文件存放地址
+func CompressFilesEncrypted(files []*os.File, dest string, alg securityutils.AeadAlgorithm, key []byte) error {
+	return compressEncrypted(dest, alg, key, func(zw *zip.Writer) error {
+		for _, file := range files {
+			if err := compress(file, "", zw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+//CompressDirEncrypted 压缩目录并流式加密，明文压缩包不会落地
+//dirPath 目录
+//dest 加密后的压缩文件存放地址
+func CompressDirEncrypted(dirPath string, dest string, alg securityutils.AeadAlgorithm, key []byte) error {
+	f, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return compressEncrypted(dest, alg, key, func(zw *zip.Writer) error {
+		return compress(f, "", zw)
+	})
+}
+
+//DeCompressEncrypted 解密并解压
+//密文先解密到临时文件，全部认证通过后再解压，临时文件用完即删除
+//opts 为非必需参数，同DeCompress
+func DeCompressEncrypted(zipFile string, dest string, key []byte, opts ...ExtractOptions) error {
+	src, err := os.Open(zipFile)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp("", "*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = securityutils.DecryptStream(tmp, src, key)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return DeCompress(tmp.Name(), dest, opts...)
+}
+
+func compressEncrypted(dest string, alg securityutils.AeadAlgorithm, key []byte, add func(zw *zip.Writer) error) error {
+	// 先写入临时文件，失败时不会在dest留下不完整的密文
+	return fileutils.WriteFileAtomic(dest, func(w io.Writer) error {
+		ew, err := securityutils.NewEncryptWriter(w, alg, key)
+		if err != nil {
+			return err
+		}
+		zw := zip.NewWriter(ew)
+		if err := add(zw); err != nil {
+			return err
+		}
+		return closeAll(zw, ew)
+	}, fileutils.WriteOptions{CreateDir: true})
+}
+
+// 按顺序关闭，返回第一个错误
+func closeAll(closers ...io.Closer) error {
+	var first error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
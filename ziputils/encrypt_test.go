@@ -0,0 +1,98 @@
+package ziputils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/youngchan1988/gocommon/securityutils"
+)
+
+// 在dir下按相对路径创建文件，内容为空字符串的以/结尾的路径创建目录
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// 检查dir下的文件内容
+func checkTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if info, err := os.Stat(p); err != nil || !info.IsDir() {
+				t.Fatalf("%s: expected directory: %v", name, err)
+			}
+			continue
+		}
+		got, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(got) != content {
+			t.Fatalf("%s: got %q, want %q", name, got, content)
+		}
+	}
+}
+
+func TestCompressDirEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, filepath.Join(dir, "src"), map[string]string{"a.txt": "aaa", "sub/b.txt": "bbb", "empty/": ""})
+	key, _ := securityutils.RandomBytes(32)
+	enc := filepath.Join(dir, "out", "src.zip.enc")
+	if err := CompressDirEncrypted(filepath.Join(dir, "src"), enc, securityutils.AeadChaCha20Poly1305, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeCompressEncrypted(enc, filepath.Join(dir, "dec"), key); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, filepath.Join(dir, "dec"), map[string]string{"src/a.txt": "aaa", "src/sub/b.txt": "bbb", "src/empty/": ""})
+
+	other, _ := securityutils.RandomBytes(32)
+	if err := DeCompressEncrypted(enc, filepath.Join(dir, "wrong"), other); err == nil {
+		t.Fatal("wrong key was accepted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "wrong")); !os.IsNotExist(err) {
+		t.Fatal("failed decryption should not extract anything")
+	}
+}
+
+func TestCompressEncryptedFailureLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, filepath.Join(dir, "src"), map[string]string{"a.txt": "aaa"})
+	enc := filepath.Join(dir, "src.zip.enc")
+	// 密钥长度不合法，加密失败
+	if err := CompressDirEncrypted(filepath.Join(dir, "src"), enc, securityutils.AeadAesGcm, []byte("short")); err == nil {
+		t.Fatal("invalid key was accepted")
+	}
+	if _, err := os.Stat(enc); !os.IsNotExist(err) {
+		t.Fatal("failed compression left a file at dest")
+	}
+	// 写入过程中出错时，已存在的dest保持不变
+	os.WriteFile(enc, []byte("old"), 0644)
+	f, err := os.Open(filepath.Join(dir, "src", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := CompressFilesEncrypted([]*os.File{f}, enc, securityutils.AeadAesGcm, make([]byte, 16)); err == nil {
+		t.Fatal("closed source file was accepted")
+	}
+	if b, _ := os.ReadFile(enc); string(b) != "old" {
+		t.Fatalf("dest was overwritten: %q", b)
+	}
+}
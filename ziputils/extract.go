@@ -0,0 +1,472 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 解压默认限制，防止zip炸弹耗尽磁盘
+const (
+	DefaultMaxTotalSize int64 = 1 << 30 //解压后总大小，1GiB
+	DefaultMaxFileSize  int64 = 1 << 30 //单个文件解压后大小，1GiB
+	DefaultMaxFiles           = 10000   //条目数量
+	DefaultMaxRatio           = 100     //单个文件压缩比
+
+	// 小于该大小的文件不检查压缩比，避免误判小的高压缩率文件
+	ratioCheckThreshold int64 = 1 << 20
+)
+
+var (
+	ErrIllegalPath     = errors.New("ziputils: illegal file path")
+	ErrTooManyFiles    = errors.New("ziputils: too many files")
+	ErrFileTooLarge    = errors.New("ziputils: file too large")
+	ErrArchiveTooLarge = errors.New("ziputils: total size too large")
+	ErrRatioTooHigh    = errors.New("ziputils: compression ratio too high")
+	ErrSymlink         = errors.New("ziputils: symlink not allowed")
+)
+
+//SymlinkPolicy 符号链接条目的处理方式
+type SymlinkPolicy int
+
+const (
+	SymlinkSkip   SymlinkPolicy = iota //忽略符号链接条目（默认）
+	SymlinkReject                      //遇到符号链接条目返回ErrSymlink
+	SymlinkInside                      //仅允许指向dest内部的相对链接，否则返回ErrSymlink
+)
+
+//ExtractOptions 解压限制，零值字段使用默认值，负数表示不限制
+type ExtractOptions struct {
+	MaxTotalSize int64 //解压后总大小，默认DefaultMaxTotalSize
+	MaxFileSize  int64 //单个文件解压后大小，默认DefaultMaxFileSize
+	MaxFiles     int   //条目数量（含目录），默认DefaultMaxFiles
+	MaxRatio     int   //单个文件解压后大小与压缩大小之比，默认DefaultMaxRatio
+	Symlinks     SymlinkPolicy
+}
+
+func extractOptions(opts []ExtractOptions) ExtractOptions {
+	var o ExtractOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.MaxTotalSize == 0 {
+		o.MaxTotalSize = DefaultMaxTotalSize
+	}
+	if o.MaxFileSize == 0 {
+		o.MaxFileSize = DefaultMaxFileSize
+	}
+	if o.MaxFiles == 0 {
+		o.MaxFiles = DefaultMaxFiles
+	}
+	if o.MaxRatio == 0 {
+		o.MaxRatio = DefaultMaxRatio
+	}
+	return o
+}
+
+// 解压条目到dest，条目名称经过校验，所有写入都限制在dest内
+// 限制先按文件头声明的大小预检，再按实际解压出的字节数校验，防止文件头伪造
+// open 打开条目内容，用于支持加密条目
+func extract(files []*zip.File, dest string, o ExtractOptions, open func(file *zip.File) (io.ReadCloser, error)) error {
+	if o.MaxFiles > 0 && len(files) > o.MaxFiles {
+		return ErrTooManyFiles
+	}
+	var declared uint64
+	for _, file := range files {
+		declared += file.UncompressedSize64
+		if o.MaxFileSize > 0 && file.UncompressedSize64 > uint64(o.MaxFileSize) {
+			return fmt.Errorf("%w: %s", ErrFileTooLarge, file.Name)
+		}
+	}
+	if o.MaxTotalSize > 0 && declared > uint64(o.MaxTotalSize) {
+		return ErrArchiveTooLarge
+	}
+	e, err := newExtractor(dest, o, nil)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := e.next(); err != nil {
+			return err
+		}
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			err = e.dir(file.Name, entryMeta{mode: mode, modTime: file.Modified})
+		case mode&os.ModeSymlink != 0:
+			err = e.symlink(file.Name, func() (string, error) {
+				rc, err := open(file)
+				if err != nil {
+					return "", err
+				}
+				defer rc.Close()
+				link, err := io.ReadAll(io.LimitReader(rc, 4096))
+				return string(link), err
+			})
+		default:
+			var rc io.ReadCloser
+			if rc, err = open(file); err != nil {
+				return err
+			}
+			err = e.file(file.Name, rc, int64(file.CompressedSize64), entryMeta{mode: mode, modTime: file.Modified})
+			rc.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.finish()
+}
+
+// 条目的权限与修改时间，零值表示不设置
+type entryMeta struct {
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// 设置权限位与修改时间
+func (m entryMeta) apply(target string) error {
+	if perm := m.mode.Perm(); perm != 0 {
+		if err := os.Chmod(target, perm); err != nil {
+			return err
+		}
+	}
+	if !m.modTime.IsZero() {
+		return os.Chtimes(target, m.modTime, m.modTime)
+	}
+	return nil
+}
+
+// 解压写入器，tar与zip共用：校验条目名称，累计条目数量与解压大小
+type extractor struct {
+	root       string
+	o          ExtractOptions
+	count      int
+	total      int64
+	compressed func() int64 //已读取的压缩数据大小，按整体计算压缩比，zip按单个文件计算时为nil
+	match      func(name string) bool
+	dirs       []dirMeta //目录的权限与修改时间在所有条目写入后设置，避免写入子条目时被修改或因只读无法写入
+	links      []linkMeta
+	linkIndex  map[string]int //条目名称在links中的位置，同名条目后出现的覆盖先出现的
+}
+
+type dirMeta struct {
+	path string
+	meta entryMeta
+}
+
+// 符号链接在所有条目写入后创建，解压过程中dest内不会出现归档中的链接
+type linkMeta struct {
+	name     string //清理后的条目名称
+	target   string //链接在dest中的路径
+	linkName string //以/分隔的链接目标
+}
+
+func newExtractor(dest string, o ExtractOptions, compressed func() int64) (*extractor, error) {
+	root, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &extractor{root: root, o: o, compressed: compressed, linkIndex: make(map[string]int)}, nil
+}
+
+func (e *extractor) next() error {
+	e.count++
+	if e.o.MaxFiles > 0 && e.count > e.o.MaxFiles {
+		return ErrTooManyFiles
+	}
+	return nil
+}
+
+// 返回条目在dest中的路径，条目即dest本身时返回空字符串
+func (e *extractor) target(name string) (string, string, error) {
+	clean, err := cleanEntryName(name)
+	if err != nil || clean == "" {
+		return "", "", err
+	}
+	if err := checkParents(e.root, clean); err != nil {
+		return "", "", err
+	}
+	return clean, filepath.Join(e.root, filepath.FromSlash(clean)), nil
+}
+
+// 同名条目后出现的覆盖先出现的，取消尚未创建的同名链接
+func (e *extractor) replace(name string) {
+	if i, ok := e.linkIndex[name]; ok {
+		e.links[i].name = ""
+		delete(e.linkIndex, name)
+	}
+}
+
+func (e *extractor) dir(name string, meta entryMeta) error {
+	clean, target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	e.replace(clean)
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	e.dirs = append(e.dirs, dirMeta{path: target, meta: meta})
+	return nil
+}
+
+// 所有条目写入后创建符号链接，再设置目录的权限与修改时间，子目录先于父目录设置
+func (e *extractor) finish() error {
+	for _, l := range e.links {
+		if l.name == "" {
+			continue
+		}
+		if err := e.createSymlink(l); err != nil {
+			return err
+		}
+	}
+	for i := len(e.dirs) - 1; i >= 0; i-- {
+		if err := e.dirs[i].meta.apply(e.dirs[i].path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//compressed 为条目压缩后的大小，用于计算单个文件的压缩比，小于0时按整体计算
+func (e *extractor) file(name string, r io.Reader, compressed int64, meta entryMeta) error {
+	clean, target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	e.replace(clean)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := removeSymlink(target); err != nil {
+		return err
+	}
+	w, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	limit := e.o.MaxFileSize
+	if e.o.MaxTotalSize > 0 && (limit < 0 || e.o.MaxTotalSize-e.total < limit) {
+		limit = e.o.MaxTotalSize - e.total
+	}
+	if limit >= 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	n, err := io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	e.total += n
+	if err == nil {
+		err = e.check(name, n, limit, compressed)
+	}
+	if err == nil {
+		err = meta.apply(target)
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+	return nil
+}
+
+func (e *extractor) check(name string, n int64, limit int64, compressed int64) error {
+	if limit >= 0 && n > limit {
+		if e.o.MaxFileSize > 0 && n > e.o.MaxFileSize {
+			return fmt.Errorf("%w: %s", ErrFileTooLarge, name)
+		}
+		return ErrArchiveTooLarge
+	}
+	if e.o.MaxRatio <= 0 {
+		return nil
+	}
+	if compressed < 0 && e.compressed != nil {
+		n, compressed = e.total, e.compressed()
+	}
+	if compressed >= 0 && n > ratioCheckThreshold && (compressed == 0 || n/compressed > int64(e.o.MaxRatio)) {
+		return fmt.Errorf("%w: %s", ErrRatioTooHigh, name)
+	}
+	return nil
+}
+
+//readLink 延迟读取链接目标，策略为忽略时不读取
+//链接记录后在finish中创建，创建时再按磁盘上的实际条目校验目标
+func (e *extractor) symlink(name string, readLink func() (string, error)) error {
+	switch e.o.Symlinks {
+	case SymlinkReject:
+		return fmt.Errorf("%w: %s", ErrSymlink, name)
+	case SymlinkInside:
+	default:
+		return nil
+	}
+	clean, target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	link, err := readLink()
+	if err != nil {
+		return err
+	}
+	linkName := strings.Replace(link, `\`, "/", -1)
+	// 链接目标按链接所在目录解析，必须仍在dest内
+	if linkName == "" || path.IsAbs(linkName) || hasVolume(linkName) {
+		return fmt.Errorf("%w: %s", ErrSymlink, name)
+	}
+	resolved := path.Join(path.Dir(clean), linkName)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("%w: %s", ErrSymlink, name)
+	}
+	e.replace(clean)
+	e.linkIndex[clean] = len(e.links)
+	e.links = append(e.links, linkMeta{name: clean, target: target, linkName: linkName})
+	return nil
+}
+
+func (e *extractor) createSymlink(l linkMeta) error {
+	if err := e.checkLinkTarget(l.name, l.linkName); err != nil {
+		return err
+	}
+	// 之前创建的链接可能位于本链接的上级路径
+	if err := checkParents(e.root, l.name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.target), 0755); err != nil {
+		return err
+	}
+	if err := removeSymlink(l.target); err != nil {
+		return err
+	}
+	return os.Symlink(filepath.FromSlash(l.linkName), l.target)
+}
+
+// 按磁盘上的实际条目逐级解析链接目标：除最后一级外，经过的路径必须是dest内真实的目录
+// 经过其他符号链接或不存在的路径时返回ErrSymlink，防止多个链接组合后（如 d/l2 -> ..，l1 -> d/l2/../..）逃逸到dest之外
+// 最后一级可以是已创建的链接，该链接的目标同样经过校验
+func (e *extractor) checkLinkTarget(name string, linkName string) error {
+	var elems []string
+	if dir := path.Dir(name); dir != "." {
+		elems = strings.Split(dir, "/")
+	}
+	parts := strings.Split(linkName, "/")
+	for i, elem := range parts {
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if len(elems) == 0 {
+				return fmt.Errorf("%w: %s", ErrSymlink, name)
+			}
+			elems = elems[:len(elems)-1]
+			continue
+		}
+		elems = append(elems, elem)
+		if i == len(parts)-1 {
+			break
+		}
+		fi, err := os.Lstat(filepath.Join(e.root, filepath.FromSlash(strings.Join(elems, "/"))))
+		if err != nil || !fi.IsDir() {
+			return fmt.Errorf("%w: %s", ErrSymlink, name)
+		}
+	}
+	return nil
+}
+
+//link 硬链接，linkName为归档内的条目名称，必须指向dest内已解压的普通文件
+func (e *extractor) link(name string, linkName string) error {
+	clean, target, err := e.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	e.replace(clean)
+	_, source, err := e.target(linkName)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Lstat(source)
+	if err != nil || !fi.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s", ErrIllegalPath, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := removeSymlink(target); err != nil {
+		return err
+	}
+	return os.Link(source, target)
+}
+
+// 清理条目名称，返回以/分隔的相对路径，空字符串表示条目即dest本身
+// 开头的/会被去除（本包旧版本压缩的条目以/开头），含盘符或..的名称返回ErrIllegalPath
+func cleanEntryName(name string) (string, error) {
+	if strings.IndexByte(name, 0) >= 0 {
+		return "", fmt.Errorf("%w: %q", ErrIllegalPath, name)
+	}
+	slashed := strings.Replace(name, `\`, "/", -1)
+	if hasVolume(slashed) {
+		return "", fmt.Errorf("%w: %s", ErrIllegalPath, name)
+	}
+	slashed = strings.TrimLeft(slashed, "/")
+	for _, elem := range strings.Split(slashed, "/") {
+		if elem == ".." {
+			return "", fmt.Errorf("%w: %s", ErrIllegalPath, name)
+		}
+	}
+	clean := path.Clean(slashed)
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
+
+// 检查目标路径上已存在的上级目录均不是符号链接，防止经由链接写到dest之外
+func checkParents(root string, name string) error {
+	dir := root
+	elems := strings.Split(name, "/")
+	for _, elem := range elems[:len(elems)-1] {
+		dir = filepath.Join(dir, elem)
+		fi, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s", ErrIllegalPath, name)
+		}
+	}
+	return nil
+}
+
+// 目标已存在且为符号链接时删除链接本身，避免写入链接指向的文件
+func removeSymlink(target string) error {
+	fi, err := os.Lstat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(target)
+	}
+	return nil
+}
+
+func hasVolume(name string) bool {
+	if len(name) >= 2 && name[1] == ':' {
+		c := name[0] | 0x20
+		return c >= 'a' && c <= 'z'
+	}
+	return filepath.VolumeName(name) != ""
+}
@@ -0,0 +1,293 @@
+package ziputils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试用归档条目，link不为空时为符号链接，hard为true时为tar硬链接
+type testEntry struct {
+	name string
+	body string
+	link string
+	hard bool
+}
+
+func writeTestZip(t *testing.T, path string, entries []testEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, en := range entries {
+		fh := &zip.FileHeader{Name: en.name, Method: zip.Deflate}
+		body := en.body
+		switch {
+		case en.link != "":
+			fh.SetMode(os.ModeSymlink | 0777)
+			body = en.link
+		case en.name[len(en.name)-1] == '/':
+			fh.SetMode(os.ModeDir | 0755)
+		default:
+			fh.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestTar(t *testing.T, path string, gz bool, entries []testEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	var gw *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if gz {
+		gw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gw)
+	}
+	for _, en := range entries {
+		h := &tar.Header{Name: en.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(en.body))}
+		switch {
+		case en.hard:
+			h.Typeflag, h.Linkname, h.Size = tar.TypeLink, en.link, 0
+		case en.link != "":
+			h.Typeflag, h.Linkname, h.Size = tar.TypeSymlink, en.link, 0
+		case en.name[len(en.name)-1] == '/':
+			h.Typeflag, h.Mode, h.Size = tar.TypeDir, 0755, 0
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(en.body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gw != nil {
+		gw.Close()
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 检查文件不存在
+func checkNotExist(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("%s should not exist: %v", path, err)
+	}
+}
+
+func TestExtractIllegalPath(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "a/../../evil.txt", `..\evil.txt`, "C:/evil.txt", `c:\evil.txt`, "a/\x00b"} {
+		dir := t.TempDir()
+		archive := filepath.Join(dir, "a.zip")
+		writeTestZip(t, archive, []testEntry{{name: name, body: "evil"}})
+		dest := filepath.Join(dir, "out", "dest")
+		if err := DeCompress(archive, dest); !errors.Is(err, ErrIllegalPath) {
+			t.Fatalf("%q: expected ErrIllegalPath, got %v", name, err)
+		}
+		checkNotExist(t, filepath.Join(dir, "out", "evil.txt"))
+		checkNotExist(t, filepath.Join(dir, "evil.txt"))
+		if strings.IndexByte(name, 0) >= 0 {
+			continue //tar不能写入含NUL的名称
+		}
+		writeTestTar(t, archive, false, []testEntry{{name: name, body: "evil"}})
+		if err := DeCompress(archive, dest); !errors.Is(err, ErrIllegalPath) {
+			t.Fatalf("tar %q: expected ErrIllegalPath, got %v", name, err)
+		}
+	}
+}
+
+func TestExtractAbsolutePath(t *testing.T) {
+	// 开头的/被去除，条目解压到dest内
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "a.zip")
+	writeTestZip(t, archive, []testEntry{{name: "/abs/file.txt", body: "abs"}, {name: "./dot/./file.txt", body: "dot"}})
+	dest := filepath.Join(dir, "dest")
+	if err := DeCompress(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, dest, map[string]string{"abs/file.txt": "abs", "dot/file.txt": "dot"})
+}
+
+func TestExtractExistingSymlink(t *testing.T) {
+	// dest中已有指向外部的链接，条目不能经由该链接写到外部
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "outside")
+	dest := filepath.Join(dir, "dest")
+	os.MkdirAll(outside, 0755)
+	os.MkdirAll(dest, 0755)
+	if err := os.Symlink(outside, filepath.Join(dest, "out")); err != nil {
+		t.Skip("symlink not supported:", err)
+	}
+	os.WriteFile(filepath.Join(outside, "target.txt"), []byte("keep"), 0644)
+	os.Symlink(filepath.Join(outside, "target.txt"), filepath.Join(dest, "file.txt"))
+
+	archive := filepath.Join(dir, "a.zip")
+	writeTestZip(t, archive, []testEntry{{name: "out/evil.txt", body: "evil"}})
+	if err := DeCompress(archive, dest); !errors.Is(err, ErrIllegalPath) {
+		t.Fatalf("expected ErrIllegalPath, got %v", err)
+	}
+	checkTree(t, outside, map[string]string{"target.txt": "keep"})
+	// 目标为链接时替换链接本身，不写入链接指向的文件
+	writeTestZip(t, archive, []testEntry{{name: "file.txt", body: "new"}})
+	if err := DeCompress(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, outside, map[string]string{"target.txt": "keep"})
+	checkTree(t, dest, map[string]string{"file.txt": "new"})
+}
+
+func TestExtractLimits(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "a.zip")
+	dest := filepath.Join(dir, "dest")
+	writeTestZip(t, archive, []testEntry{{name: "a.txt", body: "0123456789"}, {name: "b.txt", body: "0123456789"}, {name: "c/"}})
+
+	cases := []struct {
+		o   ExtractOptions
+		err error
+	}{
+		{ExtractOptions{MaxFiles: 2}, ErrTooManyFiles},
+		{ExtractOptions{MaxFileSize: 9}, ErrFileTooLarge},
+		{ExtractOptions{MaxTotalSize: 19}, ErrArchiveTooLarge},
+		{ExtractOptions{MaxFiles: 3, MaxFileSize: 10, MaxTotalSize: 20}, nil},
+		{ExtractOptions{MaxFiles: -1, MaxFileSize: -1, MaxTotalSize: -1}, nil},
+	}
+	for i, c := range cases {
+		if err := DeCompress(archive, dest, c.o); !errors.Is(err, c.err) {
+			t.Fatalf("zip case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+	// tar没有预先声明的总大小，按实际写入的字节数校验
+	writeTestTar(t, archive, false, []testEntry{{name: "a.txt", body: "0123456789"}, {name: "b.txt", body: "0123456789"}, {name: "c/"}})
+	for i, c := range cases {
+		if err := DeCompress(archive, dest, c.o); !errors.Is(err, c.err) {
+			t.Fatalf("tar case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+}
+
+func TestExtractRatio(t *testing.T) {
+	dir := t.TempDir()
+	zeros := string(make([]byte, 4<<20))
+	for _, gz := range []bool{false, true} {
+		archive := filepath.Join(dir, "bomb")
+		if gz {
+			writeTestTar(t, archive, true, []testEntry{{name: "zeros", body: zeros}})
+		} else {
+			writeTestZip(t, archive, []testEntry{{name: "zeros", body: zeros}})
+		}
+		dest := filepath.Join(dir, "dest")
+		if err := DeCompress(archive, dest); !errors.Is(err, ErrRatioTooHigh) {
+			t.Fatalf("gz %v: expected ErrRatioTooHigh, got %v", gz, err)
+		}
+		// 超限的文件不会留在dest中
+		checkNotExist(t, filepath.Join(dest, "zeros"))
+		if err := DeCompress(archive, dest, ExtractOptions{MaxRatio: -1}); err != nil {
+			t.Fatalf("gz %v: %v", gz, err)
+		}
+	}
+	// 小文件不检查压缩比
+	archive := filepath.Join(dir, "small.zip")
+	writeTestZip(t, archive, []testEntry{{name: "zeros", body: zeros[:1<<20]}})
+	if err := DeCompress(archive, filepath.Join(dir, "small")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractSymlinkPolicy(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "a.zip")
+	writeTestZip(t, archive, []testEntry{{name: "a.txt", body: "aaa"}, {name: "l", link: "a.txt"}})
+
+	dest := filepath.Join(dir, "skip")
+	if err := DeCompress(archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	checkNotExist(t, filepath.Join(dest, "l"))
+	if err := DeCompress(archive, filepath.Join(dir, "reject"), ExtractOptions{Symlinks: SymlinkReject}); !errors.Is(err, ErrSymlink) {
+		t.Fatalf("expected ErrSymlink, got %v", err)
+	}
+	dest = filepath.Join(dir, "inside")
+	if err := DeCompress(archive, dest, ExtractOptions{Symlinks: SymlinkInside}); err != nil {
+		t.Fatal(err)
+	}
+	if link, err := os.Readlink(filepath.Join(dest, "l")); err != nil || link != "a.txt" {
+		t.Fatalf("unexpected link %q: %v", link, err)
+	}
+
+	for _, link := range []string{"../../outside", "/etc/passwd", "x/../../../outside", `..\..\outside`, "C:/Windows"} {
+		writeTestZip(t, archive, []testEntry{{name: "sub/l", link: link}})
+		if err := DeCompress(archive, filepath.Join(dir, "bad"), ExtractOptions{Symlinks: SymlinkInside}); !errors.Is(err, ErrSymlink) {
+			t.Fatalf("%q: expected ErrSymlink, got %v", link, err)
+		}
+	}
+}
+
+func TestExtractSymlinkChain(t *testing.T) {
+	// 每个链接单独看都在dest内，组合后 l1 -> d/l2/../.. 实际指向dest的上级目录
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "chain.tar")
+	writeTestTar(t, archive, false, []testEntry{{name: "d/"}, {name: "d/l2", link: ".."}, {name: "l1", link: "d/l2/../.."}})
+	dest := filepath.Join(dir, "out", "dest")
+	if err := DeCompress(archive, dest, ExtractOptions{Symlinks: SymlinkInside}); !errors.Is(err, ErrSymlink) {
+		t.Fatalf("expected ErrSymlink, got %v", err)
+	}
+	checkNotExist(t, filepath.Join(dest, "l1"))
+	// 链接目标经过其他链接
+	writeTestTar(t, archive, false, []testEntry{{name: "d/"}, {name: "d/l2", link: "."}, {name: "l1", link: "d/l2/x"}})
+	if err := DeCompress(archive, filepath.Join(dir, "via"), ExtractOptions{Symlinks: SymlinkInside}); !errors.Is(err, ErrSymlink) {
+		t.Fatalf("expected ErrSymlink, got %v", err)
+	}
+	// 先解压的链接作为后续条目的上级目录
+	writeTestTar(t, archive, false, []testEntry{{name: "d/"}, {name: "l", link: "d"}, {name: "l/x.txt", body: "x"}})
+	dest = filepath.Join(dir, "parent")
+	if err := DeCompress(archive, dest, ExtractOptions{Symlinks: SymlinkInside}); err == nil {
+		t.Fatal("entry below a symlink was accepted")
+	}
+	checkNotExist(t, filepath.Join(dest, "d", "x.txt"))
+}
+
+func TestExtractSymlinkOrder(t *testing.T) {
+	// 链接目标所在目录在链接之后出现，所有条目写入后再创建链接
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "a.tar")
+	writeTestTar(t, archive, false, []testEntry{
+		{name: ".bin/tool", link: "../pkg/bin/tool"},
+		{name: "pkg/bin/tool", body: "#!/bin/sh"},
+		{name: "chain", link: ".bin/tool"},
+		{name: "g", link: "pkg/bin/tool"},
+		{name: "g", body: "file wins"},
+		{name: "h", link: "pkg/bin/tool", hard: true},
+	})
+	dest := filepath.Join(dir, "dest")
+	if err := DeCompress(archive, dest, ExtractOptions{Symlinks: SymlinkInside}); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, dest, map[string]string{".bin/tool": "#!/bin/sh", "chain": "#!/bin/sh", "g": "file wins", "h": "#!/bin/sh"})
+	if fi, err := os.Lstat(filepath.Join(dest, "g")); err != nil || fi.Mode()&os.ModeSymlink != 0 {
+		t.Fatal("later file entry should replace the link")
+	}
+
+	writeTestTar(t, archive, false, []testEntry{{name: "h", link: "../outside", hard: true}})
+	if err := DeCompress(archive, filepath.Join(dir, "hard")); !errors.Is(err, ErrIllegalPath) {
+		t.Fatalf("expected ErrIllegalPath, got %v", err)
+	}
+}
@@ -0,0 +1,363 @@
+package ziputils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/youngchan1988/gocommon/fileutils"
+)
+
+//Format 压缩格式
+type Format string
+
+const (
+	FormatZip    Format = "zip"
+	FormatTar    Format = "tar"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarBz2 Format = "tar.bz2" //仅支持解压
+	FormatTarZst Format = "tar.zst"
+	FormatGzip   Format = "gz"  //单个文件
+	FormatZstd   Format = "zst" //单个文件
+)
+
+var ErrUnknownFormat = errors.New("ziputils: unknown archive format")
+
+var (
+	magicZip   = []byte("PK\x03\x04")
+	magicZipEm = []byte("PK\x05\x06") //空zip
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicBzip2 = []byte("BZh")
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+//FormatFromName 根据文件扩展名判断压缩格式，无法识别时返回空字符串
+func FormatFromName(name string) Format {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz
+	case strings.HasSuffix(name, ".tar.bz2"), strings.HasSuffix(name, ".tbz2"):
+		return FormatTarBz2
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return FormatTarZst
+	case strings.HasSuffix(name, ".gz"):
+		return FormatGzip
+	case strings.HasSuffix(name, ".zst"):
+		return FormatZstd
+	}
+	return ""
+}
+
+//DetectFormat 根据文件头识别压缩格式，gzip/zstd/bzip2会解压开头部分判断是否为tar
+func DetectFormat(filePath string) (Format, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return detectFormat(f)
+}
+
+func detectFormat(rs io.ReadSeeker) (Format, error) {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(rs, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	header = header[:n]
+	var compressed, tarFormat Format
+	switch {
+	case bytes.HasPrefix(header, magicZip), bytes.HasPrefix(header, magicZipEm):
+		return FormatZip, nil
+	case isTarHeader(header):
+		return FormatTar, nil
+	case bytes.HasPrefix(header, magicGzip):
+		compressed, tarFormat = FormatGzip, FormatTarGz
+	case bytes.HasPrefix(header, magicZstd):
+		compressed, tarFormat = FormatZstd, FormatTarZst
+	case bytes.HasPrefix(header, magicBzip2):
+		compressed, tarFormat = "", FormatTarBz2
+	default:
+		return "", ErrUnknownFormat
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	r, err := newDecompressReader(rs, tarFormat)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	n, _ = io.ReadFull(r, header[:cap(header)])
+	if isTarHeader(header[:n]) {
+		return tarFormat, nil
+	}
+	if compressed == "" {
+		return "", ErrUnknownFormat
+	}
+	return compressed, nil
+}
+
+// ustar/GNU格式检查magic，旧格式tar检查头部校验和
+func isTarHeader(block []byte) bool {
+	if len(block) < 512 {
+		return false
+	}
+	if bytes.Equal(block[257:262], []byte("ustar")) {
+		return true
+	}
+	field := strings.Trim(string(block[148:156]), " \x00")
+	expect, err := strconv.ParseInt(field, 8, 64)
+	if err != nil {
+		return false
+	}
+	var sum int64
+	for i, b := range block[:512] {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+		sum += int64(b)
+	}
+	return sum == expect
+}
+
+//CompressDirFormat 按指定格式压缩目录，目录结构与CompressDir一致
+//format 为非必需参数，默认根据dest的扩展名判断
+func CompressDirFormat(dirPath string, dest string, format ...Format) error {
+	f, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	return CompressFilesFormat([]*os.File{f}, dest, format...)
+}
+
+//CompressFilesFormat 按指定格式压缩文件，files可以是不同dir下的文件或者文件夹
+//gz、zst格式只能压缩单个文件；所有格式均先写入临时文件，失败时不会在dest留下不完整的压缩包
+//format 为非必需参数，默认根据dest的扩展名判断
+func CompressFilesFormat(files []*os.File, dest string, format ...Format) error {
+	fm := FormatFromName(dest)
+	if len(format) > 0 && format[0] != "" {
+		fm = format[0]
+	}
+	switch fm {
+	case FormatZip:
+		return fileutils.WriteFileAtomic(dest, func(w io.Writer) error {
+			return CompressFilesTo(w, files)
+		}, fileutils.WriteOptions{CreateDir: true})
+	case FormatTar, FormatTarGz, FormatTarZst:
+		return createCompressed(dest, fm, func(w io.Writer) error {
+			tw := tar.NewWriter(w)
+			for _, file := range files {
+				if err := compressTar(file, "", tw); err != nil {
+					return err
+				}
+			}
+			return tw.Close()
+		})
+	case FormatGzip, FormatZstd:
+		if len(files) != 1 {
+			return errors.New("ziputils: " + string(fm) + " format only supports a single file")
+		}
+		return compressSingle(files[0], dest, fm)
+	case FormatTarBz2:
+		return errors.New("ziputils: tar.bz2 format only supports extraction")
+	}
+	return ErrUnknownFormat
+}
+
+//CompressFileFormat 按指定格式压缩单个文件
+//format 为非必需参数，默认根据dest的扩展名判断
+func CompressFileFormat(filePath string, dest string, format ...Format) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	return CompressFilesFormat([]*os.File{f}, dest, format...)
+}
+
+// 创建dest并写入压缩流，fm为tar类格式时写入的是tar数据
+// 先写入临时文件，失败时不会在dest留下不完整的压缩包
+func createCompressed(dest string, fm Format, write func(w io.Writer) error) error {
+	return fileutils.WriteFileAtomic(dest, func(w io.Writer) error {
+		cw, err := newCompressWriter(w, fm)
+		if err != nil {
+			return err
+		}
+		if err := write(cw); err != nil {
+			cw.Close()
+			return err
+		}
+		return cw.Close()
+	}, fileutils.WriteOptions{CreateDir: true})
+}
+
+func compressSingle(file *os.File, dest string, fm Format) error {
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return errors.New("ziputils: " + string(fm) + " format only supports a regular file")
+	}
+	return createCompressed(dest, fm, func(w io.Writer) error {
+		if gw, ok := w.(*gzip.Writer); ok {
+			gw.Name = info.Name()
+			gw.ModTime = info.ModTime()
+		}
+		_, err := io.Copy(w, file)
+		return err
+	})
+}
+
+func newCompressWriter(w io.Writer, fm Format) (io.WriteCloser, error) {
+	switch fm {
+	case FormatTar:
+		return nopWriteCloser{w}, nil
+	case FormatTarGz, FormatGzip:
+		return gzip.NewWriter(w), nil
+	case FormatTarZst, FormatZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, errors.New("ziputils: unsupported compress format " + string(fm))
+}
+
+func newDecompressReader(r io.Reader, fm Format) (io.ReadCloser, error) {
+	switch fm {
+	case FormatTar:
+		return ioutil.NopCloser(r), nil
+	case FormatTarGz, FormatGzip:
+		return gzip.NewReader(r)
+	case FormatTarBz2:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case FormatTarZst, FormatZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// 根据文件头识别格式后解压
+func decompress(archive string, dest string, o ExtractOptions) error {
+	return decompressFiltered(archive, dest, o, nil)
+}
+
+// match不为nil时仅解压名称匹配的条目
+func decompressFiltered(archive string, dest string, o ExtractOptions, match func(name string) bool) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fm, err := detectFormat(f)
+	if err != nil {
+		return err
+	}
+	if fm == FormatZip {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		reader, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return err
+		}
+		files := reader.File
+		if match != nil {
+			files = nil
+			for _, file := range reader.File {
+				if match(file.Name) {
+					files = append(files, file)
+				}
+			}
+		}
+		return extract(files, dest, o, openPlain)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	cr := &countReader{r: f}
+	r, err := newDecompressReader(cr, fm)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	e, err := newExtractor(dest, o, func() int64 { return cr.n })
+	if err != nil {
+		return err
+	}
+	e.match = match
+	if fm == FormatGzip || fm == FormatZstd {
+		if err := e.next(); err != nil {
+			return err
+		}
+		var meta entryMeta
+		if gr, ok := r.(*gzip.Reader); ok {
+			meta.modTime = gr.ModTime
+		}
+		return e.file(singleName(archive, fm, r), r, -1, meta)
+	}
+	return extractTar(tar.NewReader(r), e)
+}
+
+// 单文件解压后的名称：优先使用gzip头中的文件名，否则去掉压缩包的扩展名
+func singleName(archive string, fm Format, r io.Reader) string {
+	if gr, ok := r.(*gzip.Reader); ok && gr.Name != "" {
+		name := filepath.Base(strings.Replace(gr.Name, `\`, "/", -1))
+		if name != "." && name != ".." && name != "/" {
+			return name
+		}
+	}
+	base := filepath.Base(archive)
+	ext := filepath.Ext(base)
+	if strings.EqualFold(ext, "."+string(fm)) && len(base) > len(ext) {
+		return base[:len(base)-len(ext)]
+	}
+	return base + ".out"
+}
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,144 @@
+package ziputils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatFromName(t *testing.T) {
+	cases := map[string]Format{
+		"a.zip": FormatZip, "a.TAR": FormatTar, "a.tar.gz": FormatTarGz, "a.tgz": FormatTarGz,
+		"a.tar.bz2": FormatTarBz2, "a.tzst": FormatTarZst, "a.tar.zst": FormatTarZst,
+		"a.txt.gz": FormatGzip, "a.zst": FormatZstd, "a.rar": "",
+	}
+	for name, want := range cases {
+		if got := FormatFromName(name); got != want {
+			t.Fatalf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCompressDirFormat(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	tree := map[string]string{"a.txt": "aaa", "sub/b.txt": strings.Repeat("b", 10000), "empty/": ""}
+	writeTree(t, src, tree)
+	want := make(map[string]string)
+	for name, content := range tree {
+		want["src/"+name] = content
+	}
+	for _, fm := range []Format{FormatZip, FormatTar, FormatTarGz, FormatTarZst} {
+		archive := filepath.Join(dir, "out", "src."+string(fm))
+		if err := CompressDirFormat(src, archive); err != nil {
+			t.Fatalf("%s: %v", fm, err)
+		}
+		// 根据文件头识别格式，与扩展名无关
+		renamed := filepath.Join(dir, "out", "archive-"+strings.Replace(string(fm), ".", "-", -1))
+		os.Rename(archive, renamed)
+		if got, err := DetectFormat(renamed); err != nil || got != fm {
+			t.Fatalf("%s: detected %q: %v", fm, got, err)
+		}
+		dest := filepath.Join(dir, "dest-"+string(fm))
+		if err := DeCompress(renamed, dest); err != nil {
+			t.Fatalf("%s: %v", fm, err)
+		}
+		checkTree(t, dest, want)
+	}
+	if err := CompressDirFormat(src, filepath.Join(dir, "src.tar.bz2")); err == nil {
+		t.Fatal("tar.bz2 compression should be unsupported")
+	}
+	if err := CompressDirFormat(src, filepath.Join(dir, "src.rar")); err != ErrUnknownFormat {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestCompressDirFormatSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTree(t, src, map[string]string{"a.txt": "aaa", "sub/b.txt": "bbb"})
+	// 指向祖先目录的链接会造成循环，应被忽略；指向文件的链接按文件内容压缩
+	if err := os.Symlink("..", filepath.Join(src, "sub", "up")); err != nil {
+		t.Skip("symlink not supported:", err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"src/a.txt": "aaa", "src/sub/b.txt": "bbb", "src/link.txt": "aaa"}
+	for _, fm := range []Format{FormatZip, FormatTar, FormatTarGz} {
+		archive := filepath.Join(dir, "src."+string(fm))
+		if err := CompressDirFormat(src, archive); err != nil {
+			t.Fatalf("%s: %v", fm, err)
+		}
+		dest := filepath.Join(dir, "dest-"+string(fm))
+		if err := DeCompress(archive, dest); err != nil {
+			t.Fatalf("%s: %v", fm, err)
+		}
+		checkTree(t, dest, want)
+	}
+}
+
+func TestCompressSingleFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"data.txt": strings.Repeat("single file ", 1000)})
+	for _, fm := range []Format{FormatGzip, FormatZstd} {
+		archive := filepath.Join(dir, "data.txt."+string(fm))
+		if err := CompressFileFormat(filepath.Join(dir, "data.txt"), archive); err != nil {
+			t.Fatalf("%s: %v", fm, err)
+		}
+		if got, _ := DetectFormat(archive); got != fm {
+			t.Fatalf("%s: detected %q", fm, got)
+		}
+		dest := filepath.Join(dir, "dest-"+string(fm))
+		if err := DeCompress(archive, dest); err != nil {
+			t.Fatalf("%s: %v", fm, err)
+		}
+		checkTree(t, dest, map[string]string{"data.txt": strings.Repeat("single file ", 1000)})
+	}
+	// 单文件格式不能压缩目录或多个文件
+	if err := CompressDirFormat(dir, filepath.Join(t.TempDir(), "dir.gz")); err == nil {
+		t.Fatal("directory was compressed as gz")
+	}
+}
+
+func TestCompressFormatFailureLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.txt": "aaa"})
+	for _, fm := range []Format{FormatZip, FormatTarGz, FormatGzip} {
+		f, err := os.Open(filepath.Join(dir, "a.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		dest := filepath.Join(dir, "out", "a."+string(fm))
+		if err := CompressFilesFormat([]*os.File{f}, dest); err == nil {
+			t.Fatalf("%s: closed file was accepted", fm)
+		}
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			t.Fatalf("%s: partial archive was left: %v", fm, err)
+		}
+	}
+	// 覆盖已存在的dest失败时保留原文件
+	for _, name := range []string{"keep.tar.gz", "keep.zip"} {
+		dest := filepath.Join(dir, name)
+		os.WriteFile(dest, []byte("old"), 0644)
+		f, _ := os.Open(filepath.Join(dir, "a.txt"))
+		f.Close()
+		if err := CompressFilesFormat([]*os.File{f}, dest); err == nil {
+			t.Fatalf("%s: closed file was accepted", name)
+		}
+	}
+	checkTree(t, dir, map[string]string{"keep.tar.gz": "old", "keep.zip": "old"})
+}
+
+func TestDetectFormatUnknown(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"plain.txt": "not an archive", "empty.txt": ""})
+	for _, name := range []string{"plain.txt", "empty.txt"} {
+		if _, err := DetectFormat(filepath.Join(dir, name)); !errors.Is(err, ErrUnknownFormat) {
+			t.Fatalf("%s: expected ErrUnknownFormat, got %v", name, err)
+		}
+	}
+}
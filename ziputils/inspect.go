@@ -0,0 +1,234 @@
+package ziputils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/youngchan1988/gocommon/fileutils"
+)
+
+var ErrEntryNotFound = errors.New("ziputils: entry not found")
+
+//EntryInfo 压缩包条目信息
+type EntryInfo struct {
+	Name           string
+	Size           int64 //解压后大小
+	CompressedSize int64 //压缩后大小，tar类格式为0
+	CRC32          uint32
+	Modified       time.Time
+	Mode           os.FileMode
+	IsDir          bool
+	Encrypted      bool
+}
+
+//List 列出压缩包中的条目，支持zip与tar类格式
+func List(archive string) ([]EntryInfo, error) {
+	var infos []EntryInfo
+	_, err := walkArchive(archive, func(file *zip.File) (bool, error) {
+		infos = append(infos, EntryInfo{
+			Name:           file.Name,
+			Size:           int64(file.UncompressedSize64),
+			CompressedSize: int64(file.CompressedSize64),
+			CRC32:          file.CRC32,
+			Modified:       file.Modified,
+			Mode:           file.Mode(),
+			IsDir:          file.Mode().IsDir(),
+			Encrypted:      file.Flags&flagEncrypted != 0,
+		})
+		return true, nil
+	}, func(header *tar.Header, r io.Reader) (bool, error) {
+		infos = append(infos, EntryInfo{
+			Name:     header.Name,
+			Size:     header.Size,
+			Modified: header.ModTime,
+			Mode:     header.FileInfo().Mode(),
+			IsDir:    header.Typeflag == tar.TypeDir,
+		})
+		return true, nil
+	})
+	return infos, err
+}
+
+//ExtractGlob 解压名称匹配pattern的条目，pattern语法同.gitignore（见fileutils.NewGlobMatcher），目录匹配时解压整个目录
+//如 "*.txt"匹配任意层级的txt文件，"src/*.go"、"docs/**/*.md"、"docs/"
+//没有条目匹配时返回ErrEntryNotFound；opts 为非必需参数，同DeCompress
+func ExtractGlob(archive string, dest string, pattern string, opts ...ExtractOptions) error {
+	m := fileutils.NewGlobMatcher(pattern)
+	matched := false
+	match := func(name string) bool {
+		if matchGlob(m, name) {
+			matched = true
+			return true
+		}
+		return false
+	}
+	fm, err := DetectFormat(archive)
+	if err != nil {
+		return err
+	}
+	if fm == FormatGzip || fm == FormatZstd {
+		return errors.New("ziputils: " + string(fm) + " is not an archive")
+	}
+	if err := decompressFiltered(archive, dest, extractOptions(opts), match); err != nil {
+		return err
+	}
+	if !matched {
+		return ErrEntryNotFound
+	}
+	return nil
+}
+
+//OpenEntry 以io.ReadCloser读取压缩包中的单个条目，支持zip与tar类格式，读取完毕后需调用Close
+//password 为非必需参数，用于读取加密的zip条目
+func OpenEntry(archive string, name string, password ...string) (io.ReadCloser, error) {
+	target := entryKey(name)
+	var rc io.ReadCloser
+	closer, err := walkArchive(archive, func(file *zip.File) (bool, error) {
+		if entryKey(file.Name) != target || file.Mode().IsDir() {
+			return true, nil
+		}
+		var err error
+		if len(password) > 0 {
+			rc, err = openWithPassword(file, password[0])
+		} else {
+			rc, err = openPlain(file)
+		}
+		return false, err
+	}, func(header *tar.Header, r io.Reader) (bool, error) {
+		if entryKey(header.Name) != target || !header.FileInfo().Mode().IsRegular() {
+			return true, nil
+		}
+		rc = io.NopCloser(r)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rc == nil {
+		return nil, ErrEntryNotFound
+	}
+	return &entryReadCloser{ReadCloser: rc, archive: closer}, nil
+}
+
+// 关闭条目时一并关闭压缩包
+type entryReadCloser struct {
+	io.ReadCloser
+	archive io.Closer
+}
+
+func (r *entryReadCloser) Close() error {
+	return closeAll(r.ReadCloser, r.archive)
+}
+
+//ReadEntry 读取压缩包中单个条目的全部内容，password 为非必需参数
+func ReadEntry(archive string, name string, password ...string) ([]byte, error) {
+	rc, err := OpenEntry(archive, name, password...)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// 遍历压缩包条目，回调返回false时停止遍历并返回压缩包的closer，由调用方在读取完条目后关闭
+// 回调返回error或遍历完毕时压缩包已关闭
+func walkArchive(archive string, zipFn func(file *zip.File) (bool, error), tarFn func(header *tar.Header, r io.Reader) (bool, error)) (io.Closer, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	closers := multiCloser{f}
+	stopped, err := walkOpened(f, &closers, zipFn, tarFn)
+	if err != nil || !stopped {
+		closers.Close()
+		return nil, err
+	}
+	return closers, nil
+}
+
+func walkOpened(f *os.File, closers *multiCloser, zipFn func(file *zip.File) (bool, error), tarFn func(header *tar.Header, r io.Reader) (bool, error)) (bool, error) {
+	fm, err := detectFormat(f)
+	if err != nil {
+		return false, err
+	}
+	if fm == FormatGzip || fm == FormatZstd {
+		return false, errors.New("ziputils: " + string(fm) + " is not an archive")
+	}
+	if fm == FormatZip {
+		info, err := f.Stat()
+		if err != nil {
+			return false, err
+		}
+		reader, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return false, err
+		}
+		for _, file := range reader.File {
+			next, err := zipFn(file)
+			if err != nil || !next {
+				return !next, err
+			}
+		}
+		return false, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	r, err := newDecompressReader(f, fm)
+	if err != nil {
+		return false, err
+	}
+	*closers = append(multiCloser{r}, *closers...)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		next, err := tarFn(header, tr)
+		if err != nil || !next {
+			return !next, err
+		}
+	}
+}
+
+// 按顺序关闭，返回第一个错误
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	return closeAll(m...)
+}
+
+// 条目名称去掉开头的/与结尾的/后比较
+func entryKey(name string) string {
+	clean, err := cleanEntryName(name)
+	if err != nil {
+		return name
+	}
+	return clean
+}
+
+// 条目本身或任一上级目录匹配时返回true，以/结尾的条目视为目录
+func matchGlob(m *fileutils.GlobMatcher, name string) bool {
+	clean, err := cleanEntryName(name)
+	if err != nil || clean == "" {
+		return false
+	}
+	isDir := strings.HasSuffix(strings.Replace(name, `\`, "/", -1), "/")
+	for p := clean; p != "."; p = path.Dir(p) {
+		if m.Match(p, isDir) {
+			return true
+		}
+		isDir = true
+	}
+	return false
+}
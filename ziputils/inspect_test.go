@@ -0,0 +1,94 @@
+package ziputils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// 列出dir下的所有文件，以/分隔并排序
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestExtractGlob(t *testing.T) {
+	entries := []testEntry{
+		{name: "a.txt", body: "a"},
+		{name: "docs/", body: ""},
+		{name: "docs/b.txt", body: "b"},
+		{name: "docs/d.md", body: "d"},
+		{name: "docs/sub/c.txt", body: "c"},
+		{name: "src/main.go", body: "main"},
+		{name: "src/pkg/x.go", body: "x"},
+		{name: "build/", body: ""},
+		{name: "build/out.bin", body: "bin"},
+	}
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "test.zip")
+	tarPath := filepath.Join(dir, "test.tar.gz")
+	writeTestZip(t, zipPath, entries)
+	writeTestTar(t, tarPath, true, entries)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.txt", []string{"a.txt", "docs/b.txt", "docs/sub/c.txt"}},
+		{"/*.txt", []string{"a.txt"}},
+		{"src/*.go", []string{"src/main.go"}},
+		{"**/*.go", []string{"src/main.go", "src/pkg/x.go"}},
+		{"docs", []string{"docs/b.txt", "docs/d.md", "docs/sub/c.txt"}},
+		{"docs/**/*.txt", []string{"docs/b.txt", "docs/sub/c.txt"}},
+		{"sub", []string{"docs/sub/c.txt"}},
+		{"build/", []string{"build/out.bin"}},
+		{"out.bin/", nil},
+		{"*.[mg][do]", []string{"docs/d.md", "src/main.go", "src/pkg/x.go"}},
+	}
+	for _, archive := range []string{zipPath, tarPath} {
+		for i, tt := range tests {
+			out := filepath.Join(dir, "out", filepath.Base(archive), string(rune('a'+i)))
+			err := ExtractGlob(archive, out, tt.pattern)
+			if tt.want == nil {
+				if !errors.Is(err, ErrEntryNotFound) {
+					t.Fatalf("%s %q: got %v, want ErrEntryNotFound", archive, tt.pattern, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s %q: %v", archive, tt.pattern, err)
+			}
+			if got := listFiles(t, out); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("%s %q: got %v, want %v", archive, tt.pattern, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestExtractGlobNotFound(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "test.zip")
+	writeTestZip(t, archive, []testEntry{{name: "a.txt", body: "a"}})
+	if err := ExtractGlob(archive, filepath.Join(dir, "out"), "*.go"); !errors.Is(err, ErrEntryNotFound) {
+		t.Fatalf("got %v, want ErrEntryNotFound", err)
+	}
+	if files := listFiles(t, filepath.Join(dir, "out")); len(files) != 0 {
+		t.Fatalf("unexpected files %v", files)
+	}
+}
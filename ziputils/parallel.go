@@ -0,0 +1,462 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/youngchan1988/gocommon/fileutils"
+)
+
+// 单个条目压缩结果超过该大小时转存到临时文件，避免大文件占用过多内存
+const spillThreshold = 4 << 20
+
+//Progress 压缩进度，字节数按文件压缩前的大小计算
+type Progress struct {
+	Files      int //已写入的条目数量（含目录）
+	TotalFiles int
+	Bytes      int64 //已写入的字节数
+	TotalBytes int64
+	Elapsed    time.Duration
+	ETA        time.Duration //按已用时间与已写入字节数估算的剩余时间，尚无法估算时为0
+}
+
+//CompressOptions 并行压缩参数，零值字段使用默认值
+type CompressOptions struct {
+	StreamOptions
+	//Workers 并行压缩的协程数量，默认runtime.NumCPU()
+	//设置了Password时按顺序压缩，Workers不生效
+	Workers int
+	//Progress 每写入一个条目回调一次，在调用方的协程中执行
+	Progress func(p Progress)
+}
+
+//CompressDirContext 并行压缩目录，目录结构与CompressDir一致，保留空目录、文件权限与修改时间
+//ctx取消时停止压缩并返回ctx.Err()，不会留下不完整的dest
+//opts 为非必需参数
+func CompressDirContext(ctx context.Context, dirPath string, dest string, opts ...CompressOptions) error {
+	f, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	return CompressFilesContext(ctx, []*os.File{f}, dest, opts...)
+}
+
+//CompressFilesContext 并行压缩文件，files可以是不同dir下的文件或者文件夹，目录结构与CompressFiles一致
+//ctx取消时停止压缩并返回ctx.Err()，不会留下不完整的dest
+//opts 为非必需参数；写入完成后会关闭files
+func CompressFilesContext(ctx context.Context, files []*os.File, dest string, opts ...CompressOptions) error {
+	return fileutils.WriteFileAtomic(dest, func(w io.Writer) error {
+		return CompressFilesToContext(ctx, w, files, opts...)
+	}, fileutils.WriteOptions{CreateDir: true})
+}
+
+//CompressDirToContext 并行压缩目录并写入w，opts为非必需参数
+func CompressDirToContext(ctx context.Context, w io.Writer, dirPath string, opts ...CompressOptions) error {
+	f, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	return CompressFilesToContext(ctx, w, []*os.File{f}, opts...)
+}
+
+//CompressFilesToContext 并行压缩文件并写入w，各文件由多个协程同时压缩，按遍历顺序写入
+//ctx取消时返回ctx.Err()，此时w中的数据不完整；opts为非必需参数；写入完成后会关闭files，但不会关闭w
+func CompressFilesToContext(ctx context.Context, w io.Writer, files []*os.File, opts ...CompressOptions) error {
+	var o CompressOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.Level == 0 {
+		o.Level = flate.DefaultCompression
+	}
+	s := NewZipStream(w, o.StreamOptions)
+	var jobs []*compressJob
+	for _, file := range files {
+		name := file.Name()
+		info, err := file.Stat()
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if jobs, err = s.collect(jobs, name, info); err != nil {
+			return err
+		}
+	}
+	p := &parallelCompressor{s: s, o: o, jobs: jobs, start: time.Now()}
+	for _, job := range jobs {
+		if !job.info.IsDir() {
+			p.progress.TotalBytes += job.info.Size()
+		}
+	}
+	p.progress.TotalFiles = len(jobs)
+	if err := p.run(ctx); err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+type compressJob struct {
+	path string
+	name string
+	info os.FileInfo
+}
+
+// 遍历文件或目录，生成按顺序写入的条目，Filter与Rename在此时生效
+func (s *ZipStream) collect(jobs []*compressJob, root string, info os.FileInfo) ([]*compressJob, error) {
+	root = filepath.Clean(root)
+	base := filepath.Base(root)
+	if !info.IsDir() {
+		if s.o.Filter != nil && !s.o.Filter(root, info) {
+			return jobs, nil
+		}
+		return s.appendJob(jobs, root, base, info), nil
+	}
+	err := filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			// 跟随指向文件的链接，忽略指向目录的链接以免循环
+			if info, err = os.Stat(filePath); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+		if filePath != root && s.o.Filter != nil && !s.o.Filter(filePath, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		jobs = s.appendJob(jobs, filePath, path.Join(base, filepath.ToSlash(rel)), info)
+		return nil
+	})
+	return jobs, err
+}
+
+func (s *ZipStream) appendJob(jobs []*compressJob, filePath string, name string, info os.FileInfo) []*compressJob {
+	if s.o.Rename != nil {
+		name = s.o.Rename(name)
+	}
+	name = strings.TrimRight(streamEntryName(name), "/")
+	if name == "" {
+		return jobs
+	}
+	if info.IsDir() {
+		name += "/"
+	}
+	return append(jobs, &compressJob{path: filePath, name: name, info: info})
+}
+
+type parallelCompressor struct {
+	s        *ZipStream
+	o        CompressOptions
+	jobs     []*compressJob
+	start    time.Time
+	progress Progress
+}
+
+// 单个条目的压缩结果
+type compressResult struct {
+	header *zip.FileHeader
+	data   *spillBuffer
+	err    error
+}
+
+func (p *parallelCompressor) run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if p.o.Password != "" {
+		// AES加密需逐个条目写入，按顺序压缩
+		for _, job := range p.jobs {
+			if err := p.writeEncrypted(ctx, job); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// pending限制已提交但尚未写入的条目数量，workers限制同时压缩的数量
+	pending := make(chan struct{}, 2*p.o.Workers)
+	workers := make(chan struct{}, p.o.Workers)
+	results := make(chan chan compressResult, 2*p.o.Workers)
+	var wg sync.WaitGroup
+	go func() {
+		defer close(results)
+		for _, job := range p.jobs {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case pending <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			ch := make(chan compressResult, 1)
+			results <- ch
+			wg.Add(1)
+			go func(job *compressJob) {
+				defer wg.Done()
+				select {
+				case workers <- struct{}{}:
+				case <-ctx.Done():
+					ch <- compressResult{err: ctx.Err()}
+					return
+				}
+				ch <- p.compress(ctx, job)
+				<-workers
+			}(job)
+		}
+	}()
+
+	var err error
+	for ch := range results {
+		r := <-ch
+		if err == nil {
+			err = r.err
+			if err == nil {
+				err = p.write(r)
+			}
+			if err != nil {
+				cancel()
+			}
+		}
+		if r.data != nil {
+			r.data.Close()
+		}
+		<-pending
+	}
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// 读取并压缩单个文件，计算CRC32与大小
+func (p *parallelCompressor) compress(ctx context.Context, job *compressJob) compressResult {
+	header, err := zip.FileInfoHeader(job.info)
+	if err != nil {
+		return compressResult{err: err}
+	}
+	header.Name = job.name
+	if job.info.IsDir() {
+		return compressResult{header: header}
+	}
+	header.Method = p.s.method(job.name, nil)
+	f, err := os.Open(job.path)
+	if err != nil {
+		return compressResult{header: header, err: err}
+	}
+	defer f.Close()
+
+	data := &spillBuffer{}
+	crc := crc32.NewIEEE()
+	r := io.TeeReader(&ctxReader{ctx: ctx, r: f}, crc)
+	var n int64
+	if header.Method == zip.Store {
+		n, err = io.Copy(data, r)
+	} else {
+		fw := getFlateWriter(data, p.o.Level)
+		if n, err = io.Copy(fw, r); err == nil {
+			err = fw.Close()
+		}
+		putFlateWriter(fw, p.o.Level)
+	}
+	if err != nil {
+		data.Close()
+		return compressResult{err: err}
+	}
+	header.CRC32 = crc.Sum32()
+	header.UncompressedSize64 = uint64(n)
+	header.CompressedSize64 = uint64(data.Len())
+	return compressResult{header: header, data: data}
+}
+
+// 按顺序写入已压缩的条目
+func (p *parallelCompressor) write(r compressResult) error {
+	header := r.header
+	if r.data == nil {
+		if _, err := p.s.zw.CreateHeader(header); err != nil {
+			return err
+		}
+		p.report(0)
+		return nil
+	}
+	setRawHeaderTime(header)
+	if utf8.ValidString(header.Name) && !isASCII(header.Name) {
+		header.Flags |= flagUTF8
+	}
+	if header.CompressedSize64 >= uint32max || header.UncompressedSize64 >= uint32max {
+		header.CompressedSize, header.UncompressedSize = uint32max, uint32max
+	} else {
+		header.CompressedSize, header.UncompressedSize = uint32(header.CompressedSize64), uint32(header.UncompressedSize64)
+	}
+	w, err := p.s.zw.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	rd, err := r.data.Reader()
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, rd); err != nil {
+		return err
+	}
+	p.report(int64(header.UncompressedSize64))
+	return nil
+}
+
+func (p *parallelCompressor) writeEncrypted(ctx context.Context, job *compressJob) error {
+	header, err := zip.FileInfoHeader(job.info)
+	if err != nil {
+		return err
+	}
+	header.Name = job.name
+	if job.info.IsDir() {
+		return p.write(compressResult{header: header})
+	}
+	header.Method = p.s.method(job.name, nil)
+	f, err := os.Open(job.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cr := &countReader{r: &ctxReader{ctx: ctx, r: f}}
+	if err := p.s.write(header, cr); err != nil {
+		return err
+	}
+	p.report(cr.n)
+	return nil
+}
+
+func (p *parallelCompressor) report(n int64) {
+	p.progress.Files++
+	p.progress.Bytes += n
+	if p.o.Progress == nil {
+		return
+	}
+	p.progress.Elapsed = time.Since(p.start)
+	p.progress.ETA = 0
+	switch {
+	case p.progress.TotalBytes > 0 && p.progress.Bytes > 0:
+		p.progress.ETA = time.Duration(float64(p.progress.Elapsed) * float64(p.progress.TotalBytes-p.progress.Bytes) / float64(p.progress.Bytes))
+	case p.progress.TotalBytes == 0 && p.progress.Files > 0:
+		p.progress.ETA = p.progress.Elapsed * time.Duration(p.progress.TotalFiles-p.progress.Files) / time.Duration(p.progress.Files)
+	}
+	p.o.Progress(p.progress)
+}
+
+// 每次读取前检查ctx，取消后返回ctx.Err()
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// 先写入内存，超过spillThreshold后转存到临时文件
+type spillBuffer struct {
+	buf  bytes.Buffer
+	file *os.File
+	n    int64
+}
+
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.buf.Len()+len(p) > spillThreshold {
+		f, err := os.CreateTemp("", "ziputils-*.tmp")
+		if err != nil {
+			return 0, err
+		}
+		b.file = f
+		if _, err := b.buf.WriteTo(f); err != nil {
+			return 0, err
+		}
+		b.buf = bytes.Buffer{}
+	}
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.buf.Write(p)
+	}
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *spillBuffer) Len() int64 {
+	return b.n
+}
+
+// 返回已写入内容的reader，之后不可再写入
+func (b *spillBuffer) Reader() (io.Reader, error) {
+	if b.file == nil {
+		return &b.buf, nil
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return b.file, nil
+}
+
+// 删除临时文件
+func (b *spillBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	if rerr := os.Remove(b.file.Name()); err == nil {
+		err = rerr
+	}
+	b.file = nil
+	return err
+}
+
+// flate.Writer内部缓冲较大，按压缩级别复用
+var flateWriters sync.Map
+
+func getFlateWriter(w io.Writer, level int) *flate.Writer {
+	pool, _ := flateWriters.LoadOrStore(level, &sync.Pool{})
+	if fw, ok := pool.(*sync.Pool).Get().(*flate.Writer); ok {
+		fw.Reset(w)
+		return fw
+	}
+	fw, err := flate.NewWriter(w, level)
+	if err != nil {
+		fw, _ = flate.NewWriter(w, flate.DefaultCompression)
+	}
+	return fw
+}
+
+func putFlateWriter(fw *flate.Writer, level int) {
+	if pool, ok := flateWriters.Load(level); ok {
+		pool.(*sync.Pool).Put(fw)
+	}
+}
@@ -0,0 +1,239 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 创建包含多个文件、空目录、不同权限与修改时间的目录
+func writeParallelTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{"empty/": "", "sub/deep/": ""}
+	for i := 0; i < 30; i++ {
+		files[fmt.Sprintf("f%02d.txt", i)] = fmt.Sprintf("file %d %s", i, bytes.Repeat([]byte{'x'}, i*100))
+		files[fmt.Sprintf("sub/s%02d.log", i)] = fmt.Sprintf("sub %d", i)
+	}
+	files["img.png"] = "stored"
+	writeTree(t, dir, files)
+	if err := os.Chmod(filepath.Join(dir, "f00.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "f01.txt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"f00.txt", "sub/s00.log", "empty"} {
+		if err := os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+// 按写入顺序返回zip中的条目
+func zipEntries(t *testing.T, data []byte) []*zip.File {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr.File
+}
+
+func TestCompressDirContext(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	files := writeParallelTree(t, src)
+
+	// 与顺序写入的CompressDirTo条目与顺序一致
+	var seq bytes.Buffer
+	if err := CompressDirTo(&seq, src); err != nil {
+		t.Fatal(err)
+	}
+	var par bytes.Buffer
+	if err := CompressDirToContext(context.Background(), &par, src, CompressOptions{Workers: 4, StreamOptions: StreamOptions{Method: MethodByExt}}); err != nil {
+		t.Fatal(err)
+	}
+	var want, got []string
+	for _, f := range zipEntries(t, seq.Bytes()) {
+		want = append(want, f.Name)
+	}
+	for _, f := range zipEntries(t, par.Bytes()) {
+		got = append(got, f.Name)
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f.Name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Mode() != info.Mode() {
+			t.Fatalf("%s: mode %v, want %v", f.Name, f.Mode(), info.Mode())
+		}
+		if !f.Modified.Equal(info.ModTime().Truncate(time.Second)) {
+			t.Fatalf("%s: modified %v, want %v", f.Name, f.Modified, info.ModTime())
+		}
+		if f.Name == "src/img.png" && f.Method != zip.Store {
+			t.Fatalf("%s: method %d, want Store", f.Name, f.Method)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got entries %v, want %v", got, want)
+	}
+
+	dest := filepath.Join(dir, "out", "src.zip")
+	if err := CompressDirContext(context.Background(), src, dest, CompressOptions{Workers: 3}); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "extract")
+	if err := DeCompress(dest, out); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, filepath.Join(out, "src"), files)
+	info, err := os.Stat(filepath.Join(out, "src", "f00.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 || info.ModTime().Unix() != time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Unix() {
+		t.Fatalf("f00.txt: mode %v, modified %v", info.Mode(), info.ModTime())
+	}
+}
+
+func TestCompressContextLargeFile(t *testing.T) {
+	// 超过spillThreshold的条目转存到临时文件，完成后删除
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	dir := t.TempDir()
+	data := make([]byte, spillThreshold+12345)
+	rand.New(rand.NewSource(1)).Read(data[:len(data)/2])
+	if err := os.WriteFile(filepath.Join(dir, "big.bin"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dir, "big.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := CompressFilesToContext(context.Background(), &buf, []*os.File{f}, CompressOptions{Workers: 2}); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "big.zip")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadEntry(archive, "big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("big.bin content mismatch")
+	}
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Fatalf("temporary files left: %v", left)
+	}
+}
+
+func TestCompressContextProgress(t *testing.T) {
+	dir := t.TempDir()
+	writeParallelTree(t, dir)
+	var reports []Progress
+	var buf bytes.Buffer
+	err := CompressDirToContext(context.Background(), &buf, dir, CompressOptions{
+		Workers:  4,
+		Progress: func(p Progress) { reports = append(reports, p) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := zipEntries(t, buf.Bytes())
+	if len(reports) != len(entries) {
+		t.Fatalf("got %d progress reports, want %d", len(reports), len(entries))
+	}
+	var total int64
+	for _, f := range entries {
+		total += int64(f.UncompressedSize64)
+	}
+	for i, p := range reports {
+		if p.Files != i+1 || p.TotalFiles != len(entries) || p.TotalBytes != total {
+			t.Fatalf("report %d: %+v", i, p)
+		}
+		if i > 0 && (p.Bytes < reports[i-1].Bytes || p.Elapsed < reports[i-1].Elapsed) {
+			t.Fatalf("report %d not monotonic: %+v after %+v", i, p, reports[i-1])
+		}
+	}
+	if last := reports[len(reports)-1]; last.Bytes != total || last.ETA != 0 {
+		t.Fatalf("last report: %+v", last)
+	}
+}
+
+func TestCompressContextCancel(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeParallelTree(t, src)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dest := filepath.Join(dir, "canceled.zip")
+	if err := CompressDirContext(ctx, src, dest); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	checkNotExist(t, dest)
+
+	// 写入部分条目后取消
+	for _, password := range []string{"", "secret"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		files := 0
+		err := CompressDirContext(ctx, src, dest, CompressOptions{
+			Workers:       2,
+			StreamOptions: StreamOptions{Password: password},
+			Progress: func(p Progress) {
+				if files++; files == 3 {
+					cancel()
+				}
+			},
+		})
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("password %q: got %v, want context.Canceled", password, err)
+		}
+		if files >= 60 {
+			t.Fatalf("password %q: compression continued after cancel, %d entries written", password, files)
+		}
+		checkNotExist(t, dest)
+	}
+}
+
+func TestCompressContextOptions(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTree(t, src, map[string]string{"a.txt": "aaa", "b.log": "bbb", "skip/c.txt": "ccc", "sub/d.txt": "ddd"})
+	dest := filepath.Join(dir, "src.zip")
+	err := CompressDirContext(context.Background(), src, dest, CompressOptions{
+		StreamOptions: StreamOptions{
+			Password: "secret",
+			Filter: func(filePath string, info os.FileInfo) bool {
+				return info.Name() != "skip" && filepath.Ext(filePath) != ".log"
+			},
+			Rename: func(name string) string { return "renamed/" + name },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if err := DeCompressWithPassword(dest, out, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := listFiles(t, out), []string{"renamed/src/a.txt", "renamed/src/sub/d.txt"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if err := DeCompress(dest, filepath.Join(dir, "plain")); !errors.Is(err, ErrPasswordRequired) {
+		t.Fatalf("got %v, want ErrPasswordRequired", err)
+	}
+}
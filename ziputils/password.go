@@ -0,0 +1,453 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/youngchan1988/gocommon/fileutils"
+	"github.com/youngchan1988/gocommon/securityutils"
+)
+
+// WinZip AES加密（AE-2）：
+// 条目压缩方式记为99，extra字段0x9901记录AES强度与实际压缩方式
+// 数据格式 | salt | 口令校验值(2) | AES-CTR密文 | HMAC-SHA1(10) |
+// 密钥由PBKDF2-HMAC-SHA1(口令, salt, 1000)派生，写入时固定使用AES-256
+const (
+	methodWinZipAES  = 99
+	winZipAESExtraID = 0x9901
+	extTimeExtraID   = 0x5455
+	winZipAESIter    = 1000
+	winZipAESMacSize = 10
+	winZipAESVersion = 2 //AE-2，不记录CRC32，完整性由HMAC保证
+	winZipAES256     = 3
+
+	flagEncrypted      = 0x1
+	flagDataDescriptor = 0x8
+	flagUTF8           = 0x800
+	zipCryptoHeaderLen = 12
+	uint32max          = (1 << 32) - 1
+)
+
+var (
+	ErrPasswordRequired = errors.New("ziputils: entry is encrypted, password required")
+	ErrWrongPassword    = errors.New("ziputils: wrong password")
+	ErrAuthFailed       = errors.New("ziputils: authentication failed, data is corrupted")
+)
+
+//CompressFilesWithPassword 压缩文件并使用WinZip AES-256加密每个条目，目录结构与CompressFiles一致
+//files 文件数组，可以是不同dir下的文件或者文件夹
+//dest 压缩文件存放地址
+func CompressFilesWithPassword(files []*os.File, dest string, password string) error {
+	if password == "" {
+		return errors.New("ziputils: password can not be empty")
+	}
+	// 先写入临时文件，失败时不会在dest留下不完整的压缩包
+	return fileutils.WriteFileAtomic(dest, func(w io.Writer) error {
+		return CompressFilesTo(w, files, StreamOptions{Password: password})
+	}, fileutils.WriteOptions{CreateDir: true})
+}
+
+//CompressDirWithPassword 压缩目录并使用WinZip AES-256加密每个条目，目录结构与CompressDir一致
+func CompressDirWithPassword(dirPath string, dest string, password string) error {
+	f, err := os.Open(dirPath)
+	if err != nil {
+		return err
+	}
+	return CompressFilesWithPassword([]*os.File{f}, dest, password)
+}
+
+//DeCompressWithPassword 解压加密的zip，支持WinZip AES（128/192/256）与传统ZipCrypto加密，未加密的条目正常解压
+//口令错误返回ErrWrongPassword，数据被篡改返回ErrAuthFailed
+//opts 为非必需参数，同DeCompress
+func DeCompressWithPassword(zipFile string, dest string, password string, opts ...ExtractOptions) error {
+	reader, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return extract(reader.File, dest, extractOptions(opts), func(file *zip.File) (io.ReadCloser, error) {
+		return openWithPassword(file, password)
+	})
+}
+
+func openPlain(file *zip.File) (io.ReadCloser, error) {
+	if file.Flags&flagEncrypted != 0 {
+		return nil, ErrPasswordRequired
+	}
+	return file.Open()
+}
+
+func openWithPassword(file *zip.File, password string) (io.ReadCloser, error) {
+	if file.Flags&flagEncrypted == 0 {
+		return file.Open()
+	}
+	raw, err := file.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	if file.Method == methodWinZipAES {
+		return openWinZipAES(file, raw, password)
+	}
+	return openZipCrypto(file, raw, password)
+}
+
+// =================== WinZip AES ======================
+func openWinZipAES(file *zip.File, raw io.Reader, password string) (io.ReadCloser, error) {
+	version, strength, method, err := parseWinZipAESExtra(file.Extra)
+	if err != nil {
+		return nil, err
+	}
+	keyLen := 8 + 8*int(strength)
+	saltLen := keyLen / 2
+	dataLen := int64(file.CompressedSize64) - int64(saltLen+2+winZipAESMacSize)
+	if dataLen < 0 {
+		return nil, ErrAuthFailed
+	}
+	header := make([]byte, saltLen+2)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys := securityutils.Pbkdf2Sha1([]byte(password), header[:saltLen], winZipAESIter, 2*keyLen+2)
+	if subtle.ConstantTimeCompare(keys[2*keyLen:], header[saltLen:]) != 1 {
+		return nil, ErrWrongPassword
+	}
+	block, err := aes.NewCipher(keys[:keyLen])
+	if err != nil {
+		return nil, err
+	}
+	ar := &winZipAESReader{
+		r:    io.LimitReader(raw, dataLen),
+		tail: raw,
+		ctr:  newWinZipCTR(block),
+		mac:  hmac.New(sha1.New, keys[keyLen:2*keyLen]),
+	}
+	var crc uint32
+	checkCRC := version == 1 // AE-1记录CRC32，AE-2为0
+	if checkCRC {
+		crc = file.CRC32
+	}
+	return newEntryReader(ar, method, checkCRC, crc)
+}
+
+func parseWinZipAESExtra(extra []byte) (version uint16, strength byte, method uint16, err error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == winZipAESExtraID && size >= 7 {
+			version = binary.LittleEndian.Uint16(extra)
+			strength = extra[4]
+			method = binary.LittleEndian.Uint16(extra[5:])
+			if strength < 1 || strength > 3 {
+				return 0, 0, 0, errors.New("ziputils: invalid WinZip AES strength")
+			}
+			return version, strength, method, nil
+		}
+		extra = extra[size:]
+	}
+	return 0, 0, 0, errors.New("ziputils: WinZip AES extra field not found")
+}
+
+// WinZip AES使用小端计数器的CTR模式，计数器从1开始
+type winZipCTR struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	pos     int
+}
+
+func newWinZipCTR(block cipher.Block) *winZipCTR {
+	return &winZipCTR{block: block, pos: aes.BlockSize}
+}
+
+func (c *winZipCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if c.pos == aes.BlockSize {
+			for j := range c.counter {
+				c.counter[j]++
+				if c.counter[j] != 0 {
+					break
+				}
+			}
+			c.block.Encrypt(c.stream[:], c.counter[:])
+			c.pos = 0
+		}
+		dst[i] = src[i] ^ c.stream[c.pos]
+		c.pos++
+	}
+}
+
+type winZipAESReader struct {
+	r        io.Reader
+	tail     io.Reader
+	ctr      *winZipCTR
+	mac      hash.Hash
+	verified bool
+}
+
+func (r *winZipAESReader) Read(p []byte) (int, error) {
+	if r.verified {
+		// 已完成HMAC校验，重复读取时直接返回EOF
+		return 0, io.EOF
+	}
+	n, err := r.r.Read(p)
+	r.mac.Write(p[:n])
+	r.ctr.XORKeyStream(p[:n], p[:n])
+	if err == io.EOF {
+		tag := make([]byte, winZipAESMacSize)
+		if _, terr := io.ReadFull(r.tail, tag); terr != nil {
+			return n, ErrAuthFailed
+		}
+		if !hmac.Equal(tag, r.mac.Sum(nil)[:winZipAESMacSize]) {
+			return n, ErrAuthFailed
+		}
+		r.verified = true
+	}
+	return n, err
+}
+
+type winZipAESWriter struct {
+	w   io.Writer
+	ctr *winZipCTR
+	mac hash.Hash
+	buf []byte
+}
+
+func (w *winZipAESWriter) Write(p []byte) (int, error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	buf := w.buf[:len(p)]
+	w.ctr.XORKeyStream(buf, p)
+	w.mac.Write(buf)
+	return w.w.Write(buf)
+}
+
+// 创建WinZip AES加密条目，header.Method为实际压缩方式
+// 返回的finish必须在写入数据后、创建下一个条目前调用，用于写入HMAC并回填大小
+func createWinZipAES(zw *zip.Writer, header *zip.FileHeader, password string, level int) (io.Writer, func() error, error) {
+	method := header.Method
+	if method != zip.Store && method != zip.Deflate {
+		return nil, nil, zip.ErrAlgorithm
+	}
+	var extra [11]byte
+	binary.LittleEndian.PutUint16(extra[0:], winZipAESExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], winZipAESVersion)
+	copy(extra[6:], "AE")
+	extra[8] = winZipAES256
+	binary.LittleEndian.PutUint16(extra[9:], method)
+	header.Extra = append(header.Extra, extra[:]...)
+	if header.Modified.IsZero() {
+		header.Modified = time.Now()
+	}
+	setRawHeaderTime(header)
+	if utf8.ValidString(header.Name) && !isASCII(header.Name) {
+		header.Flags |= flagUTF8
+	}
+	header.Method = methodWinZipAES
+	header.Flags |= flagEncrypted | flagDataDescriptor
+	header.CRC32 = 0
+	header.CompressedSize64, header.UncompressedSize64 = 0, 0
+
+	raw, err := zw.CreateRaw(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	const keyLen = 32
+	salt, err := securityutils.RandomBytes(keyLen / 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := securityutils.Pbkdf2Sha1([]byte(password), salt, winZipAESIter, 2*keyLen+2)
+	block, err := aes.NewCipher(keys[:keyLen])
+	if err != nil {
+		return nil, nil, err
+	}
+	cw := &countWriter{w: raw}
+	if _, err := cw.Write(append(salt, keys[2*keyLen:]...)); err != nil {
+		return nil, nil, err
+	}
+	aw := &winZipAESWriter{w: cw, ctr: newWinZipCTR(block), mac: hmac.New(sha1.New, keys[keyLen:2*keyLen])}
+	var comp io.WriteCloser = nopWriteCloser{aw}
+	if method == zip.Deflate {
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		if comp, err = flate.NewWriter(aw, level); err != nil {
+			return nil, nil, err
+		}
+	}
+	uw := &countWriter{w: comp}
+	finish := func() error {
+		if err := comp.Close(); err != nil {
+			return err
+		}
+		if _, err := cw.Write(aw.mac.Sum(nil)[:winZipAESMacSize]); err != nil {
+			return err
+		}
+		// CreateRaw保存了header指针，数据描述符与中央目录在下一个条目或Close时按回填后的值写入
+		header.CompressedSize64 = uint64(cw.n)
+		header.UncompressedSize64 = uint64(uw.n)
+		if header.CompressedSize64 >= uint32max || header.UncompressedSize64 >= uint32max {
+			header.CompressedSize, header.UncompressedSize = uint32max, uint32max
+		} else {
+			header.CompressedSize, header.UncompressedSize = uint32(header.CompressedSize64), uint32(header.UncompressedSize64)
+		}
+		return nil
+	}
+	return uw, finish, nil
+}
+
+// CreateRaw不会像CreateHeader一样处理Modified，需手动写入DOS时间与扩展时间戳
+func setRawHeaderTime(header *zip.FileHeader) {
+	t := header.Modified
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	header.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	header.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	var ext [9]byte
+	binary.LittleEndian.PutUint16(ext[0:], extTimeExtraID)
+	binary.LittleEndian.PutUint16(ext[2:], 5)
+	ext[4] = 1 // 仅包含修改时间
+	binary.LittleEndian.PutUint32(ext[5:], uint32(header.Modified.Unix()))
+	header.Extra = append(header.Extra, ext[:]...)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// =================== ZipCrypto ======================
+// 传统PKWARE加密，安全性很弱，仅支持解密以兼容旧压缩包
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Byte(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Byte(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) decrypt(p []byte) {
+	for i, c := range p {
+		temp := k[2]&0xffff | 2
+		p[i] = c ^ byte((temp*(temp^1))>>8)
+		k.update(p[i])
+	}
+}
+
+func crc32Byte(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.keys.decrypt(p[:n])
+	return n, err
+}
+
+func openZipCrypto(file *zip.File, raw io.Reader, password string) (io.ReadCloser, error) {
+	if file.CompressedSize64 < zipCryptoHeaderLen {
+		return nil, ErrAuthFailed
+	}
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, zipCryptoHeaderLen)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys.decrypt(header)
+	// 加密头最后一个字节为CRC32高位，使用数据描述符时为修改时间高位
+	check := byte(file.CRC32 >> 24)
+	if file.Flags&flagDataDescriptor != 0 {
+		check = byte(file.ModifiedTime >> 8)
+	}
+	if header[zipCryptoHeaderLen-1] != check {
+		return nil, ErrWrongPassword
+	}
+	r := &zipCryptoReader{r: io.LimitReader(raw, int64(file.CompressedSize64)-zipCryptoHeaderLen), keys: keys}
+	return newEntryReader(r, file.Method, true, file.CRC32)
+}
+
+// 解压条目数据，读到结尾时校验CRC32，并确保底层数据被完整读取以完成HMAC校验
+func newEntryReader(r io.Reader, method uint16, checkCRC bool, crc uint32) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	switch method {
+	case zip.Store:
+		rc = ioutil.NopCloser(r)
+	case zip.Deflate:
+		rc = flate.NewReader(r)
+	default:
+		return nil, zip.ErrAlgorithm
+	}
+	return &entryReader{rc: rc, src: r, hash: crc32.NewIEEE(), checkCRC: checkCRC, crc: crc}, nil
+}
+
+type entryReader struct {
+	rc       io.ReadCloser
+	src      io.Reader
+	hash     hash.Hash32
+	checkCRC bool
+	crc      uint32
+}
+
+func (r *entryReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	r.hash.Write(p[:n])
+	if err != nil && err != io.EOF {
+		// 解压出错时优先报告认证失败，避免将篡改的数据报告为格式错误
+		if _, derr := io.Copy(ioutil.Discard, r.src); derr != nil {
+			return n, derr
+		}
+		return n, err
+	}
+	if err == io.EOF {
+		// deflate流结束时底层数据可能尚未读完，继续读取以触发认证校验
+		if _, derr := io.Copy(ioutil.Discard, r.src); derr != nil {
+			return n, derr
+		}
+		if r.checkCRC && r.hash.Sum32() != r.crc {
+			return n, ErrAuthFailed
+		}
+	}
+	return n, err
+}
+
+func (r *entryReader) Close() error {
+	return r.rc.Close()
+}
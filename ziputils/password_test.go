@@ -0,0 +1,384 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youngchan1988/gocommon/securityutils"
+)
+
+// Info-ZIP 3.0 生成的ZipCrypto压缩包，口令为secret：
+// zip -X -r -P secret fixture.zip .（a.txt为Store，sub/b.txt为Deflate，均使用数据描述符）
+const zipCryptoFixture = `
+UEsDBAoAAAAAAIMYIlAAAAAAAAAAAAAAAAAGAAAAZW1wdHkvUEsDBAoAAAAAAIMYIlAAAAAA
+AAAAAAAAAAAEAAAAc3ViL1BLAwQUAAkACACDGCJQahzQQx4AAAC4AQAACQAAAHN1Yi9iLnR4
+dGhqyBroa9ziED4NFOCurNuBGuBe5xJhTFITzzQ6llBLBwhqHNBDHgAAALgBAABQSwMECgAJ
+AAAAgxgiUK6WkbYcAAAAEAAAAAUAAABhLnR4dGhN522lzlMpZpbOh+VP8A0YDsTV8xenuqML
+NRxQSwcIrpaRthwAAAAQAAAAUEsBAh4DCgAAAAAAgxgiUAAAAAAAAAAAAAAAAAYAAAAAAAAA
+AAAQAO1BAAAAAGVtcHR5L1BLAQIeAwoAAAAAAIMYIlAAAAAAAAAAAAAAAAAEAAAAAAAAAAAA
+EADtQSQAAABzdWIvUEsBAh4DFAAJAAgAgxgiUGoc0EMeAAAAuAEAAAkAAAAAAAAAAQAAAKSB
+RgAAAHN1Yi9iLnR4dFBLAQIeAwoACQAAAIMYIlCulpG2HAAAABAAAAAFAAAAAAAAAAEAAACk
+gZsAAABhLnR4dFBLBQYAAAAABAAEANAAAADqAAAAAAA=`
+
+// Info-ZIP 3.0 从标准输入流式生成的zip64 ZipCrypto压缩包，口令为secret，条目名为-
+const zipCryptoStreamFixture = `
+UEsDBC0ACQAAAN2BU12ulpG2//////////8BABQALQEAEAAQAAAAAAAAABwAAAAAAAAAdrdT
+mxK3Qw6oB3iNa2kSMU/fJHkIufLbraKwQFBLBwiulpG2HAAAAAAAAAAQAAAAAAAAAFBLAQIe
+Ay0ACQAAAN2BU12ulpG2HAAAABAAAAABAAAAAAAAAAEAAACAEQAAAAAtUEsGBiwAAAAAAAAA
+HgMtAAAAAAAAAAAAAQAAAAAAAAABAAAAAAAAAC8AAAAAAAAAZwAAAAAAAABQSwYHAAAAAJYA
+AAAAAAAAAQAAAFBLBQYAAAAAAQABAC8AAABnAAAAAAA=`
+
+// 将base64编码的压缩包写入临时文件
+func writeFixture(t *testing.T, fixture string) string {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(strings.Replace(fixture, "\n", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "fixture.zip")
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDeCompressZipCrypto(t *testing.T) {
+	archive := writeFixture(t, zipCryptoFixture)
+	out := filepath.Join(t.TempDir(), "out")
+	if err := DeCompressWithPassword(archive, out, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, out, map[string]string{
+		"a.txt":     "hello zipcrypto\n",
+		"sub/b.txt": strings.Repeat("deflate me ", 40),
+		"empty/":    "",
+	})
+
+	if err := DeCompressWithPassword(archive, filepath.Join(t.TempDir(), "out"), "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("wrong password: got %v, want ErrWrongPassword", err)
+	}
+	if err := DeCompress(archive, filepath.Join(t.TempDir(), "out")); !errors.Is(err, ErrPasswordRequired) {
+		t.Fatalf("no password: got %v, want ErrPasswordRequired", err)
+	}
+
+	stream := writeFixture(t, zipCryptoStreamFixture)
+	got, err := ReadEntry(stream, "-", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello zipcrypto\n" {
+		t.Fatalf("stream entry: got %q", got)
+	}
+}
+
+// 按WinZip AES规范独立实现的解密，用于校验写入结果
+func decryptWinZipAESSpec(t *testing.T, f *zip.File, password string) []byte {
+	t.Helper()
+	if f.Method != 99 || f.Flags&0x1 == 0 {
+		t.Fatalf("%s: method %d flags %#x, want method 99 with encryption flag", f.Name, f.Method, f.Flags)
+	}
+	if f.CRC32 != 0 {
+		t.Fatalf("%s: AE-2 entry must not record CRC32, got %#x", f.Name, f.CRC32)
+	}
+	extra := f.Extra
+	var field []byte
+	for len(extra) >= 4 {
+		id, size := binary.LittleEndian.Uint16(extra), int(binary.LittleEndian.Uint16(extra[2:]))
+		if id == 0x9901 {
+			field = extra[4 : 4+size]
+			break
+		}
+		extra = extra[4+size:]
+	}
+	if len(field) != 7 || binary.LittleEndian.Uint16(field) != 2 || string(field[2:4]) != "AE" || field[4] != 3 {
+		t.Fatalf("%s: unexpected AES extra field %x", f.Name, field)
+	}
+	method := binary.LittleEndian.Uint16(field[5:])
+
+	rr, err := f.OpenRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// AES-256：salt 16字节，口令校验值2字节，HMAC 10字节
+	salt, verifier, data, tag := raw[:16], raw[16:18], raw[18:len(raw)-10], raw[len(raw)-10:]
+	keys := securityutils.Pbkdf2Sha1([]byte(password), salt, 1000, 66)
+	if !bytes.Equal(keys[64:], verifier) {
+		t.Fatalf("%s: password verifier mismatch", f.Name)
+	}
+	mac := hmac.New(sha1.New, keys[32:64])
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil)[:10], tag) {
+		t.Fatalf("%s: HMAC mismatch", f.Name)
+	}
+	block, err := aes.NewCipher(keys[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make([]byte, len(data))
+	var counter, stream [16]byte
+	for i := 0; i < len(data); i += 16 {
+		// 计数器为小端序，从1开始
+		binary.LittleEndian.PutUint64(counter[:8], uint64(i/16+1))
+		block.Encrypt(stream[:], counter[:])
+		for j := i; j < len(data) && j < i+16; j++ {
+			plain[j] = data[j] ^ stream[j-i]
+		}
+	}
+	if method == zip.Store {
+		return plain
+	}
+	if method != zip.Deflate {
+		t.Fatalf("%s: unexpected method %d", f.Name, method)
+	}
+	out, err := io.ReadAll(flate.NewReader(bytes.NewReader(plain)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestWinZipAESWriteSpec(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/a.txt":     "aaa",
+		"src/sub/b.txt": strings.Repeat("winzip aes ", 1000),
+		"src/img.png":   "not really a png",
+		"src/空.txt":     "",
+	}
+	writeTree(t, dir, files)
+	var buf bytes.Buffer
+	if err := CompressDirTo(&buf, filepath.Join(dir, "src"), StreamOptions{Password: "p@ss", Method: MethodByExt}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		want, ok := files[f.Name]
+		if !ok {
+			t.Fatalf("unexpected entry %s", f.Name)
+		}
+		if got := decryptWinZipAESSpec(t, f, "p@ss"); string(got) != want {
+			t.Fatalf("%s: got %q, want %q", f.Name, got, want)
+		}
+		n++
+	}
+	if n != len(files) {
+		t.Fatalf("got %d files, want %d", n, len(files))
+	}
+
+	// Store与Deflate条目均可由DeCompressWithPassword还原
+	archive := filepath.Join(dir, "aes.zip")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if err := DeCompressWithPassword(archive, out, "p@ss"); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, out, files)
+}
+
+// 按WinZip AES规范独立实现的写入（Store），用于校验读取AE-1/AE-2及不同密钥长度
+func writeWinZipAESSpec(t *testing.T, zw *zip.Writer, name string, data []byte, password string, strength byte, version uint16) {
+	t.Helper()
+	keyLen := 8 + 8*int(strength)
+	salt, err := securityutils.RandomBytes(keyLen / 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := securityutils.Pbkdf2Sha1([]byte(password), salt, 1000, 2*keyLen+2)
+	block, err := aes.NewCipher(keys[:keyLen])
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := make([]byte, len(data))
+	var counter, stream [16]byte
+	for i := 0; i < len(data); i += 16 {
+		binary.LittleEndian.PutUint64(counter[:8], uint64(i/16+1))
+		block.Encrypt(stream[:], counter[:])
+		for j := i; j < len(data) && j < i+16; j++ {
+			enc[j] = data[j] ^ stream[j-i]
+		}
+	}
+	mac := hmac.New(sha1.New, keys[keyLen:2*keyLen])
+	mac.Write(enc)
+	body := append(append(append(salt, keys[2*keyLen:]...), enc...), mac.Sum(nil)[:10]...)
+
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra, 0x9901)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], version)
+	copy(extra[6:], "AE")
+	extra[8] = strength
+	binary.LittleEndian.PutUint16(extra[9:], zip.Store)
+	header := &zip.FileHeader{
+		Name:               name,
+		Method:             99,
+		Flags:              0x1,
+		Extra:              extra,
+		CompressedSize64:   uint64(len(body)),
+		UncompressedSize64: uint64(len(data)),
+	}
+	if version == 1 {
+		header.CRC32 = crc32.ChecksumIEEE(data)
+	}
+	w, err := zw.CreateRaw(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWinZipAESReadSpec(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "aes.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	data := []byte(strings.Repeat("0123456789abcdef", 5) + "tail")
+	for _, strength := range []byte{1, 2, 3} {
+		for _, version := range []uint16{1, 2} {
+			name := string([]byte{'a', '0' + strength, '-', byte('0' + version)})
+			writeWinZipAESSpec(t, zw, name, data, "secret", strength, version)
+		}
+	}
+	w, err := zw.Create("plain.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("plain"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	for _, name := range []string{"a1-1", "a1-2", "a2-1", "a2-2", "a3-1", "a3-2"} {
+		got, err := ReadEntry(archive, name, "secret")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%s: got %q", name, got)
+		}
+		if _, err := ReadEntry(archive, name, "wrong"); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("%s: wrong password: got %v", name, err)
+		}
+		if _, err := ReadEntry(archive, name); !errors.Is(err, ErrPasswordRequired) {
+			t.Fatalf("%s: no password: got %v", name, err)
+		}
+	}
+	// 未加密的条目在提供口令时也能正常读取
+	if got, err := ReadEntry(archive, "plain.txt", "secret"); err != nil || string(got) != "plain" {
+		t.Fatalf("plain.txt: got %q, %v", got, err)
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	if err := DeCompressWithPassword(archive, out, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, out, map[string]string{"a3-2": string(data), "plain.txt": "plain"})
+}
+
+func TestWinZipAESTampered(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"src/a.txt": strings.Repeat("tamper ", 100)})
+	var buf bytes.Buffer
+	if err := CompressDirTo(&buf, filepath.Join(dir, "src"), StreamOptions{Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry *zip.File
+	for _, f := range zr.File {
+		if f.Name == "src/a.txt" {
+			entry = f
+		}
+	}
+	offset, err := entry.DataOffset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 跳过salt(16)与口令校验值(2)，修改第一个密文字节
+	data[offset+18] ^= 0xff
+	archive := filepath.Join(dir, "tampered.zip")
+	if err := os.WriteFile(archive, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadEntry(archive, "src/a.txt", "secret"); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	if err := DeCompressWithPassword(archive, filepath.Join(dir, "out"), "secret"); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestCompressFilesWithPasswordFailure(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.txt": "aaa", "b.txt": "bbb"})
+	dest := filepath.Join(dir, "out.zip")
+	if err := os.WriteFile(dest, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := os.Open(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.Open(filepath.Join(dir, "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+	// 写入a.txt后读取已关闭的b.txt失败，dest保持原内容
+	if err := CompressFilesWithPassword([]*os.File{a, b}, dest, "secret"); err == nil {
+		t.Fatal("expected error for closed file")
+	}
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "old" {
+		t.Fatalf("dest changed after failed compression: %q, %v", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Fatalf("temporary file left: %s", e.Name())
+		}
+	}
+
+	missing := filepath.Join(dir, "sub", "new.zip")
+	a, _ = os.Open(filepath.Join(dir, "a.txt"))
+	if err := CompressFilesWithPassword([]*os.File{a}, missing, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadEntry(missing, "a.txt", "secret"); err != nil || string(got) != "aaa" {
+		t.Fatalf("ReadEntry: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,248 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 压缩方式
+const (
+	Store   = zip.Store   //仅存储，不压缩
+	Deflate = zip.Deflate //deflate压缩
+)
+
+//StreamOptions 流式压缩参数，零值字段使用默认值
+type StreamOptions struct {
+	//Filter 过滤AddFile、AddDir添加的文件，返回false时忽略该文件，目录返回false时忽略整个目录
+	Filter func(filePath string, info os.FileInfo) bool
+	//Rename 修改AddFile、AddDir添加的条目名称，参数为默认名称，返回空字符串时忽略该文件
+	Rename func(name string) string
+	//Method 根据条目名称选择压缩方式，默认Deflate，可使用MethodByExt
+	Method func(name string) uint16
+	//Level deflate压缩级别，1~9，默认flate.DefaultCompression
+	Level int
+	//Password 不为空时使用WinZip AES-256加密每个条目
+	Password string
+}
+
+//ZipStream 流式写入zip，直接写到io.Writer（如http.ResponseWriter），不落地临时文件
+type ZipStream struct {
+	zw *zip.Writer
+	o  StreamOptions
+}
+
+//NewZipStream 创建流式zip，opts为非必需参数
+//写入完成后必须调用Close，Close不会关闭w
+func NewZipStream(w io.Writer, opts ...StreamOptions) *ZipStream {
+	s := &ZipStream{zw: zip.NewWriter(w)}
+	if len(opts) > 0 {
+		s.o = opts[0]
+	}
+	if s.o.Level != 0 {
+		level := s.o.Level
+		s.zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, level)
+		})
+	}
+	return s
+}
+
+//AddReader 从r读取内容写入名称为name的条目，不经过Filter和Rename
+//modTime 为零值时使用当前时间；method为非必需参数，优先于StreamOptions.Method
+func (s *ZipStream) AddReader(name string, r io.Reader, modTime time.Time, method ...uint16) error {
+	name = streamEntryName(name)
+	if name == "" || strings.HasSuffix(name, "/") {
+		return errors.New("ziputils: invalid entry name")
+	}
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	header := &zip.FileHeader{Name: name, Modified: modTime, Method: s.method(name, method)}
+	header.SetMode(0644)
+	return s.write(header, r)
+}
+
+//AddFile 添加单个文件，name为非必需参数，默认为文件名
+func (s *ZipStream) AddFile(filePath string, name ...string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return errors.New("ziputils: " + filePath + " is a directory")
+	}
+	if s.o.Filter != nil && !s.o.Filter(filePath, info) {
+		return nil
+	}
+	entryName := info.Name()
+	if len(name) > 0 && name[0] != "" {
+		entryName = name[0]
+	}
+	return s.addFile(filePath, info, entryName)
+}
+
+//AddDir 添加目录，prefix为非必需参数，默认为目录名，与CompressDir的目录结构一致
+//prefix为空字符串时目录下的文件直接位于zip根目录
+func (s *ZipStream) AddDir(dirPath string, prefix ...string) error {
+	root := filepath.Clean(dirPath)
+	base := filepath.Base(root)
+	if len(prefix) > 0 {
+		base = prefix[0]
+	}
+	return filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			// 跟随指向文件的链接，忽略指向目录的链接以免循环
+			if info, err = os.Stat(filePath); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+		if filePath != root && s.o.Filter != nil && !s.o.Filter(filePath, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			// 根目录条目，prefix为空字符串时不写入
+			return s.addFile(filePath, info, base)
+		}
+		return s.addFile(filePath, info, path.Join(base, filepath.ToSlash(rel)))
+	})
+}
+
+//Flush 将已写入的数据刷新到底层io.Writer
+func (s *ZipStream) Flush() error {
+	return s.zw.Flush()
+}
+
+//Close 写入zip目录结构，不会关闭底层io.Writer
+func (s *ZipStream) Close() error {
+	return s.zw.Close()
+}
+
+func (s *ZipStream) addFile(filePath string, info os.FileInfo, name string) error {
+	if s.o.Rename != nil {
+		name = s.o.Rename(name)
+	}
+	name = strings.TrimRight(streamEntryName(name), "/")
+	if name == "" {
+		return nil
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		//写入目录条目，保留空目录及目录的权限和修改时间，目录条目不加密
+		header.Name += "/"
+		_, err := s.zw.CreateHeader(header)
+		return err
+	}
+	header.Method = s.method(name, nil)
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.write(header, f)
+}
+
+func (s *ZipStream) write(header *zip.FileHeader, r io.Reader) error {
+	if s.o.Password == "" {
+		w, err := s.zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, r)
+		return err
+	}
+	w, finish, err := createWinZipAES(s.zw, header, s.o.Password, s.o.Level)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+	return finish()
+}
+
+func (s *ZipStream) method(name string, method []uint16) uint16 {
+	if len(method) > 0 {
+		return method[0]
+	}
+	if s.o.Method != nil {
+		return s.o.Method(name)
+	}
+	return Deflate
+}
+
+// zip条目名称使用/分隔且不以/开头
+func streamEntryName(name string) string {
+	return strings.TrimLeft(strings.Replace(name, `\`, "/", -1), "/")
+}
+
+// 已压缩格式，再次deflate基本没有收益
+var storedExts = map[string]bool{
+	".zip": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".7z": true, ".rar": true,
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".heic": true,
+	".mp3": true, ".aac": true, ".ogg": true, ".mp4": true, ".mov": true, ".mkv": true, ".webm": true,
+	".docx": true, ".xlsx": true, ".pptx": true, ".apk": true, ".jar": true,
+}
+
+//MethodByExt 已压缩的文件格式（图片、音视频、压缩包等）使用Store，其余使用Deflate，可用作StreamOptions.Method
+func MethodByExt(name string) uint16 {
+	if storedExts[strings.ToLower(path.Ext(name))] {
+		return Store
+	}
+	return Deflate
+}
+
+//CompressFilesTo 压缩文件并写入w，files可以是不同dir下的文件或者文件夹，目录结构与CompressFiles一致
+//opts 为非必需参数；写入完成后会关闭files，但不会关闭w
+func CompressFilesTo(w io.Writer, files []*os.File, opts ...StreamOptions) error {
+	s := NewZipStream(w, opts...)
+	for _, file := range files {
+		info, err := file.Stat()
+		name := file.Name()
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			err = s.AddDir(name)
+		} else {
+			err = s.AddFile(name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.Close()
+}
+
+//CompressDirTo 压缩目录并写入w，目录结构与CompressDir一致，opts为非必需参数
+func CompressDirTo(w io.Writer, dirPath string, opts ...StreamOptions) error {
+	s := NewZipStream(w, opts...)
+	if err := s.AddDir(dirPath); err != nil {
+		return err
+	}
+	return s.Close()
+}
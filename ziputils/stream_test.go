@@ -0,0 +1,132 @@
+package ziputils
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// 读取zip中的条目名称，按名称排序
+func zipNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var streamTree = map[string]string{"a.txt": "aaa", "sub/b.txt": "bbb", "empty/": "", "sub/inner/": ""}
+
+func TestCompressDirToMatchesCompressDir(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTree(t, src, streamTree)
+
+	dest := filepath.Join(dir, "src.zip")
+	if err := CompressDir(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := zipNames(t, data)
+
+	var buf bytes.Buffer
+	if err := CompressDirTo(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	got := zipNames(t, buf.Bytes())
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CompressDirTo entries %v, CompressDir entries %v", got, want)
+	}
+	for _, name := range []string{"src/", "src/empty/", "src/sub/inner/"} {
+		if i := sort.SearchStrings(got, name); i == len(got) || got[i] != name {
+			t.Fatalf("missing directory entry %s in %v", name, got)
+		}
+	}
+
+	out := filepath.Join(dir, "out")
+	if err := os.WriteFile(dest, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeCompress(dest, out); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, filepath.Join(out, "src"), streamTree)
+}
+
+func TestAddDirPrefix(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, streamTree)
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"a.txt", "empty/", "sub/", "sub/b.txt", "sub/inner/"}},
+		{"root", []string{"root/", "root/a.txt", "root/empty/", "root/sub/", "root/sub/b.txt", "root/sub/inner/"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		s := NewZipStream(&buf)
+		if err := s.AddDir(dir, tt.prefix); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := zipNames(t, buf.Bytes()); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("prefix %q: got %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestAddDirFilterRename(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, streamTree)
+	var buf bytes.Buffer
+	s := NewZipStream(&buf, StreamOptions{
+		Filter: func(filePath string, info os.FileInfo) bool { return info.Name() != "sub" },
+		Rename: func(name string) string {
+			if name == "x/empty" {
+				return ""
+			}
+			return name
+		},
+	})
+	if err := s.AddDir(dir, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"x/", "x/a.txt"}
+	if got := zipNames(t, buf.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompressDirWithPasswordKeepsEmptyDirs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTree(t, src, streamTree)
+	dest := filepath.Join(dir, "src.zip")
+	if err := CompressDirWithPassword(src, dest, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if err := DeCompressWithPassword(dest, out, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, filepath.Join(out, "src"), streamTree)
+}
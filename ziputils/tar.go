@@ -0,0 +1,91 @@
+package ziputils
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func compressTar(file *os.File, prefix string, tw *tar.Writer) error {
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = strings.TrimPrefix(prefix+"/"+header.Name, "/")
+	if info.IsDir() {
+		header.Name += "/"
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		fileInfos, err := file.Readdir(-1)
+		if err != nil {
+			return err
+		}
+		for _, fi := range fileInfos {
+			childPath := filepath.Join(file.Name(), fi.Name())
+			if fi.Mode()&os.ModeSymlink != 0 {
+				// 跟随指向文件的链接，忽略指向目录的链接以免循环，与ZipStream.AddDir一致
+				if fi, err = os.Stat(childPath); err != nil || fi.IsDir() {
+					continue
+				}
+			}
+			if !fi.IsDir() && !fi.Mode().IsRegular() {
+				continue
+			}
+			f, err := os.Open(childPath)
+			if err != nil {
+				return err
+			}
+			if err := compressTar(f, header.Name[:len(header.Name)-1], tw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// 逐个解压tar条目，设备文件、管道等特殊条目会被忽略
+func extractTar(tr *tar.Reader, e *extractor) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return e.finish()
+		}
+		if err != nil {
+			return err
+		}
+		if e.match != nil && !e.match(header.Name) {
+			continue
+		}
+		if err := e.next(); err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.dir(header.Name, entryMeta{mode: header.FileInfo().Mode(), modTime: header.ModTime})
+		case tar.TypeSymlink:
+			err = e.symlink(header.Name, func() (string, error) { return header.Linkname, nil })
+		case tar.TypeLink:
+			err = e.link(header.Name, header.Linkname)
+		default:
+			if header.FileInfo().Mode().IsRegular() {
+				err = e.file(header.Name, tr, -1, entryMeta{mode: header.FileInfo().Mode(), modTime: header.ModTime})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
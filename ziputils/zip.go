@@ -120,12 +120,22 @@ func CompressDirTemp(dirPath string, dest string) (string, error) {
 }
 
 func compress(file *os.File, prefix string, zw *zip.Writer) error {
+	defer file.Close()
 	info, err := file.Stat()
 	if err != nil {
 		return err
 	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = strings.TrimPrefix(prefix+"/"+header.Name, "/")
 	if info.IsDir() {
-		prefix = prefix + "/" + info.Name()
+		//写入目录条目，保留空目录及目录的权限和修改时间
+		header.Name += "/"
+		if _, err := zw.CreateHeader(header); err != nil {
+			return err
+		}
 		fileInfos, err := file.Readdir(-1)
 		if err != nil {
 			return err
@@ -135,78 +145,23 @@ func compress(file *os.File, prefix string, zw *zip.Writer) error {
 			if err != nil {
 				return err
 			}
-			err = compress(f, prefix, zw)
-			if err != nil {
+			if err := compress(f, header.Name[:len(header.Name)-1], zw); err != nil {
 				return err
 			}
 		}
-	} else {
-		header, err := zip.FileInfoHeader(info)
-		header.Name = prefix + "/" + header.Name
-		if err != nil {
-			return err
-		}
-		writer, err := zw.CreateHeader(header)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(writer, file)
-		file.Close()
-		if err != nil {
-			return err
-		}
+		return nil
 	}
-	return nil
-}
-
-//DeCompress 解压
-func DeCompress(zipFile, dest string) error {
-	reader, err := zip.OpenReader(zipFile)
+	writer, err := zw.CreateHeader(header)
 	if err != nil {
 		return err
 	}
-	defer reader.Close()
-	for _, file := range reader.File {
-		rc, err := file.Open()
-		if err != nil {
-			return err
-		}
-		defer rc.Close()
-		filename := dest + file.Name
-		err = os.MkdirAll(getDir(filename), 0755)
-		if err != nil {
-			return err
-		}
-		w, err := os.Create(filename)
-		if err != nil {
-			return err
-		}
-		defer w.Close()
-		_, err = io.Copy(w, rc)
-		if err != nil {
-			return err
-		}
-		w.Close()
-		rc.Close()
-	}
-	return nil
+	_, err = io.Copy(writer, file)
+	return err
 }
 
-func getDir(path string) string {
-	return subString(path, 0, strings.LastIndex(path, "/"))
-}
-
-func subString(str string, start, end int) string {
-	rs := []rune(str)
-	length := len(rs)
-
-	if start < 0 || start > length {
-		panic("start is wrong")
-	}
-
-	if end < start || end > length {
-		panic("end is wrong")
-	}
-
-	return string(rs[start:end])
+//DeCompress 解压到dest目录，根据文件头自动识别zip、tar、tar.gz、tar.bz2、tar.zst以及单文件gz、zst格式
+//条目路径经过校验，含..或盘符的条目返回ErrIllegalPath，不会写到dest之外
+//opts 为非必需参数，用于限制解压大小、条目数量、压缩比以及符号链接的处理方式，默认见ExtractOptions
+func DeCompress(zipFile, dest string, opts ...ExtractOptions) error {
+	return decompress(zipFile, dest, extractOptions(opts))
 }